@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"task-manager-api-clean/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// errInvalidBody is reported when a request body is not JSON at all.
+var errInvalidBody = domain.NewError(domain.ErrValidation, "request body is not valid JSON")
+
+// bindJSON decodes the request body into obj. Fields of the wrong type or
+// failing a binding tag are reported one by one as a domain.ValidationError,
+// named by their json tag.
+func bindJSON(ctx *gin.Context, obj interface{}) error {
+	err := ctx.ShouldBindJSON(obj)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		invalid := &domain.ValidationError{}
+		invalid.Add(typeErr.Field, domain.CodeInvalidType, fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type))
+		return invalid
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		invalid := &domain.ValidationError{}
+		for _, fieldErr := range fieldErrs {
+			name := jsonName(obj, fieldErr)
+			invalid.Add(name, fieldErr.Tag(), fmt.Sprintf("%s failed the %q rule", name, fieldErr.Tag()))
+		}
+		return invalid
+	}
+
+	return errInvalidBody
+}
+
+// jsonName returns the name a client knows the failing field by.
+func jsonName(obj interface{}, fieldErr validator.FieldError) string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if field, ok := t.FieldByName(fieldErr.StructField()); ok {
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return fieldErr.Field()
+}
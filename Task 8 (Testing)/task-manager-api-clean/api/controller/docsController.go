@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+
+	"task-manager-api-clean/api/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DocsController struct{}
+
+func NewDocsController() *DocsController {
+	return &DocsController{}
+}
+
+// GetSpec serves the OpenAPI document of the API.
+func (dc *DocsController) GetSpec(ctx *gin.Context) {
+	spec, err := openapi.JSON()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+}
+
+// GetSwaggerUI serves a Swagger UI page for the OpenAPI document.
+func (dc *DocsController) GetSwaggerUI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", openapi.SwaggerUI())
+}
+
+// GetSwaggerAsset serves a file the Swagger UI page loads.
+func (dc *DocsController) GetSwaggerAsset(ctx *gin.Context) {
+	ctx.FileFromFS(ctx.Param("asset"), http.FS(openapi.SwaggerAssets()))
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"task-manager-api-clean/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	healthUseCase domain.HealthUseCase
+	lifetime      context.Context
+}
+
+// NewHealthController reports the API as not ready once lifetime is done, so
+// traffic is routed elsewhere while the server shuts down.
+func NewHealthController(lifetime context.Context, healthUseCase domain.HealthUseCase) *HealthController {
+	return &HealthController{
+		healthUseCase: healthUseCase,
+		lifetime:      lifetime,
+	}
+}
+
+// Liveness answers as long as the process can serve requests at all.
+func (hc *HealthController) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": domain.HealthOK})
+}
+
+// Readiness checks every dependency and answers 503 unless all of them are
+// reachable.
+func (hc *HealthController) Readiness(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	if hc.lifetime.Err() != nil {
+		ctx.JSON(http.StatusServiceUnavailable, &domain.HealthReport{Status: domain.HealthShuttingDown, Checks: map[string]domain.DependencyHealth{}})
+		return
+	}
+
+	report := hc.healthUseCase.Check(ctx)
+	status := http.StatusOK
+	if report.Status != domain.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
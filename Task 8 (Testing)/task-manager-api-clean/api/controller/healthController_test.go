@@ -0,0 +1,95 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"task-manager-api-clean/api/controller"
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/domain/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthControllerTestSuite struct {
+	suite.Suite
+	useCase  *mocks.HealthUseCase
+	router   *gin.Engine
+	shutdown context.CancelFunc
+}
+
+func (suite *HealthControllerTestSuite) SetupTest() {
+	suite.useCase = new(mocks.HealthUseCase)
+	lifetime, shutdown := context.WithCancel(context.Background())
+	suite.shutdown = shutdown
+	healthController := controller.NewHealthController(lifetime, suite.useCase)
+
+	gin.SetMode(gin.TestMode)
+	suite.router = gin.Default()
+	suite.router.GET("/healthz", healthController.Liveness)
+	suite.router.GET("/readyz", healthController.Readiness)
+}
+
+func (suite *HealthControllerTestSuite) TearDownTest() {
+	suite.shutdown()
+}
+
+func (suite *HealthControllerTestSuite) serve(path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+	return resp
+}
+
+func (suite *HealthControllerTestSuite) TestLiveness() {
+	resp := suite.serve("/healthz")
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.JSONEq(`{"status":"ok"}`, resp.Body.String())
+	suite.useCase.AssertNotCalled(suite.T(), "Check", mock.Anything)
+}
+
+func (suite *HealthControllerTestSuite) TestReadiness_Ready() {
+	suite.useCase.On("Check", mock.Anything).Return(&domain.HealthReport{
+		Status: domain.HealthOK,
+		Checks: map[string]domain.DependencyHealth{"mongo": {Status: domain.HealthOK}},
+	})
+
+	resp := suite.serve("/readyz")
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.JSONEq(`{"status":"ok","checks":{"mongo":{"status":"ok"}}}`, resp.Body.String())
+}
+
+func (suite *HealthControllerTestSuite) TestReadiness_DependencyDown() {
+	suite.useCase.On("Check", mock.Anything).Return(&domain.HealthReport{
+		Status: domain.HealthUnavailable,
+		Checks: map[string]domain.DependencyHealth{"mongo": {Status: domain.HealthUnavailable}},
+	})
+
+	resp := suite.serve("/readyz")
+
+	suite.Equal(http.StatusServiceUnavailable, resp.Code)
+	suite.JSONEq(`{"status":"unavailable","checks":{"mongo":{"status":"unavailable"}}}`, resp.Body.String())
+}
+
+func (suite *HealthControllerTestSuite) TestReadiness_ShuttingDown() {
+	suite.shutdown()
+
+	resp := suite.serve("/readyz")
+
+	suite.Equal(http.StatusServiceUnavailable, resp.Code)
+	suite.JSONEq(`{"status":"shutting_down","checks":{}}`, resp.Body.String())
+	suite.useCase.AssertNotCalled(suite.T(), "Check", mock.Anything)
+
+	// The process is still alive while it drains
+	suite.Equal(http.StatusOK, suite.serve("/healthz").Code)
+}
+
+func TestHealthControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthControllerTestSuite))
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"net/http"
+
+	"task-manager-api-clean/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KeyController struct {
+	keys *utils.KeyRing
+}
+
+func NewKeyController(keys *utils.KeyRing) *KeyController {
+	return &KeyController{
+		keys: keys,
+	}
+}
+
+// GetJWKS serves the public keys access tokens can be verified with.
+func (kc *KeyController) GetJWKS(ctx *gin.Context) {
+	// Let verifiers cache the set, but not for so long that they miss a rotation
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, kc.keys.JWKS())
+}
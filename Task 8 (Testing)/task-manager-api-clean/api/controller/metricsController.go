@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"net/http"
+
+	"task-manager-api-clean/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsController struct {
+	handler http.Handler
+}
+
+func NewMetricsController(m *metrics.Metrics) *MetricsController {
+	return &MetricsController{
+		handler: m.Handler(),
+	}
+}
+
+// GetMetrics serves the metrics in the Prometheus text format.
+func (mc *MetricsController) GetMetrics(ctx *gin.Context) {
+	mc.handler.ServeHTTP(ctx.Writer, ctx.Request)
+}
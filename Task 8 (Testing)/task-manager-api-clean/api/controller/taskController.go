@@ -1,13 +1,23 @@
 package controller
 
 import (
+	"errors"
+	"io"
 	"net/http"
-	"go.mongodb.org/mongo-driver/mongo"
+	"strconv"
+	"strings"
+	"time"
 	"task-manager-api-clean/domain"
 	"task-manager-api-clean/utils"
 	"github.com/gin-gonic/gin"
 )
 
+// maxPatchSize caps a patch document, far above what a task can hold.
+const maxPatchSize = 64 << 10
+
+// acceptPatch lists the formats PATCH /tasks/:id takes.
+const acceptPatch = domain.MediaTypeMergePatch + ", " + domain.MediaTypeJSONPatch + ", " + gin.MIMEJSON
+
 type TaskController struct {
 	taskUseCase domain.TaskUseCase
 }
@@ -24,72 +34,72 @@ func (tc *TaskController) CreateTask(ctx *gin.Context) {
 	// Get authenticated user from gin context
 	user, err := utils.CheckUser(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	if user.Role != "admin" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Only the admin can create a task"})
-		return
-	}
 	// Bind JSON data to newTask
-	if err := ctx.BindJSON(&newTask); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if err := bindJSON(ctx, &newTask); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	task, err := tc.taskUseCase.Create(ctx, &newTask)
+	task, err := tc.taskUseCase.Create(ctx, user, &newTask)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
+	setTaskETag(ctx, task)
 	ctx.JSON(http.StatusCreated, gin.H{"message": "Task created", "task": task})
 }
 
 func (tc *TaskController) GetTasks(ctx *gin.Context) {
 	// Get authenticated user from gin context
-	_, err := utils.CheckUser(ctx)
+	user, err := utils.CheckUser(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	tasks, err := tc.taskUseCase.GetAll(ctx)
+	query, err := parseTaskQuery(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		ctx.Error(err)
 		return
 	}
 
-	if len(*tasks) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "No tasks found"})
+	// ?assignee=me is shorthand for the caller's own user id
+	if query.AssigneeId == "me" {
+		query.AssigneeId = user.UserID
+	}
+
+	page, err := tc.taskUseCase.GetAll(ctx, user, query)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"tasks": tasks})
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (tc *TaskController) GetTaskByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	// Get authenticated user from gin context
-	_, err := utils.CheckUser(ctx)
+	user, err := utils.CheckUser(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 
-	task, err := tc.taskUseCase.GetById(ctx, id)
+	task, err := tc.taskUseCase.GetById(ctx, user, id)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		ctx.Error(err)
 		return
 	}
 
+	setTaskETag(ctx, task)
 	ctx.JSON(http.StatusOK, task)
 }
 
@@ -98,37 +108,97 @@ func (tc *TaskController) UpdateTask(ctx *gin.Context) {
 	var updateTask domain.TaskInput
 
 	// Get authenticated user from gin context
-	user, errr := utils.CheckUser(ctx)
-	if errr != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errr.Error()})
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	if user.Role != "admin" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Only the admin can update this task"})
+	version, err := parseIfMatch(ctx)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		tc.versionMismatch(ctx, user, id)
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	_, err := tc.taskUseCase.GetById(ctx, id)
+	// Patch documents say what to clear; plain JSON only sets the fields given
+	var task *domain.Task
+	switch ctx.ContentType() {
+	case domain.MediaTypeMergePatch, domain.MediaTypeJSONPatch:
+		document, readErr := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxPatchSize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(readErr, &tooLarge) {
+			ctx.Error(domain.NewError(domain.ErrValidation, "patch document must be at most %d bytes", maxPatchSize))
+			return
+		}
+		if readErr != nil {
+			ctx.Error(readErr)
+			return
+		}
+		task, err = tc.taskUseCase.Patch(ctx, user, id, &domain.TaskPatch{MediaType: ctx.ContentType(), Document: document}, version)
+	case "", gin.MIMEJSON:
+		if err := bindJSON(ctx, &updateTask); err != nil {
+			ctx.Error(err)
+			return
+		}
+		task, err = tc.taskUseCase.Update(ctx, user, id, &updateTask, version)
+	default:
+		err = domain.ErrUnsupportedPatch
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		if errors.Is(err, domain.ErrUnsupportedMediaType) {
+			ctx.Header("Accept-Patch", acceptPatch)
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			tc.versionMismatch(ctx, user, id)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		ctx.Error(err)
+		return
+	}
+	setTaskETag(ctx, task)
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (tc *TaskController) ReplaceTask(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var replacement domain.TaskInput
+
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	version, err := parseIfMatch(ctx)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		tc.versionMismatch(ctx, user, id)
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	if err := ctx.BindJSON(&updateTask); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if err := bindJSON(ctx, &replacement); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	task, err := tc.taskUseCase.Update(ctx, id, &updateTask)
+	task, err := tc.taskUseCase.Replace(ctx, user, id, &replacement, version)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			tc.versionMismatch(ctx, user, id)
+			return
+		}
+		ctx.Error(err)
 		return
 	}
+	setTaskETag(ctx, task)
 	ctx.JSON(http.StatusOK, task)
 }
 
@@ -136,32 +206,289 @@ func (tc *TaskController) DeleteTask(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	// Get authenticated user from gin context
-	user, errr := utils.CheckUser(ctx)
-	if errr != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errr.Error()})
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-
-	if user.Role != "admin" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Only the admin can delete this task"})
+	version, err := parseIfMatch(ctx)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		tc.versionMismatch(ctx, user, id)
 		return
 	}
-
-	_, err := tc.taskUseCase.GetById(ctx, id)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		ctx.Error(err)
+		return
+	}
+
+	if err := tc.taskUseCase.Delete(ctx, user, id, version); err != nil {
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			tc.versionMismatch(ctx, user, id)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Task removed"})
+}
+
+func (tc *TaskController) GetTrash(ctx *gin.Context) {
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	if err := tc.taskUseCase.Delete(ctx, id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+	query, err := parseTaskQuery(ctx)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Task removed"})
+	page, err := tc.taskUseCase.Trash(ctx, user, query)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (tc *TaskController) RestoreTask(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	task, err := tc.taskUseCase.Restore(ctx, user, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	setTaskETag(ctx, task)
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (tc *TaskController) AssignTask(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var assignment domain.TaskAssignment
+
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	version, err := parseIfMatch(ctx)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		tc.versionMismatch(ctx, user, id)
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := bindJSON(ctx, &assignment); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	task, err := tc.taskUseCase.Assign(ctx, user, id, assignment.AssigneeIDs, version)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		tc.versionMismatch(ctx, user, id)
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	setTaskETag(ctx, task)
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (tc *TaskController) TransitionTask(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var transition domain.TaskTransition
+
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := bindJSON(ctx, &transition); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	task, err := tc.taskUseCase.Transition(ctx, user, id, transition.Status)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	setTaskETag(ctx, task)
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (tc *TaskController) GetTaskHistory(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	query, err := parseHistoryQuery(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	page, err := tc.taskUseCase.History(ctx, user, id, query)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (tc *TaskController) GetAudit(ctx *gin.Context) {
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	query, err := parseHistoryQuery(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	query.TaskId = ctx.Query("task")
+	query.ActorId = ctx.Query("actor")
+	query.Action = ctx.Query("action")
+
+	page, err := tc.taskUseCase.Audit(ctx, user, query)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// versionMismatch answers 412 with the task as it currently is, so the client
+// can redo its change on top of it.
+func (tc *TaskController) versionMismatch(ctx *gin.Context, user *domain.AuthenticatedUser, id string) {
+	current, err := tc.taskUseCase.GetById(ctx, user, id)
+	if err != nil {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": domain.ErrVersionMismatch.Error()})
+		return
+	}
+	setTaskETag(ctx, current)
+	ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": domain.ErrVersionMismatch.Error(), "task": current})
+}
+
+// setTaskETag sends the task's version as its entity tag.
+func setTaskETag(ctx *gin.Context, task *domain.Task) {
+	ctx.Header("ETag", `"`+strconv.FormatInt(task.Version, 10)+`"`)
+}
+
+// parseIfMatch reads the version a PATCH, PUT or DELETE is conditional on from the
+// If-Match header. A missing header or "*" gives 0, meaning any version. If-Match
+// compares entity tags strongly (RFC 7232), so a weak tag never matches and
+// gives domain.ErrVersionMismatch.
+func parseIfMatch(ctx *gin.Context) (int64, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, domain.ErrVersionMismatch
+	}
+
+	tag := strings.Trim(header, `"`)
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, domain.NewError(domain.ErrValidation, "invalid If-Match header, expected an ETag returned by the API")
+	}
+	return version, nil
+}
+
+// parseTaskQuery reads the filtering, sorting and paging parameters of GET /tasks:
+// status, title, assignee, due_before, due_after (RFC 3339), sort, order (asc|desc), limit and cursor.
+func parseTaskQuery(ctx *gin.Context) (*domain.TaskQuery, error) {
+	query := &domain.TaskQuery{
+		Status:     ctx.Query("status"),
+		Title:      ctx.Query("title"),
+		SortBy:     ctx.Query("sort"),
+		Cursor:     ctx.Query("cursor"),
+		AssigneeId: ctx.Query("assignee"),
+	}
+
+	switch order := ctx.Query("order"); order {
+	case "", "asc":
+	case "desc":
+		query.SortDesc = true
+	default:
+		return nil, domain.NewError(domain.ErrValidation, "order must be either asc or desc")
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return nil, domain.NewError(domain.ErrValidation, "limit must be a positive integer")
+		}
+		query.Limit = value
+	}
+
+	if dueBefore := ctx.Query("due_before"); dueBefore != "" {
+		value, err := time.Parse(time.RFC3339, dueBefore)
+		if err != nil {
+			return nil, domain.NewError(domain.ErrValidation, "due_before must be an RFC 3339 timestamp")
+		}
+		query.DueBefore = value
+	}
+
+	if dueAfter := ctx.Query("due_after"); dueAfter != "" {
+		value, err := time.Parse(time.RFC3339, dueAfter)
+		if err != nil {
+			return nil, domain.NewError(domain.ErrValidation, "due_after must be an RFC 3339 timestamp")
+		}
+		query.DueAfter = value
+	}
+
+	return query, nil
+}
+
+// parseHistoryQuery reads the paging parameters of the history endpoints: limit and cursor.
+func parseHistoryQuery(ctx *gin.Context) (*domain.HistoryQuery, error) {
+	query := &domain.HistoryQuery{Cursor: ctx.Query("cursor")}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return nil, domain.NewError(domain.ErrValidation, "limit must be a positive integer")
+		}
+		query.Limit = value
+	}
+
+	return query, nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	
 	"encoding/json"
+	"fmt"
 	
 	"net/http"
 	"net/http/httptest"
@@ -14,13 +15,12 @@ import (
 	"task-manager-api-clean/api/middleware"
 	"task-manager-api-clean/domain"
 	"task-manager-api-clean/domain/mocks"
-	// "task-manager-api-clean/utils"
+	"task-manager-api-clean/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type TaskControllerTestSuite struct {
@@ -34,19 +34,33 @@ type TaskControllerTestSuite struct {
 func (suite *TaskControllerTestSuite) SetupTest() {
 	gin.SetMode(gin.TestMode)
 	suite.router = gin.Default()
+	suite.router.Use(middleware.ErrorHandler())
 	suite.useCase = new(mocks.TaskUseCase)
 	suite.taskController = controller.NewTaskController(suite.useCase)
 	suite.secret = "secret" 
 
 	// Apply the middleware
-	auth := middleware.AuthMiddleware(suite.secret)
+	auth := middleware.AuthMiddleware(utils.NewHMACKeyRing(suite.secret), nil)
 	suite.router.Use(auth)
 
-	suite.router.POST("/tasks", suite.taskController.CreateTask)
-	suite.router.GET("/tasks", suite.taskController.GetTasks)
-	suite.router.GET("/tasks/:id", suite.taskController.GetTaskByID)
-	suite.router.PUT("/tasks/:id", suite.taskController.UpdateTask)
-	suite.router.DELETE("/tasks/:id", suite.taskController.DeleteTask)
+	policy, err := domain.NewPolicy(nil)
+	suite.Require().NoError(err)
+	can := func(permissions ...domain.Permission) gin.HandlerFunc {
+		return middleware.RequirePermission(policy, permissions...)
+	}
+
+	suite.router.POST("/tasks", can(domain.PermTaskCreate), suite.taskController.CreateTask)
+	suite.router.GET("/tasks", can(domain.PermTaskRead, domain.PermTaskReadAny), suite.taskController.GetTasks)
+	suite.router.GET("/tasks/:id", can(domain.PermTaskRead, domain.PermTaskReadAny), suite.taskController.GetTaskByID)
+	suite.router.PATCH("/tasks/:id", can(domain.PermTaskUpdate, domain.PermTaskUpdateAny), suite.taskController.UpdateTask)
+	suite.router.PUT("/tasks/:id", can(domain.PermTaskUpdate, domain.PermTaskUpdateAny), suite.taskController.ReplaceTask)
+	suite.router.DELETE("/tasks/:id", can(domain.PermTaskDelete), suite.taskController.DeleteTask)
+	suite.router.GET("/tasks/trash", can(domain.PermTaskDelete), suite.taskController.GetTrash)
+	suite.router.POST("/tasks/:id/restore", can(domain.PermTaskDelete), suite.taskController.RestoreTask)
+	suite.router.PUT("/tasks/:id/assignees", can(domain.PermTaskAssign), suite.taskController.AssignTask)
+	suite.router.POST("/tasks/:id/transition", can(domain.PermTaskUpdate, domain.PermTaskUpdateAny), suite.taskController.TransitionTask)
+	suite.router.GET("/tasks/:id/history", can(domain.PermTaskRead, domain.PermTaskReadAny), suite.taskController.GetTaskHistory)
+	suite.router.GET("/audit", can(domain.PermAuditRead), suite.taskController.GetAudit)
 }
 
 func (suite *TaskControllerTestSuite) TearDownTest() {
@@ -72,7 +86,7 @@ func (suite *TaskControllerTestSuite) TestCreateTask_Success() {
 	taskInput := &domain.TaskInput{Title: "Test Task", Description: "Test Description", Status: "Pending"}
 	task := &domain.Task{Id: "1", Title: taskInput.Title, Description: taskInput.Description, Status: taskInput.Status}
 
-	suite.useCase.On("Create", mock.Anything, taskInput).Return(task, nil)
+	suite.useCase.On("Create", mock.Anything, mock.Anything, taskInput).Return(task, nil)
 
 	body, _ := json.Marshal(taskInput)
 	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
@@ -83,10 +97,10 @@ func (suite *TaskControllerTestSuite) TestCreateTask_Success() {
 	suite.router.ServeHTTP(resp, req)
 
 	suite.Equal(http.StatusCreated, resp.Code)
-	suite.useCase.AssertCalled(suite.T(), "Create", mock.Anything, taskInput)
+	suite.useCase.AssertCalled(suite.T(), "Create", mock.Anything, mock.Anything, taskInput)
 }
 
-func (suite *TaskControllerTestSuite) TestCreateTask_Failure_Unauthorized() {
+func (suite *TaskControllerTestSuite) TestCreateTask_Failure_Forbidden() {
 	taskInput := &domain.TaskInput{Title: "Test Task", Description: "Test Description", Status: "Pending", DueDate: time.Now()}
 
 	body, _ := json.Marshal(taskInput)
@@ -97,9 +111,9 @@ func (suite *TaskControllerTestSuite) TestCreateTask_Failure_Unauthorized() {
 	resp := httptest.NewRecorder()
 	suite.router.ServeHTTP(resp, req)
 
-	suite.Equal(http.StatusUnauthorized, resp.Code)
-	suite.Contains(resp.Body.String(), "Unauthorized: Only the admin can create a task")
-	suite.useCase.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+	suite.Equal(http.StatusForbidden, resp.Code)
+	suite.Contains(resp.Body.String(), "Forbidden")
+	suite.useCase.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (suite *TaskControllerTestSuite) TestGetTasks_Success() {
@@ -108,7 +122,7 @@ func (suite *TaskControllerTestSuite) TestGetTasks_Success() {
 		{Id: "2", Title: "Task 2", Description: "Description 2", Status: "Completed", DueDate: time.Now()},
 	}
 
-	suite.useCase.On("GetAll", mock.Anything).Return(&tasks, nil)
+	suite.useCase.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return(&domain.TaskPage{Items: tasks, NextCursor: "next", Total: 7}, nil)
 
 	req, _ := http.NewRequest(http.MethodGet, "/tasks", nil)
 	token := suite.createTestJWT("123", "testuser", "admin")
@@ -120,12 +134,13 @@ func (suite *TaskControllerTestSuite) TestGetTasks_Success() {
 	suite.Equal(http.StatusOK, resp.Code)
 	suite.Contains(resp.Body.String(), "Task 1")
 	suite.Contains(resp.Body.String(), "Task 2")
-	suite.useCase.AssertCalled(suite.T(), "GetAll", mock.Anything)
+	suite.Contains(resp.Body.String(), `"next_cursor":"next"`)
+	suite.Contains(resp.Body.String(), `"total":7`)
+	suite.useCase.AssertCalled(suite.T(), "GetAll", mock.Anything, mock.Anything, mock.Anything)
 }
 
-func (suite *TaskControllerTestSuite) TestGetTasks_Failure_NotFound() {
-	var tasks []*domain.Task
-	suite.useCase.On("GetAll", mock.Anything).Return(&tasks, nil)
+func (suite *TaskControllerTestSuite) TestGetTasks_Empty() {
+	suite.useCase.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return(&domain.TaskPage{Items: []*domain.Task{}}, nil)
 
 	req, _ := http.NewRequest(http.MethodGet, "/tasks", nil)
 	token := suite.createTestJWT("123", "testuser", "admin")
@@ -134,15 +149,67 @@ func (suite *TaskControllerTestSuite) TestGetTasks_Failure_NotFound() {
 	resp := httptest.NewRecorder()
 	suite.router.ServeHTTP(resp, req)
 
-	suite.Equal(http.StatusNotFound, resp.Code)
-	suite.Contains(resp.Body.String(), "No tasks found")
-	suite.useCase.AssertCalled(suite.T(), "GetAll", mock.Anything)
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Contains(resp.Body.String(), `"items":[]`)
+	suite.useCase.AssertCalled(suite.T(), "GetAll", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasks_QueryParameters() {
+	expected := &domain.TaskQuery{
+		Status:    "Pending",
+		Title:     "report",
+		SortBy:    "dueDate",
+		SortDesc:  true,
+		Limit:     10,
+		Cursor:    "abc",
+		DueBefore: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		DueAfter:  time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	suite.useCase.On("GetAll", mock.Anything, mock.Anything, expected).Return(&domain.TaskPage{Items: []*domain.Task{}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks?status=Pending&title=report&sort=dueDate&order=desc&limit=10&cursor=abc&due_before=2030-01-01T00:00:00Z&due_after=2029-01-01T00:00:00Z", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.useCase.AssertCalled(suite.T(), "GetAll", mock.Anything, mock.Anything, expected)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasks_Failure_BadQuery() {
+	for _, rawQuery := range []string{"limit=abc", "order=sideways", "due_before=tomorrow"} {
+		req, _ := http.NewRequest(http.MethodGet, "/tasks?"+rawQuery, nil)
+		token := suite.createTestJWT("123", "testuser", "admin")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp := httptest.NewRecorder()
+		suite.router.ServeHTTP(resp, req)
+
+		suite.Equal(http.StatusBadRequest, resp.Code, rawQuery)
+	}
+	suite.useCase.AssertNotCalled(suite.T(), "GetAll", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasks_Failure_InvalidQueryFromUseCase() {
+	suite.useCase.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: cannot sort by \"owner\"", domain.ErrInvalidTaskQuery))
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks?sort=owner", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusBadRequest, resp.Code)
+	suite.Contains(resp.Body.String(), "cannot sort by")
 }
 
 func (suite *TaskControllerTestSuite) TestGetTaskByID_Success() {
 	task := &domain.Task{Id: "1", Title: "Task 1", Description: "Description 1", Status: "Pending"}
 
-	suite.useCase.On("GetById", mock.Anything, "1").Return(task, nil)
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "1").Return(task, nil)
 
 	req, _ := http.NewRequest(http.MethodGet, "/tasks/1", nil)
 	token := suite.createTestJWT("123", "testuser", "admin")
@@ -153,12 +220,12 @@ func (suite *TaskControllerTestSuite) TestGetTaskByID_Success() {
 
 	suite.Equal(http.StatusOK, resp.Code)
 	suite.Contains(resp.Body.String(), "Task 1")
-	suite.useCase.AssertCalled(suite.T(), "GetById", mock.Anything, "1")
+	suite.useCase.AssertCalled(suite.T(), "GetById", mock.Anything, mock.Anything, "1")
 }
 
 
 func (suite *TaskControllerTestSuite) TestGetTaskByID_Failure_NotFound() {
-	suite.useCase.On("GetById", mock.Anything, "nonExistentId").Return(nil, mongo.ErrNoDocuments)
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "nonExistentId").Return(nil, domain.ErrTaskNotFound)
 
 	req, _ := http.NewRequest(http.MethodGet, "/tasks/nonExistentId", nil)
 	token := suite.createTestJWT("123", "testuser", "admin")
@@ -168,19 +235,106 @@ func (suite *TaskControllerTestSuite) TestGetTaskByID_Failure_NotFound() {
 	suite.router.ServeHTTP(resp, req)
 
 	suite.Equal(http.StatusNotFound, resp.Code)
-	suite.Contains(resp.Body.String(), "Task not found")
-	suite.useCase.AssertCalled(suite.T(), "GetById", mock.Anything, "nonExistentId")
+	suite.Contains(resp.Body.String(), "task not found")
+	suite.useCase.AssertCalled(suite.T(), "GetById", mock.Anything, mock.Anything, "nonExistentId")
+}
+
+func (suite *TaskControllerTestSuite) TestGetTaskByID_SetsETag() {
+	task := &domain.Task{Id: "1", Title: "Task 1", Status: "Pending", Version: 4}
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "1").Return(task, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks/1", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Equal(`"4"`, resp.Header().Get("ETag"))
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTask_IfMatch() {
+	taskInput := &domain.TaskInput{Title: "Updated Task"}
+	updated := &domain.Task{Id: "1", Title: "Updated Task", Status: "Pending", Version: 4}
+
+	suite.useCase.On("Update", mock.Anything, mock.Anything, "1", taskInput, int64(3)).Return(updated, nil)
+
+	body, _ := json.Marshal(taskInput)
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBuffer(body))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", `"3"`)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Equal(`"4"`, resp.Header().Get("ETag"))
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTask_Failure_VersionMismatch() {
+	taskInput := &domain.TaskInput{Title: "Updated Task"}
+	current := &domain.Task{Id: "1", Title: "Changed Elsewhere", Status: "Pending", Version: 5}
+
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "1").Return(current, nil)
+	suite.useCase.On("Update", mock.Anything, mock.Anything, "1", taskInput, int64(3)).Return(nil, domain.ErrVersionMismatch)
+
+	body, _ := json.Marshal(taskInput)
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBuffer(body))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", `"3"`)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusPreconditionFailed, resp.Code)
+	suite.Equal(`"5"`, resp.Header().Get("ETag"))
+	suite.Contains(resp.Body.String(), "Changed Elsewhere")
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTask_Failure_InvalidIfMatch() {
+
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(`{"title":"Updated Task"}`))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", `"abc"`)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusBadRequest, resp.Code)
+	suite.useCase.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerTestSuite) TestDeleteTask_Failure_VersionMismatch() {
+	current := &domain.Task{Id: "1", Title: "Task 1", Status: "Pending", Version: 2}
+
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "1").Return(current, nil)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/tasks/1", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", `W/"1"`)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusPreconditionFailed, resp.Code)
+	suite.Equal(`"2"`, resp.Header().Get("ETag"))
+	// If-Match compares strongly, so a weak tag never matches
+	suite.useCase.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (suite *TaskControllerTestSuite) TestUpdateTask_Success() {
 	taskInput := &domain.TaskInput{Title: "Updated Task", Description: "Updated Description", Status: "Completed"}
 	task := &domain.Task{Id: "1", Title: taskInput.Title, Description: taskInput.Description, Status: taskInput.Status}
 
-	suite.useCase.On("GetById", mock.Anything, "1").Return(task, nil)
-	suite.useCase.On("Update", mock.Anything, "1", taskInput).Return(task, nil)
+	suite.useCase.On("Update", mock.Anything, mock.Anything, "1", taskInput, int64(0)).Return(task, nil)
 
 	body, _ := json.Marshal(taskInput)
-	req, _ := http.NewRequest(http.MethodPut, "/tasks/1", bytes.NewBuffer(body))
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBuffer(body))
 		
 	token := suite.createTestJWT("123", "testuser", "admin")
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -190,32 +344,141 @@ func (suite *TaskControllerTestSuite) TestUpdateTask_Success() {
 
 	suite.Equal(http.StatusOK, resp.Code)
 	suite.Contains(resp.Body.String(), "Updated Task")
-	suite.useCase.AssertCalled(suite.T(), "GetById", mock.Anything, "1")
-	suite.useCase.AssertCalled(suite.T(), "Update", mock.Anything, "1", taskInput)
+	suite.useCase.AssertCalled(suite.T(), "Update", mock.Anything, mock.Anything, "1", taskInput, int64(0))
 
 }
 
-func (suite *TaskControllerTestSuite) TestUpdateTask_Failure_Unauthorized() {
-	taskInput := &domain.TaskInput{Title: "Updated Task", Description: "Updated Description", Status: "Completed", DueDate: time.Now()}
+func (suite *TaskControllerTestSuite) TestUpdateTask_Failure_Forbidden() {
+	taskInput := &domain.TaskInput{Title: "Updated Task", Description: "Updated Description", Status: "Completed"}
+
+	suite.useCase.On("Update", mock.Anything, mock.Anything, "1", taskInput, int64(0)).Return(nil, domain.ErrTaskForbidden)
 
 	body, _ := json.Marshal(taskInput)
-	req, _ := http.NewRequest(http.MethodPut, "/tasks/1", bytes.NewBuffer(body))
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBuffer(body))
 	token := suite.createTestJWT("123", "testuser", "user")
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp := httptest.NewRecorder()
 	suite.router.ServeHTTP(resp, req)
 
-	suite.Equal(http.StatusUnauthorized, resp.Code)
-	suite.Contains(resp.Body.String(), "Unauthorized: Only the admin can update this task")
-	suite.useCase.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+	suite.Equal(http.StatusForbidden, resp.Code)
+	suite.Contains(resp.Body.String(), domain.ErrTaskForbidden.Error())
 }
 
-func (suite *TaskControllerTestSuite) TestDeleteTask_Success() {
-	task := &domain.Task{Id: "1", Title: "Task 1", Description: "Description 1", Status: "Pending"}
+func (suite *TaskControllerTestSuite) TestUpdateTask_AssigneeChangesStatus() {
+	taskInput := &domain.TaskInput{Status: "Completed"}
+	updated := &domain.Task{Id: "1", Title: "Task 1", Status: "Completed", AssigneeIDs: []string{"123"}}
+
+	suite.useCase.On("Update", mock.Anything, mock.MatchedBy(func(user *domain.AuthenticatedUser) bool {
+		return user.UserID == "123" && user.Role == "user"
+	}), "1", taskInput, int64(0)).Return(updated, nil)
+
+	body, _ := json.Marshal(taskInput)
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBuffer(body))
+	token := suite.createTestJWT("123", "testuser", "user")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Contains(resp.Body.String(), "Completed")
+}
+
+func (suite *TaskControllerTestSuite) TestGetTaskByID_Failure_NotVisible() {
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "1").Return(nil, domain.ErrTaskNotFound)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks/1", nil)
+	token := suite.createTestJWT("123", "testuser", "user")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusNotFound, resp.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTasks_AssigneeMe() {
+	suite.useCase.On("GetAll", mock.Anything, mock.Anything, mock.MatchedBy(func(query *domain.TaskQuery) bool {
+		return query.AssigneeId == "123"
+	})).Return(&domain.TaskPage{Items: []*domain.Task{}}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks?assignee=me", nil)
+	token := suite.createTestJWT("123", "testuser", "user")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestAssignTask_Success() {
+	assignment := &domain.TaskAssignment{AssigneeIDs: []string{"u1", "u2"}}
+	task := &domain.Task{Id: "1", Title: "Task 1", AssigneeIDs: assignment.AssigneeIDs}
+
+	suite.useCase.On("Assign", mock.Anything, mock.Anything, "1", assignment.AssigneeIDs, int64(0)).Return(task, nil)
+
+	body, _ := json.Marshal(assignment)
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/1/assignees", bytes.NewBuffer(body))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Contains(resp.Body.String(), `"assigneeIds":["u1","u2"]`)
+}
+
+func (suite *TaskControllerTestSuite) TestAssignTask_Failure_Forbidden() {
+	body, _ := json.Marshal(&domain.TaskAssignment{AssigneeIDs: []string{"123"}})
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/1/assignees", bytes.NewBuffer(body))
+	token := suite.createTestJWT("123", "testuser", "user")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusForbidden, resp.Code)
+	suite.useCase.AssertNotCalled(suite.T(), "Assign", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerTestSuite) TestAssignTask_Failure_VersionMismatch() {
+	assignment := &domain.TaskAssignment{AssigneeIDs: []string{"u1"}}
+	current := &domain.Task{Id: "1", Title: "Task 1", Status: "Pending", Version: 4}
 
-	suite.useCase.On("GetById", mock.Anything, "1").Return(task, nil)
-	suite.useCase.On("Delete", mock.Anything, "1").Return(nil)
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "1").Return(current, nil)
+	suite.useCase.On("Assign", mock.Anything, mock.Anything, "1", assignment.AssigneeIDs, int64(3)).Return(nil, domain.ErrVersionMismatch)
+
+	body, _ := json.Marshal(assignment)
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/1/assignees", bytes.NewBuffer(body))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", `"3"`)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusPreconditionFailed, resp.Code)
+	suite.Equal(`"4"`, resp.Header().Get("ETag"))
+}
+
+func (suite *TaskControllerTestSuite) TestAssignTask_Failure_NotFound() {
+	suite.useCase.On("Assign", mock.Anything, mock.Anything, "missing", []string{}, int64(0)).Return(nil, domain.ErrTaskNotFound)
+
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/missing/assignees", bytes.NewBufferString(`{"assigneeIds":[]}`))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusNotFound, resp.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestDeleteTask_Success() {
+	suite.useCase.On("Delete", mock.Anything, mock.Anything, "1", int64(0)).Return(nil)
 
 	req, _ := http.NewRequest(http.MethodDelete, "/tasks/1", nil)
 	token := suite.createTestJWT("123", "testuser", "admin")
@@ -226,12 +489,55 @@ func (suite *TaskControllerTestSuite) TestDeleteTask_Success() {
 
 	suite.Equal(http.StatusOK, resp.Code)
 	suite.Contains(resp.Body.String(), "Task removed")
-	suite.useCase.AssertCalled(suite.T(), "GetById", mock.Anything, "1")
-	suite.useCase.AssertCalled(suite.T(), "Delete", mock.Anything, "1")
+	suite.useCase.AssertCalled(suite.T(), "Delete", mock.Anything, mock.Anything, "1", int64(0))
+
+}
+
+func (suite *TaskControllerTestSuite) TestGetTrash_Success() {
+	page := &domain.TaskPage{Items: []*domain.Task{{Id: "1", Title: "Task 1"}}}
+	suite.useCase.On("Trash", mock.Anything, mock.Anything, mock.Anything).Return(page, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tasks/trash", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Contains(resp.Body.String(), "Task 1")
+	suite.useCase.AssertNotCalled(suite.T(), "GetById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerTestSuite) TestRestoreTask_Success() {
+	task := &domain.Task{Id: "1", Title: "Task 1", Status: "Pending"}
+	suite.useCase.On("Restore", mock.Anything, mock.Anything, "1").Return(task, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/1/restore", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Contains(resp.Body.String(), "Task 1")
+}
+
+func (suite *TaskControllerTestSuite) TestRestoreTask_Failure_NotInTrash() {
+	suite.useCase.On("Restore", mock.Anything, mock.Anything, "1").Return(nil, domain.ErrTaskNotFound)
+
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/1/restore", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusNotFound, resp.Code)
 }
 
-func (suite *TaskControllerTestSuite) TestDeleteTask_Failure_Unauthorized() {
+func (suite *TaskControllerTestSuite) TestDeleteTask_Failure_Forbidden() {
 	req, _ := http.NewRequest(http.MethodDelete, "/tasks/1", nil)
 
 	// Set the JWT token for a non-admin user
@@ -241,45 +547,230 @@ func (suite *TaskControllerTestSuite) TestDeleteTask_Failure_Unauthorized() {
 	resp := httptest.NewRecorder()
 	suite.router.ServeHTTP(resp, req)
 
-	suite.Equal(http.StatusUnauthorized, resp.Code)
-	suite.Contains(resp.Body.String(), "Unauthorized: Only the admin can delete this task")
-	suite.useCase.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything)
+	suite.Equal(http.StatusForbidden, resp.Code)
+	suite.Contains(resp.Body.String(), "Forbidden")
+	suite.useCase.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	
 }
 func TestTaskControllerTestSuite(t *testing.T) {
 	suite.Run(t, new(TaskControllerTestSuite))
 }
 
+func (suite *TaskControllerTestSuite) TestCreateTask_ManagerAllowed() {
+	taskInput := &domain.TaskInput{Title: "Test Task", Status: "Pending"}
+	suite.useCase.On("Create", mock.Anything, mock.Anything, taskInput).Return(&domain.Task{Id: "1", Title: taskInput.Title}, nil)
+
+	body, _ := json.Marshal(taskInput)
+	req, _ := http.NewRequest(http.MethodPost, "/tasks", bytes.NewBuffer(body))
+	token := suite.createTestJWT("123", "testuser", "manager")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusCreated, resp.Code)
+}
+
+func (suite *TaskControllerTestSuite) TestUpdateTask_ViewerForbidden() {
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(`{"status":"Completed"}`))
+	token := suite.createTestJWT("123", "testuser", "viewer")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusForbidden, resp.Code)
+	suite.useCase.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerTestSuite) TestTransitionTask_Success() {
+	updated := &domain.Task{Id: "1", Title: "Task 1", Status: "In Progress", StatusChangedBy: "123"}
+	suite.useCase.On("Transition", mock.Anything, mock.Anything, "1", "In Progress").Return(updated, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/1/transition", bytes.NewBufferString(`{"status":"In Progress"}`))
+	token := suite.createTestJWT("123", "testuser", "user")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Contains(resp.Body.String(), `"statusChangedBy":"123"`)
+}
+
+func (suite *TaskControllerTestSuite) TestTransitionTask_Failure_IllegalTransition() {
+	err := &domain.TransitionError{From: "Pending", To: "Completed", Allowed: []string{"In Progress"}}
+	suite.useCase.On("Transition", mock.Anything, mock.Anything, "1", "Completed").Return(nil, err)
+
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/1/transition", bytes.NewBufferString(`{"status":"Completed"}`))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusConflict, resp.Code)
+	suite.Contains(resp.Body.String(), "cannot move a task")
+}
+
+func (suite *TaskControllerTestSuite) TestTransitionTask_Failure_UnknownStatus() {
+	workflow, _ := domain.NewWorkflow(nil, nil)
+	suite.useCase.On("Transition", mock.Anything, mock.Anything, "1", "Someday").Return(nil, workflow.CheckStatus("Someday"))
+
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/1/transition", bytes.NewBufferString(`{"status":"Someday"}`))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusUnprocessableEntity, resp.Code)
+	suite.Contains(resp.Body.String(), `"field":"status","code":"unknown_value"`)
+}
+
+func (suite *TaskControllerTestSuite) TestTransitionTask_Failure_MissingStatus() {
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/1/transition", bytes.NewBufferString(`{}`))
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusUnprocessableEntity, resp.Code)
+	suite.Contains(resp.Body.String(), `"field":"status","code":"required"`)
+	suite.useCase.AssertNotCalled(suite.T(), "Transition", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerTestSuite) TestGetTaskHistory_Success() {
+	page := &domain.HistoryPage{Items: []*domain.TaskHistoryEntry{
+		{Id: "h1", TaskId: "1", Action: domain.HistoryUpdate, ActorId: "123", Changes: []domain.FieldChange{{Field: "title", From: "Old", To: "New"}}},
+	}}
+	suite.useCase.On("History", mock.Anything, mock.Anything, "1", &domain.HistoryQuery{Limit: 5, Cursor: "abc"}).Return(page, nil)
 
+	req, _ := http.NewRequest(http.MethodGet, "/tasks/1/history?limit=5&cursor=abc", nil)
+	token := suite.createTestJWT("123", "testuser", "user")
+	req.Header.Set("Authorization", "Bearer "+token)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Contains(resp.Body.String(), `"changes":[{"field":"title","from":"Old","to":"New"}]`)
+}
 
+func (suite *TaskControllerTestSuite) TestGetTaskHistory_Failure_NotFound() {
+	suite.useCase.On("History", mock.Anything, mock.Anything, "1", mock.Anything).Return(nil, domain.ErrTaskNotFound)
 
+	req, _ := http.NewRequest(http.MethodGet, "/tasks/1/history", nil)
+	token := suite.createTestJWT("123", "testuser", "user")
+	req.Header.Set("Authorization", "Bearer "+token)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusNotFound, resp.Code)
+}
 
+func (suite *TaskControllerTestSuite) TestGetAudit_Success() {
+	suite.useCase.On("Audit", mock.Anything, mock.Anything, &domain.HistoryQuery{ActorId: "u1", Action: "delete"}).Return(&domain.HistoryPage{Items: []*domain.TaskHistoryEntry{}}, nil)
 
+	req, _ := http.NewRequest(http.MethodGet, "/audit?actor=u1&action=delete", nil)
+	token := suite.createTestJWT("123", "testuser", "admin")
+	req.Header.Set("Authorization", "Bearer "+token)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusOK, resp.Code)
+}
 
+func (suite *TaskControllerTestSuite) TestGetAudit_Failure_Forbidden() {
+	req, _ := http.NewRequest(http.MethodGet, "/audit", nil)
+	token := suite.createTestJWT("123", "testuser", "manager")
+	req.Header.Set("Authorization", "Bearer "+token)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusForbidden, resp.Code)
+	suite.useCase.AssertNotCalled(suite.T(), "Audit", mock.Anything, mock.Anything, mock.Anything)
+}
 
+func (suite *TaskControllerTestSuite) TestUpdateTask_MergePatch() {
+	updated := &domain.Task{Id: "1", Title: "Task 1", Status: "Pending", Version: 4}
+	patch := &domain.TaskPatch{MediaType: domain.MediaTypeMergePatch, Document: []byte(`{"description":null}`)}
 
+	suite.useCase.On("Patch", mock.Anything, mock.Anything, "1", patch, int64(3)).Return(updated, nil)
 
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(`{"description":null}`))
+	req.Header.Set("Authorization", "Bearer "+suite.createTestJWT("123", "testuser", "admin"))
+	req.Header.Set("Content-Type", "application/merge-patch+json; charset=utf-8")
+	req.Header.Set("If-Match", `"3"`)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Equal(`"4"`, resp.Header().Get("ETag"))
+}
 
+func (suite *TaskControllerTestSuite) TestUpdateTask_Failure_PatchTestFailed() {
+	suite.useCase.On("Patch", mock.Anything, mock.Anything, "1", mock.Anything, int64(0)).Return(nil, domain.ErrPatchTestFailed)
 
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(`[{"op":"test","path":"/title","value":"Other"}]`))
+	req.Header.Set("Authorization", "Bearer "+suite.createTestJWT("123", "testuser", "admin"))
+	req.Header.Set("Content-Type", domain.MediaTypeJSONPatch)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusConflict, resp.Code)
+}
 
+func (suite *TaskControllerTestSuite) TestUpdateTask_Failure_UnsupportedMediaType() {
+	req, _ := http.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(`title=Renamed`))
+	req.Header.Set("Authorization", "Bearer "+suite.createTestJWT("123", "testuser", "admin"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusUnsupportedMediaType, resp.Code)
+	suite.Contains(resp.Header().Get("Accept-Patch"), domain.MediaTypeMergePatch)
+	suite.useCase.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
 
+func (suite *TaskControllerTestSuite) TestReplaceTask_Success() {
+	replacement := &domain.TaskInput{Title: "Task 1", Status: "Pending"}
+	replaced := &domain.Task{Id: "1", Title: "Task 1", Status: "Pending", Version: 4}
+	suite.useCase.On("Replace", mock.Anything, mock.Anything, "1", replacement, int64(3)).Return(replaced, nil)
 
+	body, _ := json.Marshal(replacement)
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/1", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+suite.createTestJWT("123", "testuser", "admin"))
+	req.Header.Set("If-Match", `"3"`)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.Equal(`"4"`, resp.Header().Get("ETag"))
+}
 
+func (suite *TaskControllerTestSuite) TestReplaceTask_Failure_VersionMismatch() {
+	replacement := &domain.TaskInput{Title: "Task 1", Status: "Pending"}
+	current := &domain.Task{Id: "1", Title: "Changed Elsewhere", Status: "Pending", Version: 5}
+	suite.useCase.On("Replace", mock.Anything, mock.Anything, "1", replacement, int64(3)).Return(nil, domain.ErrVersionMismatch)
+	suite.useCase.On("GetById", mock.Anything, mock.Anything, "1").Return(current, nil)
 
+	body, _ := json.Marshal(replacement)
+	req, _ := http.NewRequest(http.MethodPut, "/tasks/1", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+suite.createTestJWT("123", "testuser", "admin"))
+	req.Header.Set("If-Match", `"3"`)
 
+	resp := httptest.NewRecorder()
+	suite.router.ServeHTTP(resp, req)
 
+	suite.Equal(http.StatusPreconditionFailed, resp.Code)
+	suite.Equal(`"5"`, resp.Header().Get("ETag"))
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"strconv"
 
 	"task-manager-api-clean/domain"
 	"task-manager-api-clean/utils"
@@ -23,62 +24,313 @@ func (uc *UserController) CreateUser(ctx *gin.Context) {
 	var newUser domain.UserCreate
 
 	// Bind JSON to new user
-	if err := ctx.BindJSON(&newUser); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if err := bindJSON(ctx, &newUser); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	_, err := uc.userUseCase.RegisterUser(ctx, &newUser)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
 }
 
+func (uc *UserController) SetupAdmin(ctx *gin.Context) {
+	var setup domain.AdminSetup
+	if err := bindJSON(ctx, &setup); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if _, err := uc.userUseCase.Setup(ctx, &setup); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Admin created successfully"})
+}
+
 func (uc *UserController) LoginUser(ctx *gin.Context) {
 	var user domain.UserLogin
 
-	if err := ctx.BindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(ctx, &user); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	jwtToken, err := uc.userUseCase.Login(ctx, &user)
+	tokens, err := uc.userUseCase.Login(ctx, &user)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 	
-	ctx.JSON(http.StatusOK, gin.H{"message": "User logged in successfully", "token": jwtToken})
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":       "User logged in successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+func (uc *UserController) RefreshToken(ctx *gin.Context) {
+	var payload domain.RefreshRequest
+
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	tokens, err := uc.userUseCase.Refresh(ctx, &payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (uc *UserController) Logout(ctx *gin.Context) {
+	var payload domain.LogoutRequest
+
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := uc.userUseCase.Logout(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User logged out successfully"})
 }
 
 func (uc *UserController) PromoteUser(ctx *gin.Context) {
 	// Get authenticated user from gin context
-	user, err := utils.CheckUser(ctx)
+	_, err := utils.CheckUser(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	if user.Role != "admin" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Only the admin can promote a user"})
+	// The body is optional, without one the user is made an admin
+	promotion := domain.UserPromotion{Role: "admin"}
+	if ctx.Request.ContentLength != 0 {
+		if err := bindJSON(ctx, &promotion); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	username := ctx.Param("username")
+
+	_, err = uc.userUseCase.Promote(ctx, username, promotion.Role)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
+	ctx.JSON(http.StatusOK, gin.H{"message": "User promoted to " + promotion.Role})
+}
+
+func (uc *UserController) UnlockUser(ctx *gin.Context) {
 	username := ctx.Param("username")
 
-	_, er := uc.userUseCase.Promote(ctx, username)
-	if er != nil {
-		if er.Error() == "user not found" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": er.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": er.Error()})
-		}
+	if err := uc.userUseCase.Unlock(ctx, username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+func (uc *UserController) ChangePassword(ctx *gin.Context) {
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var payload domain.PasswordChange
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	tokens, err := uc.userUseCase.ChangePassword(ctx, user, &payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed, every other session has been logged out",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+func (uc *UserController) ForgotPassword(ctx *gin.Context) {
+	var payload domain.PasswordResetRequest
+
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := uc.userUseCase.RequestPasswordReset(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// The same answer whether or not the address has an account
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If an account uses this address, a password reset token has been sent to it"})
+}
+
+func (uc *UserController) ResetPassword(ctx *gin.Context) {
+	var payload domain.PasswordReset
+
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := uc.userUseCase.ResetPassword(ctx, &payload); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "User promoted to admin"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset, log in with the new password"})
 }
 
+func (uc *UserController) VerifyEmail(ctx *gin.Context) {
+	if err := uc.userUseCase.VerifyEmail(ctx, ctx.Query("token")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+func (uc *UserController) ResendVerification(ctx *gin.Context) {
+	var payload domain.VerificationResendRequest
+
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := uc.userUseCase.ResendVerification(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// The same answer whether or not the address has an unverified account
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If an unverified account uses this address, a verification link has been sent to it"})
+}
+
+func (uc *UserController) ListUsers(ctx *gin.Context) {
+	query, err := parseUserQuery(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	page, err := uc.userUseCase.List(ctx, query)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (uc *UserController) GetProfile(ctx *gin.Context) {
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	profile, err := uc.userUseCase.Profile(ctx, user)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, profile)
+}
+
+func (uc *UserController) UpdateProfile(ctx *gin.Context) {
+	// Get authenticated user from gin context
+	user, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var payload domain.UserProfileUpdate
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	profile, tokens, err := uc.userUseCase.UpdateProfile(ctx, user, &payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// A new username comes with the token pair replacing the revoked one
+	ctx.JSON(http.StatusOK, struct {
+		*domain.UserInfo
+		*domain.TokenPair
+	}{profile, tokens})
+}
+
+func (uc *UserController) UpdateUser(ctx *gin.Context) {
+	// Get authenticated user from gin context
+	actor, err := utils.CheckUser(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var payload domain.UserUpdate
+	if err := bindJSON(ctx, &payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	user, err := uc.userUseCase.Update(ctx, actor, ctx.Param("id"), &payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// parseUserQuery reads the filtering and paging parameters of GET /users:
+// role, limit and cursor.
+func parseUserQuery(ctx *gin.Context) (*domain.UserQuery, error) {
+	query := &domain.UserQuery{Role: ctx.Query("role"), Cursor: ctx.Query("cursor")}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return nil, domain.NewError(domain.ErrValidation, "limit must be a positive integer")
+		}
+		query.Limit = value
+	}
+
+	return query, nil
+}
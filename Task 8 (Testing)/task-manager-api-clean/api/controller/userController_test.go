@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"task-manager-api-clean/api/middleware"
 	"task-manager-api-clean/domain"
 	"task-manager-api-clean/domain/mocks"
+	"task-manager-api-clean/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -34,12 +37,28 @@ func (suite *UserControllerTestSuite) SetupTest() {
     suite.controller = controller.NewUserController(suite.useCase)
     gin.SetMode(gin.TestMode)
     suite.router = gin.Default()
-    auth := middleware.AuthMiddleware(suite.secret)
+    suite.router.Use(middleware.ErrorHandler())
+    auth := middleware.AuthMiddleware(utils.NewHMACKeyRing(suite.secret), nil)
 
 
     suite.router.POST("/users", suite.controller.CreateUser)
+    suite.router.POST("/setup", suite.controller.SetupAdmin)
     suite.router.POST("/login", suite.controller.LoginUser)
-    suite.router.POST("/promote/:username", auth, suite.controller.PromoteUser)
+    policy, err := domain.NewPolicy(nil)
+    suite.Require().NoError(err)
+    suite.router.POST("/promote/:username", auth, middleware.RequirePermission(policy, domain.PermUserPromote), suite.controller.PromoteUser)
+    suite.router.POST("/unlock/:username", auth, middleware.RequirePermission(policy, domain.PermUserUnlock), suite.controller.UnlockUser)
+    suite.router.POST("/auth/refresh", suite.controller.RefreshToken)
+    suite.router.POST("/auth/logout", suite.controller.Logout)
+    suite.router.POST("/me/password", auth, suite.controller.ChangePassword)
+    suite.router.POST("/auth/forgot", suite.controller.ForgotPassword)
+    suite.router.POST("/auth/reset", suite.controller.ResetPassword)
+    suite.router.GET("/auth/verify", suite.controller.VerifyEmail)
+    suite.router.POST("/auth/verify/resend", suite.controller.ResendVerification)
+    suite.router.GET("/me", auth, suite.controller.GetProfile)
+    suite.router.PATCH("/me", auth, suite.controller.UpdateProfile)
+    suite.router.GET("/users", auth, middleware.RequirePermission(policy, domain.PermUserRead), suite.controller.ListUsers)
+    suite.router.PATCH("/users/:id", auth, middleware.RequirePermission(policy, domain.PermUserPromote, domain.PermUserDeactivate), suite.controller.UpdateUser)
 }
 
 func (suite *UserControllerTestSuite) TearDownTest() {
@@ -76,8 +95,26 @@ func (suite *UserControllerTestSuite) TestCreateUser_Success() {
     suite.useCase.AssertCalled(suite.T(), "RegisterUser", mock.Anything, payload)
 }
 
+func (suite *UserControllerTestSuite) TestSetupAdmin() {
+    payload := &domain.AdminSetup{UserCreate: domain.UserCreate{Username: "root", Password: "root", Email: "root@example.com"}, SetupToken: "token"}
+    suite.useCase.On("Setup", mock.Anything, payload).Return(&domain.UserInfo{UserId: "1", Username: "root", Role: "admin"}, nil).Once()
+    suite.useCase.On("Setup", mock.Anything, payload).Return(nil, domain.ErrInvalidSetupToken).Once()
+
+    body := []byte(`{"username":"root","password":"root","email":"root@example.com","setup_token":"token"}`)
+    req, _ := http.NewRequest("POST", "/setup", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+    suite.Equal(http.StatusCreated, w.Code)
+
+    req, _ = http.NewRequest("POST", "/setup", bytes.NewBuffer(body))
+    w = httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+    suite.Equal(http.StatusForbidden, w.Code)
+    suite.JSONEq(`{"error":"invalid or used setup token"}`, w.Body.String())
+}
+
 func (suite *UserControllerTestSuite) TestCreateUser_BadRequest() {
-    body := []byte(`{"username":"test"}`)
+    body := []byte(`{"username":"test"`)
     req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
     w := httptest.NewRecorder()
     suite.router.ServeHTTP(w, req)
@@ -87,16 +124,45 @@ func (suite *UserControllerTestSuite) TestCreateUser_BadRequest() {
     suite.Equal(http.StatusBadRequest, w.Code)
 }
 
+func (suite *UserControllerTestSuite) TestCreateUser_InvalidFields() {
+    invalid := &domain.ValidationError{}
+    invalid.Add("password", domain.CodeRequired, "password is required")
+    invalid.Add("email", domain.CodeInvalidFormat, "email must be a valid email address")
+    suite.useCase.On("RegisterUser", mock.Anything, &domain.UserCreate{Username: "test", Email: "nope"}).Return(nil, invalid)
+
+    body := []byte(`{"username":"test","email":"nope"}`)
+    req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusUnprocessableEntity, w.Code)
+    suite.JSONEq(`{"errors":[
+        {"field":"password","code":"required","message":"password is required"},
+        {"field":"email","code":"invalid_format","message":"email must be a valid email address"}
+    ]}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestCreateUser_WrongFieldType() {
+    body := []byte(`{"username":42}`)
+    req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.useCase.AssertNotCalled(suite.T(), "RegisterUser", mock.Anything, mock.Anything)
+    suite.Equal(http.StatusUnprocessableEntity, w.Code)
+    suite.Contains(w.Body.String(), `"field":"username","code":"invalid_type"`)
+}
+
 func (suite *UserControllerTestSuite) TestCreateUser_UseCaseError() {
     payload := &domain.UserCreate{Username: "test", Password: "test", Email: "test@example.com"}
-    suite.useCase.On("RegisterUser", mock.Anything, payload).Return(nil, errors.New("user already exists"))
+    suite.useCase.On("RegisterUser", mock.Anything, payload).Return(nil, domain.ErrUserExists)
 
     body, _ := json.Marshal(payload)
     req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(body))
     w := httptest.NewRecorder()
     suite.router.ServeHTTP(w, req)
 
-    suite.Equal(http.StatusBadRequest, w.Code)
+    suite.Equal(http.StatusConflict, w.Code)
     suite.useCase.AssertCalled(suite.T(), "RegisterUser", mock.Anything, payload)
 	
 
@@ -104,7 +170,8 @@ func (suite *UserControllerTestSuite) TestCreateUser_UseCaseError() {
 
 func (suite *UserControllerTestSuite) TestLoginUser_Success() {
     payload := &domain.UserLogin{Username: "test", Password: "test"}
-    suite.useCase.On("Login", mock.Anything, payload).Return("jwtToken", nil)
+    tokens := &domain.TokenPair{AccessToken: "jwtToken", RefreshToken: "refreshToken", TokenType: "Bearer", ExpiresIn: 900}
+    suite.useCase.On("Login", mock.Anything, payload).Return(tokens, nil)
 
     body, _ := json.Marshal(payload)
     req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(body))
@@ -112,6 +179,8 @@ func (suite *UserControllerTestSuite) TestLoginUser_Success() {
     suite.router.ServeHTTP(w, req)
 
     suite.Equal(http.StatusOK, w.Code)
+    suite.Contains(w.Body.String(), `"token":"jwtToken"`)
+    suite.Contains(w.Body.String(), `"refresh_token":"refreshToken"`)
     suite.useCase.AssertCalled(suite.T(), "Login", mock.Anything, payload)
 }
 
@@ -121,12 +190,13 @@ func (suite *UserControllerTestSuite) TestLoginUser_BadRequest() {
     w := httptest.NewRecorder()
     suite.router.ServeHTTP(w, req)
 
-    suite.Equal(http.StatusBadRequest, w.Code)
+    suite.Equal(http.StatusUnprocessableEntity, w.Code)
+    suite.JSONEq(`{"errors":[{"field":"password","code":"required","message":"password failed the \"required\" rule"}]}`, w.Body.String())
 }
 
 func (suite *UserControllerTestSuite) TestLoginUser_UseCaseError() {
     payload := &domain.UserLogin{Username: "test", Password: "test"}
-    suite.useCase.On("Login", mock.Anything, payload).Return("", errors.New("invalid credentials"))
+    suite.useCase.On("Login", mock.Anything, payload).Return(nil, domain.ErrInvalidCredentials)
 
     body, _ := json.Marshal(payload)
     req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(body))
@@ -149,7 +219,7 @@ func (suite *UserControllerTestSuite) TestPromoteUser_Success() {
         Username: username,
         Email:    "test@example.com",
     }
-    suite.useCase.On("Promote", mock.Anything, username).Return(expectedUserInfo, nil)
+    suite.useCase.On("Promote", mock.Anything, username, "admin").Return(expectedUserInfo, nil)
 
     req, _ := http.NewRequest("POST", "/promote/"+username, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenString)
@@ -160,10 +230,10 @@ func (suite *UserControllerTestSuite) TestPromoteUser_Success() {
 
     expectedResponse := `{"message":"User promoted to admin"}`
     suite.JSONEq(expectedResponse, w.Body.String())
-    suite.useCase.AssertCalled(suite.T(), "Promote", mock.Anything, username)
+    suite.useCase.AssertCalled(suite.T(), "Promote", mock.Anything, username, "admin")
 }
 
-func (suite *UserControllerTestSuite) TestPromoteUser_Unauthorized() {
+func (suite *UserControllerTestSuite) TestPromoteUser_Forbidden() {
     username := "testUser"
 	tokenString := suite.createTestJWT("1", "adminUser", "user")
 
@@ -173,18 +243,18 @@ func (suite *UserControllerTestSuite) TestPromoteUser_Unauthorized() {
 	w := httptest.NewRecorder()
 
     suite.router.ServeHTTP(w, req)
-    suite.Equal(http.StatusUnauthorized, w.Code)
+    suite.Equal(http.StatusForbidden, w.Code)
 
-    expectedResponse := `{"error":"Unauthorized: Only the admin can promote a user"}`
+    expectedResponse := `{"error":"Forbidden: your role does not allow this action"}`
     suite.JSONEq(expectedResponse, w.Body.String())
-    suite.useCase.AssertNotCalled(suite.T(), "Promote", mock.Anything, username)
+    suite.useCase.AssertNotCalled(suite.T(), "Promote", mock.Anything, username, mock.Anything)
 }
 
 func (suite *UserControllerTestSuite) TestPromoteUser_UserNotFound() {
     username := "nonExistentUser"
     tokenString := suite.createTestJWT("1", "adminUser", "admin")
 
-    suite.useCase.On("Promote", mock.Anything, username).Return(nil, errors.New("user not found"))
+    suite.useCase.On("Promote", mock.Anything, username, "admin").Return(nil, domain.ErrUserNotFound)
 
     req, _ := http.NewRequest("POST", "/promote/"+username, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenString)
@@ -195,14 +265,14 @@ func (suite *UserControllerTestSuite) TestPromoteUser_UserNotFound() {
 
     expectedResponse := `{"error":"user not found"}`
     suite.JSONEq(expectedResponse, w.Body.String())
-    suite.useCase.AssertCalled(suite.T(), "Promote", mock.Anything, username)
+    suite.useCase.AssertCalled(suite.T(), "Promote", mock.Anything, username, "admin")
 }
 
 func (suite *UserControllerTestSuite) TestPromoteUser_Failure() {
     username := "testuser"
     tokenString := suite.createTestJWT("1", "adminUser", "admin")
 
-    suite.useCase.On("Promote", mock.Anything, username).Return(nil, errors.New("usecase error"))
+    suite.useCase.On("Promote", mock.Anything, username, "admin").Return(nil, errors.New("usecase error"))
 
     req, _ := http.NewRequest("POST", "/promote/"+username, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenString)
@@ -211,9 +281,317 @@ func (suite *UserControllerTestSuite) TestPromoteUser_Failure() {
     suite.router.ServeHTTP(w, req)
     suite.Equal(http.StatusInternalServerError, w.Code)
 
-    expectedResponse := `{"error":"usecase error"}`
+    expectedResponse := `{"error":"internal server error"}`
     suite.JSONEq(expectedResponse, w.Body.String())
-    suite.useCase.AssertCalled(suite.T(), "Promote", mock.Anything, username)
+    suite.useCase.AssertCalled(suite.T(), "Promote", mock.Anything, username, "admin")
+}
+
+func (suite *UserControllerTestSuite) TestPromoteUser_CustomRole() {
+    username := "testUser"
+    tokenString := suite.createTestJWT("1", "adminUser", "admin")
+
+    suite.useCase.On("Promote", mock.Anything, username, "manager").Return(&domain.UserInfo{UserId: "2", Username: username}, nil)
+
+    req, _ := http.NewRequest("POST", "/promote/"+username, bytes.NewBufferString(`{"role":"manager"}`))
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+
+    suite.router.ServeHTTP(w, req)
+    suite.Equal(http.StatusOK, w.Code)
+    suite.JSONEq(`{"message":"User promoted to manager"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestPromoteUser_UnknownRole() {
+    username := "testUser"
+    tokenString := suite.createTestJWT("1", "adminUser", "admin")
+
+    suite.useCase.On("Promote", mock.Anything, username, "wizard").Return(nil, fmt.Errorf("%w %q", domain.ErrUnknownRole, "wizard"))
+
+    req, _ := http.NewRequest("POST", "/promote/"+username, bytes.NewBufferString(`{"role":"wizard"}`))
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+
+    suite.router.ServeHTTP(w, req)
+    suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *UserControllerTestSuite) TestRefreshToken_Success() {
+    payload := &domain.RefreshRequest{RefreshToken: "refreshToken"}
+    tokens := &domain.TokenPair{AccessToken: "newJwt", RefreshToken: "newRefresh", TokenType: "Bearer", ExpiresIn: 900}
+    suite.useCase.On("Refresh", mock.Anything, payload).Return(tokens, nil)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.Contains(w.Body.String(), `"refresh_token":"newRefresh"`)
+}
+
+func (suite *UserControllerTestSuite) TestRefreshToken_Invalid() {
+    payload := &domain.RefreshRequest{RefreshToken: "reused"}
+    suite.useCase.On("Refresh", mock.Anything, payload).Return(nil, domain.ErrInvalidRefreshToken)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusUnauthorized, w.Code)
+}
+
+func (suite *UserControllerTestSuite) TestRefreshToken_BadRequest() {
+    req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(`{}`))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusUnprocessableEntity, w.Code)
+    suite.Contains(w.Body.String(), `"field":"refresh_token","code":"required"`)
+    suite.useCase.AssertNotCalled(suite.T(), "Refresh", mock.Anything, mock.Anything)
+}
+
+func (suite *UserControllerTestSuite) TestLogout_Success() {
+    payload := &domain.LogoutRequest{RefreshToken: "refreshToken", AllSessions: true}
+    suite.useCase.On("Logout", mock.Anything, payload).Return(nil)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/auth/logout", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.useCase.AssertCalled(suite.T(), "Logout", mock.Anything, payload)
+}
+
+func (suite *UserControllerTestSuite) TestLogout_Invalid() {
+    payload := &domain.LogoutRequest{RefreshToken: "unknown"}
+    suite.useCase.On("Logout", mock.Anything, payload).Return(domain.ErrInvalidRefreshToken)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/auth/logout", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusUnauthorized, w.Code)
+}
+
+func (suite *UserControllerTestSuite) TestUnlockUser_Success() {
+    tokenString := suite.createTestJWT("1", "adminUser", "admin")
+    suite.useCase.On("Unlock", mock.Anything, "testUser").Return(nil)
+
+    req, _ := http.NewRequest("POST", "/unlock/testUser", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.JSONEq(`{"message":"User unlocked"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestUnlockUser_Forbidden() {
+    tokenString := suite.createTestJWT("1", "someUser", "user")
+
+    req, _ := http.NewRequest("POST", "/unlock/testUser", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusForbidden, w.Code)
+    suite.useCase.AssertNotCalled(suite.T(), "Unlock", mock.Anything, mock.Anything)
+}
+
+func (suite *UserControllerTestSuite) TestChangePassword_Success() {
+    tokenString := suite.createTestJWT("1", "testUser", "user")
+    payload := &domain.PasswordChange{CurrentPassword: "old", NewPassword: "new"}
+    suite.useCase.On("ChangePassword", mock.Anything, mock.MatchedBy(func(user *domain.AuthenticatedUser) bool {
+        return user.UserID == "1"
+    }), payload).Return(&domain.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}, nil)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/me/password", bytes.NewBuffer(body))
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.Contains(w.Body.String(), `"token":"access"`)
+    suite.Contains(w.Body.String(), `"refresh_token":"refresh"`)
+}
+
+func (suite *UserControllerTestSuite) TestChangePassword_WrongPassword() {
+    tokenString := suite.createTestJWT("1", "testUser", "user")
+    suite.useCase.On("ChangePassword", mock.Anything, mock.Anything, mock.Anything).Return(nil, domain.ErrWrongPassword)
+
+    req, _ := http.NewRequest("POST", "/me/password", strings.NewReader(`{"current_password":"guess","new_password":"new"}`))
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusForbidden, w.Code)
+    suite.JSONEq(`{"error":"current password is incorrect"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestForgotPassword_Accepted() {
+    payload := &domain.PasswordResetRequest{Email: "test@example.com"}
+    suite.useCase.On("RequestPasswordReset", mock.Anything, payload).Return(nil)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/auth/forgot", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusAccepted, w.Code)
+}
+
+func (suite *UserControllerTestSuite) TestResetPassword_InvalidToken() {
+    payload := &domain.PasswordReset{Token: "stale", NewPassword: "new"}
+    suite.useCase.On("ResetPassword", mock.Anything, payload).Return(domain.ErrInvalidResetToken)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/auth/reset", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusBadRequest, w.Code)
+    suite.JSONEq(`{"error":"invalid or expired password reset token"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestLoginUser_EmailNotVerified() {
+    payload := &domain.UserLogin{Username: "test", Password: "test"}
+    suite.useCase.On("Login", mock.Anything, payload).Return(nil, domain.ErrEmailNotVerified)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusForbidden, w.Code)
+    suite.JSONEq(`{"error":"email address has not been verified"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestVerifyEmail_Success() {
+    suite.useCase.On("VerifyEmail", mock.Anything, "signed.token").Return(nil)
+
+    req, _ := http.NewRequest("GET", "/auth/verify?token=signed.token", nil)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.JSONEq(`{"message":"Email address verified"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestVerifyEmail_InvalidToken() {
+    suite.useCase.On("VerifyEmail", mock.Anything, "stale").Return(domain.ErrInvalidVerificationToken)
+
+    req, _ := http.NewRequest("GET", "/auth/verify?token=stale", nil)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusBadRequest, w.Code)
+    suite.JSONEq(`{"error":"invalid or expired email verification token"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestResendVerification_Accepted() {
+    payload := &domain.VerificationResendRequest{Email: "test@example.com"}
+    suite.useCase.On("ResendVerification", mock.Anything, payload).Return(nil)
+
+    body, _ := json.Marshal(payload)
+    req, _ := http.NewRequest("POST", "/auth/verify/resend", bytes.NewBuffer(body))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusAccepted, w.Code)
+}
+
+func (suite *UserControllerTestSuite) TestListUsers_Success() {
+    tokenString := suite.createTestJWT("1", "admin", "admin")
+    suite.useCase.On("List", mock.Anything, &domain.UserQuery{Role: "user", Limit: 10, Cursor: "abc"}).Return(&domain.UserPage{
+        Items:      []*domain.UserInfo{{UserId: "2", Username: "test", Email: "test@example.com", Role: "user"}},
+        NextCursor: "2",
+    }, nil)
+
+    req, _ := http.NewRequest("GET", "/users?role=user&limit=10&cursor=abc", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.JSONEq(`{"items":[{"user_id":"2","username":"test","email":"test@example.com","email_verified":false,"role":"user","deactivated":false}],"next_cursor":"2"}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestListUsers_Rejected() {
+    req, _ := http.NewRequest("GET", "/users", nil)
+    req.Header.Set("Authorization", "Bearer "+suite.createTestJWT("2", "test", "user"))
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+    suite.Equal(http.StatusForbidden, w.Code)
+
+    req, _ = http.NewRequest("GET", "/users?limit=0", nil)
+    req.Header.Set("Authorization", "Bearer "+suite.createTestJWT("1", "admin", "admin"))
+    w = httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+    suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *UserControllerTestSuite) TestGetProfile() {
+    tokenString := suite.createTestJWT("1", "test", "user")
+    suite.useCase.On("Profile", mock.Anything, mock.MatchedBy(func(user *domain.AuthenticatedUser) bool {
+        return user.UserID == "1"
+    })).Return(&domain.UserInfo{UserId: "1", Username: "test", Email: "test@example.com", Role: "user"}, nil)
+
+    req, _ := http.NewRequest("GET", "/me", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.Contains(w.Body.String(), `"username":"test"`)
+}
+
+func (suite *UserControllerTestSuite) TestUpdateProfile_RenameReturnsTokens() {
+    tokenString := suite.createTestJWT("1", "test", "user")
+    profile := &domain.UserInfo{UserId: "1", Username: "renamed", Email: "test@example.com", Role: "user"}
+    tokens := &domain.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}
+    suite.useCase.On("UpdateProfile", mock.Anything, mock.Anything, mock.Anything).Return(profile, tokens, nil)
+
+    req, _ := http.NewRequest("PATCH", "/me", strings.NewReader(`{"username":"renamed"}`))
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusOK, w.Code)
+    suite.JSONEq(`{"user_id":"1","username":"renamed","email":"test@example.com","email_verified":false,"role":"user","deactivated":false,
+        "token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":900}`, w.Body.String())
+}
+
+func (suite *UserControllerTestSuite) TestUpdateProfile_UsernameTaken() {
+    tokenString := suite.createTestJWT("1", "test", "user")
+    suite.useCase.On("UpdateProfile", mock.Anything, mock.Anything, mock.MatchedBy(func(payload *domain.UserProfileUpdate) bool {
+        return *payload.Username == "taken" && payload.Email == nil
+    })).Return(nil, nil, domain.ErrUserExists)
+
+    req, _ := http.NewRequest("PATCH", "/me", strings.NewReader(`{"username":"taken"}`))
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusConflict, w.Code)
+}
+
+func (suite *UserControllerTestSuite) TestUpdateUser_LastAdmin() {
+    tokenString := suite.createTestJWT("1", "admin", "admin")
+    suite.useCase.On("Update", mock.Anything, mock.Anything, "1", mock.MatchedBy(func(payload *domain.UserUpdate) bool {
+        return payload.Role == nil && payload.Active != nil && !*payload.Active
+    })).Return(nil, domain.ErrLastAdmin)
+
+    req, _ := http.NewRequest("PATCH", "/users/1", strings.NewReader(`{"active":false}`))
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    suite.router.ServeHTTP(w, req)
+
+    suite.Equal(http.StatusConflict, w.Code)
+    suite.JSONEq(`{"error":"the last active admin cannot be demoted or deactivated"}`, w.Body.String())
 }
 
 func TestUserControllerTestSuite(t *testing.T) {
@@ -1,17 +1,22 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
 
 	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(secret string) gin.HandlerFunc {
+// AuthMiddleware validates the bearer JWT against the key ring and stores the
+// caller as "AuthenticatedUser". When users is not nil, tokens whose "ver"
+// claim no longer matches the user's token version are rejected as revoked,
+// as are the tokens of deactivated users.
+func AuthMiddleware(keys *utils.KeyRing, users domain.UserRepository) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -28,13 +33,7 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-			token, err := jwt.Parse(authParts[1], func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-
-				return []byte(secret), nil
-		})
+		token, err := jwt.Parse(authParts[1], keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid JWT"})
@@ -72,11 +71,37 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "role not found in token"})
 				return
 			}
+
+			// Tokens issued before versioning carry no "ver" claim and count as version 0
+			version, _ := claims["ver"].(float64)
+			TokenVersion := int(version)
+
+			if users != nil {
+				user, err := users.GetById(c, UserID)
+				if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+					// the store failing says nothing about the token, so it is not a 401
+					c.Error(err)
+					c.Abort()
+					return
+				}
+				if err != nil || user.TokenVersion != TokenVersion {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+					return
+				}
+				if user.Deactivated {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Account has been deactivated"})
+					return
+				}
+			}
+
 			c.Set("AuthenticatedUser", &domain.AuthenticatedUser{
 				UserID: UserID,
 				Username: Username,
 				Role: Role,
+				TokenVersion: TokenVersion,
 			})
+			// Everything logged from here on says who made the request
+			c.Request = c.Request.WithContext(utils.WithLogger(c.Request.Context(), utils.Logger(c.Request.Context()).With("username", Username)))
 	
 			c.Next()
 		}
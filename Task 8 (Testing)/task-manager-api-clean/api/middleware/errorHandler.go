@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler answers for handlers that gave up with ctx.Error. The status
+// code follows from the kind of domain error; anything else is logged and
+// reported as a bare 500 so driver messages never reach the client. Rejected
+// input fields are listed in a 422 so clients can point at each of them.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		var invalid *domain.ValidationError
+		if errors.As(err, &invalid) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"errors": invalid.Fields})
+			return
+		}
+
+		status := StatusCode(err)
+		if status == http.StatusInternalServerError {
+			utils.Logger(c.Request.Context()).Error("request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+			c.JSON(status, gin.H{"error": "internal server error"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+	}
+}
+
+// StatusCode maps a domain error kind to the HTTP status reporting it.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
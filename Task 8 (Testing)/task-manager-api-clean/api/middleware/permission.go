@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission lets the request through when the authenticated user's
+// role holds at least one of the permissions, and answers 403 otherwise. It
+// must run after AuthMiddleware.
+func RequirePermission(policy *domain.Policy, permissions ...domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := utils.CheckUser(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		for _, permission := range permissions {
+			if policy.Allows(user.Role, permission) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: your role does not allow this action"})
+	}
+}
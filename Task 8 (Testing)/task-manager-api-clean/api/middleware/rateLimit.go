@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"task-manager-api-clean/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit takes a token for every request from the bucket key picks out,
+// answering 429 with a Retry-After header once the bucket is empty. Requests
+// key returns nothing for are let through.
+func RateLimit(limiter domain.RateLimiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter, err := limiter.Allow(c.Request.Context(), k)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.Error(domain.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClientIP keys a rate limit by the address of the client, which is only
+// taken from X-Forwarded-For when the request came through a trusted proxy.
+func ClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// LoginUsername keys a rate limit by the username of a login request, so
+// guesses spread over many addresses still count against the same account.
+func LoginUsername(c *gin.Context) string {
+	return bodyField(c, "username")
+}
+
+// VerificationEmail keys a rate limit by the address a verification link is
+// asked for, so nobody can flood an inbox with them.
+func VerificationEmail(c *gin.Context) string {
+	return strings.ToLower(bodyField(c, "email"))
+}
+
+// maxKeyedBodySize caps the bodies read to find a rate limit key. The bodies
+// keyed on are small credentials, so anything larger is no real request.
+const maxKeyedBodySize = 16 << 10
+
+// bodyField reads a string field of a JSON request body and puts the body
+// back for the handler to read. A body over maxKeyedBodySize is not read any
+// further and is left failing for the handler too, so padding a body cannot
+// slip past the limit.
+func bodyField(c *gin.Context, name string) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	limited := http.MaxBytesReader(c.Writer, c.Request.Body, maxKeyedBodySize)
+	body, err := io.ReadAll(limited)
+	if err != nil {
+		c.Request.Body = limited
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) != nil {
+		return ""
+	}
+	value, _ := fields[name].(string)
+	return value
+}
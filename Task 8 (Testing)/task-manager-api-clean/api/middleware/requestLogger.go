@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID that ties a request to everything logged
+// while serving it.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID accepts IDs from upstream proxies as long as they cannot
+// forge log lines.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestLogger tags each request with an ID, taken from the X-Request-ID
+// header or generated, and puts a logger carrying it into the request
+// context, where use cases and repositories find it with utils.Logger. Once
+// the request is served it logs the route, status and latency. The engine
+// must have ContextWithFallback set for the gin context to expose it.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		requestLogger := logger.With("request_id", requestID)
+		c.Request = c.Request.WithContext(utils.WithLogger(c.Request.Context(), requestLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if value, ok := c.Get("AuthenticatedUser"); ok {
+			if user, ok := value.(*domain.AuthenticatedUser); ok {
+				attrs = append(attrs, "username", user.Username)
+			}
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		requestLogger.Log(c.Request.Context(), level, "request served", attrs...)
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"task-manager-api-clean/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests no route matched, so scanners probing random
+// paths cannot blow up the number of series.
+const unmatchedRoute = "unmatched"
+
+// knownMethods are labelled as they are, any other method as "other".
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// RequestMetrics counts and times every request by its route pattern, such
+// as "/tasks/:id", rather than by path.
+func RequestMetrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		if !knownMethods[method] {
+			method = "other"
+		}
+		m.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The document is written in YAML, which is easier to keep up by hand, and
+// served as JSON, which every client reads.
+//
+//go:embed openapi.yaml
+var source []byte
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// The Swagger UI assets are vendored from swagger-ui-dist, see
+// swagger-ui/README.md.
+//
+//go:embed swagger-ui
+var swaggerAssets embed.FS
+
+var (
+	once    sync.Once
+	encoded []byte
+	loadErr error
+)
+
+// JSON returns the OpenAPI document of the API, converted once on first use.
+func JSON() ([]byte, error) {
+	once.Do(func() {
+		var document map[string]interface{}
+		if err := yaml.Unmarshal(source, &document); err != nil {
+			loadErr = fmt.Errorf("failed to parse OpenAPI document: %w", err)
+			return
+		}
+		encoded, loadErr = json.Marshal(document)
+		if loadErr != nil {
+			loadErr = fmt.Errorf("failed to encode OpenAPI document: %w", loadErr)
+		}
+	})
+	return encoded, loadErr
+}
+
+// SwaggerUI returns a page browsing the document served at /openapi.json.
+// It loads the Swagger UI assets from SwaggerAssets, served under /docs/.
+func SwaggerUI() []byte {
+	return swaggerUI
+}
+
+// SwaggerAssets returns the Swagger UI stylesheet, script and icons.
+func SwaggerAssets() fs.FS {
+	assets, _ := fs.Sub(swaggerAssets, "swagger-ui")
+	return assets
+}
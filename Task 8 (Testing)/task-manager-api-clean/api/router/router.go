@@ -1,49 +1,156 @@
 package router
 
 import (
+	"context"
+
 	"task-manager-api-clean/config"
 	"task-manager-api-clean/api/controller"
 	"task-manager-api-clean/api/middleware"
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/mail"
+	"task-manager-api-clean/metrics"
 	"task-manager-api-clean/repository"
+	"task-manager-api-clean/repository/memory"
 	"task-manager-api-clean/usecase"
+	"task-manager-api-clean/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func Setup(env *config.Environment, db *mongo.Database, gin *gin.Engine) {
-	// Initialize repositories
-	userRepository := repository.NewUserRepository(db, "users")
-	taskRepository := repository.NewTaskRepository(db, "tasks")
+// Setup wires the repositories, use cases and routes onto gin. Background
+// jobs it starts run until ctx is done. The MongoDB schema is expected to be
+// migrated already.
+func Setup(ctx context.Context, env *config.Environment, keys *utils.KeyRing, policy *domain.Policy, workflow *domain.Workflow, db *mongo.Database, gin *gin.Engine) error {
+	// Every request, probes included, is counted and timed
+	apiMetrics := metrics.New()
+	gin.Use(middleware.RequestMetrics(apiMetrics))
+
+	// Initialize repositories, timing the storage operations apart from the handlers
+	userRepository, taskRepository, refreshTokenRepository, historyRepository, passwordResetRepository := newRepositories(env, db)
+	userRepository = metrics.NewUserRepository(userRepository, apiMetrics)
+	taskRepository = metrics.NewTaskRepository(taskRepository, apiMetrics)
 
 	// Initialize use cases
-	userUseCase := usecase.NewUserUseCase(userRepository, env)
-	taskUseCase := usecase.NewTaskUseCase(taskRepository)
+	userUseCase := metrics.NewUserUseCase(usecase.NewUserUseCase(userRepository, refreshTokenRepository, passwordResetRepository, newMailer(env), keys, policy, env), apiMetrics)
+	taskUseCase := usecase.NewTaskUseCase(taskRepository, historyRepository, userRepository, policy, workflow)
+	healthUseCase := usecase.NewHealthUseCase(env.HealthCheckTimeout, newHealthChecks(env, db)...)
+
+	// Without an admin, log the token for creating one
+	if err := userUseCase.BootstrapAdmin(ctx); err != nil {
+		return err
+	}
+
+	// Empty the trash of tasks kept past their retention period
+	taskPurger := usecase.NewTaskPurger(taskRepository, historyRepository, env.TrashRetention, env.TrashPurgeInterval)
+	go taskPurger.Run(ctx)
 
 	// Initialize controllers
 	userController := controller.NewUserController(userUseCase)
 	taskController := controller.NewTaskController(taskUseCase)
+	keyController := controller.NewKeyController(keys)
+	healthController := controller.NewHealthController(ctx, healthUseCase)
+	metricsController := controller.NewMetricsController(apiMetrics)
+	docsController := controller.NewDocsController()
+
+	// Probes for the orchestrator, ahead of any middleware that could fail them
+	gin.GET("/healthz", healthController.Liveness)
+	gin.GET("/readyz", healthController.Readiness)
+	gin.GET("/metrics", metricsController.GetMetrics)
 
 	// Middleware
-	authMiddleware := middleware.AuthMiddleware(env.JwtSecret)
+	gin.Use(middleware.ErrorHandler())
+	authMiddleware := middleware.AuthMiddleware(keys, userRepository)
+
+	// Password guessing is slowed down per client and per account alike.
+	// Password resets and verification links share the client limit, as
+	// they also send email.
+	loginLimitIP := middleware.RateLimit(memory.NewRateLimiter(env.LoginRateLimitIP.Requests, env.LoginRateLimitIP.Per), middleware.ClientIP)
+	loginLimitUsername := middleware.RateLimit(memory.NewRateLimiter(env.LoginRateLimitUsername.Requests, env.LoginRateLimitUsername.Per), middleware.LoginUsername)
+	resendLimitEmail := middleware.RateLimit(memory.NewRateLimiter(env.VerificationResendRateLimit.Requests, env.VerificationResendRateLimit.Per), middleware.VerificationEmail)
 
 	// User routes
 	userRouter := gin.Group("")
 	{
 		userRouter.POST("/register", userController.CreateUser)
-		userRouter.POST("/login", userController.LoginUser)
-		userRouter.POST("/promote/:username", authMiddleware, userController.PromoteUser)
+		userRouter.POST("/setup", loginLimitIP, userController.SetupAdmin)
+		userRouter.POST("/login", loginLimitIP, loginLimitUsername, userController.LoginUser)
+		userRouter.POST("/promote/:username", authMiddleware, middleware.RequirePermission(policy, domain.PermUserPromote), userController.PromoteUser)
+		userRouter.POST("/unlock/:username", authMiddleware, middleware.RequirePermission(policy, domain.PermUserUnlock), userController.UnlockUser)
+		userRouter.GET("/me", authMiddleware, userController.GetProfile)
+		userRouter.PATCH("/me", authMiddleware, userController.UpdateProfile)
+		userRouter.POST("/me/password", authMiddleware, userController.ChangePassword)
+		userRouter.GET("/users", authMiddleware, middleware.RequirePermission(policy, domain.PermUserRead), userController.ListUsers)
+		userRouter.PATCH("/users/:id", authMiddleware, middleware.RequirePermission(policy, domain.PermUserPromote, domain.PermUserDeactivate), userController.UpdateUser)
+	}
+
+	// Public keys for services verifying our tokens
+	gin.GET("/.well-known/jwks.json", keyController.GetJWKS)
+
+	// API description, see api/openapi
+	gin.GET("/openapi.json", docsController.GetSpec)
+	gin.GET("/docs", docsController.GetSwaggerUI)
+	gin.GET("/docs/:asset", docsController.GetSwaggerAsset)
+
+	// Session routes
+	authRouter := gin.Group("auth")
+	{
+		authRouter.POST("/refresh", userController.RefreshToken)
+		authRouter.POST("/logout", userController.Logout)
+		authRouter.POST("/forgot", loginLimitIP, userController.ForgotPassword)
+		authRouter.POST("/reset", loginLimitIP, userController.ResetPassword)
+		authRouter.GET("/verify", userController.VerifyEmail)
+		authRouter.POST("/verify/resend", loginLimitIP, resendLimitEmail, userController.ResendVerification)
 	}
 
 	// Task routes
 	taskRouter := gin.Group("tasks")
 	taskRouter.Use(authMiddleware)
 	{
-		taskRouter.GET("/", taskController.GetTasks)
-		taskRouter.GET("/:id", taskController.GetTaskByID)
-		taskRouter.PATCH("/:id", taskController.UpdateTask)
-		taskRouter.POST("/", taskController.CreateTask)
-		taskRouter.DELETE("/:id", taskController.DeleteTask)
+		taskRouter.GET("/", middleware.RequirePermission(policy, domain.PermTaskRead, domain.PermTaskReadAny), taskController.GetTasks)
+		taskRouter.GET("/trash", middleware.RequirePermission(policy, domain.PermTaskDelete), taskController.GetTrash)
+		taskRouter.GET("/:id", middleware.RequirePermission(policy, domain.PermTaskRead, domain.PermTaskReadAny), taskController.GetTaskByID)
+		taskRouter.PATCH("/:id", middleware.RequirePermission(policy, domain.PermTaskUpdate, domain.PermTaskUpdateAny), taskController.UpdateTask)
+		taskRouter.PUT("/:id", middleware.RequirePermission(policy, domain.PermTaskUpdate, domain.PermTaskUpdateAny), taskController.ReplaceTask)
+		taskRouter.POST("/", middleware.RequirePermission(policy, domain.PermTaskCreate), taskController.CreateTask)
+		taskRouter.DELETE("/:id", middleware.RequirePermission(policy, domain.PermTaskDelete), taskController.DeleteTask)
+		taskRouter.POST("/:id/restore", middleware.RequirePermission(policy, domain.PermTaskDelete), taskController.RestoreTask)
+		taskRouter.PUT("/:id/assignees", middleware.RequirePermission(policy, domain.PermTaskAssign), taskController.AssignTask)
+		taskRouter.POST("/:id/transition", middleware.RequirePermission(policy, domain.PermTaskUpdate, domain.PermTaskUpdateAny), taskController.TransitionTask)
+		taskRouter.GET("/:id/history", middleware.RequirePermission(policy, domain.PermTaskRead, domain.PermTaskReadAny), taskController.GetTaskHistory)
+	}
+
+	// Audit trail across all tasks
+	gin.GET("/audit", authMiddleware, middleware.RequirePermission(policy, domain.PermAuditRead), taskController.GetAudit)
+	return nil
+}
+
+
+// newRepositories picks the storage implementation selected by STORAGE_DRIVER.
+func newRepositories(env *config.Environment, db *mongo.Database) (domain.UserRepository, domain.TaskRepository, domain.RefreshTokenRepository, domain.HistoryRepository, domain.PasswordResetRepository) {
+	if env.StorageDriver == config.StorageMemory {
+		return memory.NewUserRepository(), memory.NewTaskRepository(), memory.NewRefreshTokenRepository(), memory.NewHistoryRepository(), memory.NewPasswordResetRepository()
 	}
+	return repository.NewUserRepository(db, repository.UsersCollection), repository.NewTaskRepository(db, repository.TasksCollection), repository.NewRefreshTokenRepository(db, repository.RefreshTokensCollection), repository.NewHistoryRepository(db, repository.HistoryCollection), repository.NewPasswordResetRepository(db, repository.PasswordResetsCollection)
 }
 
+// newMailer picks the mail delivery selected by MAIL_DRIVER.
+func newMailer(env *config.Environment) domain.Mailer {
+	switch env.MailDriver {
+	case config.MailSMTP:
+		return mail.NewSMTPMailer(env.SMTPHost, env.SMTPPort, env.SMTPUsername, env.SMTPPassword, env.MailFrom)
+	case config.MailFile:
+		return mail.NewFileMailer(env.MailFile, env.MailFrom)
+	default:
+		return mail.NewLogMailer()
+	}
+}
+
+// newHealthChecks returns the readiness checks of the storage selected by
+// STORAGE_DRIVER. The in-memory store cannot be unreachable, so it has none.
+func newHealthChecks(env *config.Environment, db *mongo.Database) []domain.HealthCheck {
+	if env.StorageDriver == config.StorageMemory {
+		return nil
+	}
+	return []domain.HealthCheck{repository.NewHealthCheck(db)}
+}
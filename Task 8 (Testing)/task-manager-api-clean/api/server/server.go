@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"task-manager-api-clean/config"
+)
+
+// maxReadHeaderTimeout caps how long a client may take to send the request
+// headers, so slow clients cannot hold connections open.
+const maxReadHeaderTimeout = 5 * time.Second
+
+// New returns an HTTP server for handler listening on env.Addr() with the
+// configured timeouts.
+func New(env *config.Environment, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              env.Addr(),
+		Handler:           handler,
+		ReadTimeout:       env.ReadTimeout,
+		ReadHeaderTimeout: min(env.ReadTimeout, maxReadHeaderTimeout),
+		WriteTimeout:      env.WriteTimeout,
+		IdleTimeout:       env.IdleTimeout,
+	}
+}
+
+// Lifecycle says how a server stops once asked to.
+type Lifecycle struct {
+	// ShutdownDelay keeps serving for a while after readiness starts failing,
+	// so load balancers stop sending traffic before connections are refused.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout bounds how long requests in flight may take to finish.
+	ShutdownTimeout time.Duration
+}
+
+// Run serves on srv until ctx is done, then stops accepting connections and
+// gives requests in flight time to finish. It returns an error if the server
+// could not listen or did not drain in time.
+func Run(ctx context.Context, srv *http.Server, lifecycle Lifecycle) error {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, srv, listener, lifecycle)
+}
+
+// Serve is Run on a listener that is already open.
+func Serve(ctx context.Context, srv *http.Server, listener net.Listener, lifecycle Lifecycle) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+	slog.Info("Listening", "address", listener.Addr().String())
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	if lifecycle.ShutdownDelay > 0 {
+		slog.Info("Shutting down after a delay", "delay", lifecycle.ShutdownDelay.String())
+		select {
+		case err := <-serveErr:
+			return err
+		case <-time.After(lifecycle.ShutdownDelay):
+		}
+	}
+
+	slog.Info("Shutting down, waiting for requests in flight", "timeout", lifecycle.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), lifecycle.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -3,10 +3,13 @@ package config
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
+	"task-manager-api-clean/utils"
+
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -14,31 +17,59 @@ import (
 
 var (
     mongoClient *mongo.Client
+    connectErr  error
     once        sync.Once
 )
 
 // GetMongoClient returns a singleton instance of the MongoDB client.
 func GetClient(uri string, dbName string) (*mongo.Database, error) {
-    var err error
     once.Do(func() {
-        clientOptions := options.Client().ApplyURI(uri)
+        clientOptions := options.Client().ApplyURI(uri).SetMonitor(commandMonitor())
         ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
         defer cancel()
 
-        mongoClient, err = mongo.Connect(ctx, clientOptions)
+        client, err := mongo.Connect(ctx, clientOptions)
         if err != nil {
-            log.Fatal(err)
+            connectErr = fmt.Errorf("failed to connect to MongoDB: %w", err)
+            return
         }
 
-
-        err = mongoClient.Ping(ctx, nil)
-        if err != nil {
-            log.Fatal(err)
+        if err = client.Ping(ctx, nil); err != nil {
+            client.Disconnect(ctx)
+            connectErr = fmt.Errorf("failed to reach MongoDB: %w", err)
+            return
         }
 
-        fmt.Println("Successfully connected to MongoDB")
+        mongoClient = client
+        slog.Info("Successfully connected to MongoDB")
     })
 
-    // Replace "your_database" with the name of your database
-    return mongoClient.Database(dbName), err
-}
\ No newline at end of file
+    if connectErr != nil {
+        return nil, connectErr
+    }
+    return mongoClient.Database(dbName), nil
+}
+
+// Disconnect closes the connections opened by GetClient, waiting for
+// operations in flight until ctx is done. It does nothing if GetClient never
+// connected.
+func Disconnect(ctx context.Context) error {
+    if mongoClient == nil {
+        return nil
+    }
+    return mongoClient.Disconnect(ctx)
+}
+
+// commandMonitor logs every database command at debug level with the logger
+// of the context it ran in, so commands can be traced to the request that
+// issued them.
+func commandMonitor() *event.CommandMonitor {
+    return &event.CommandMonitor{
+        Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+            utils.Logger(ctx).Debug("mongo command", "command", evt.CommandName, "database", evt.DatabaseName, "duration_ms", float64(evt.Duration.Microseconds())/1000)
+        },
+        Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+            utils.Logger(ctx).Warn("mongo command failed", "command", evt.CommandName, "database", evt.DatabaseName, "duration_ms", float64(evt.Duration.Microseconds())/1000, "error", evt.Failure)
+        },
+    }
+}
@@ -1,38 +1,332 @@
 package config
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// minSecretLength is the shortest JWT_SECRET accepted, the 256 bits HS256
+// needs to be as strong as its hash.
+const minSecretLength = 32
+
+// Supported values for STORAGE_DRIVER.
+const (
+	StorageMongo  = "mongo"
+	StorageMemory = "memory"
+)
+
+// Supported values for MAIL_DRIVER.
+const (
+	MailLog  = "log"
+	MailFile = "file"
+	MailSMTP = "smtp"
+)
+
 type Environment struct {
 	DatabaseURL string
 	DatabaseName string
 	JwtSecret   string
+	JwtSigningKey string
+	JwtVerificationKeys []string
 	JwtExpiration int
-	TimeOut string
+	RefreshExpiration int
+	Timeout time.Duration
+	BindAddress string
 	Port string
+	ReadTimeout time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout time.Duration
+	ShutdownTimeout time.Duration
+	ShutdownDelay time.Duration
+	HealthCheckTimeout time.Duration
+	LogLevel slog.Level
+	LogFormat string
+	StorageDriver string
+	Roles map[string][]string
+	TaskStatuses []string
+	TaskTransitions map[string][]string
+	TrashRetention time.Duration
+	TrashPurgeInterval time.Duration
+	TrustedProxies []string
+	LoginRateLimitIP RateLimit
+	LoginRateLimitUsername RateLimit
+	LockoutThreshold int
+	LockoutDuration time.Duration
+	LockoutMaxDuration time.Duration
+	MailDriver string
+	MailFrom string
+	MailFile string
+	SMTPHost string
+	SMTPPort string
+	SMTPUsername string
+	SMTPPassword string
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	RequireEmailVerification bool
+	EmailVerificationTTL time.Duration
+	EmailVerificationURL string
+	VerificationResendRateLimit RateLimit
+	MigrateOnStartup bool
 }
 
+// Load reads the configuration from the environment, a .env file and the
+// YAML file named by CONFIG_FILE, if any.
 func Load() (*Environment, error){
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile reads the configuration with path as the YAML config file, which
+// environment variables override. An empty path reads no file. Every invalid
+// setting is reported in a single *Error.
+func LoadFile(path string) (*Environment, error) {
 	// Load the environment variables
 	err := godotenv.Load()
     if err != nil {
-        log.Println("No .env file found, using environment variables")
+        slog.Info("No .env file found, using environment variables")
     }
-	
-	jwtExpirationStr := os.Getenv("JWT_EXPIRATION")
-	jwtExpiration, err := strconv.Atoi(jwtExpirationStr)
-	return &Environment{
-		DatabaseURL: os.Getenv("DATABASE_URL"),
-		JwtSecret: os.Getenv("JWT_SECRET"),
-		JwtExpiration: jwtExpiration,
-		Port: os.Getenv("PORT"),
-		TimeOut: os.Getenv("TIMEOUT"),
-		DatabaseName: os.Getenv("DATABASE_NAME"),
-	}, err
 
+	l, err := newLoader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, rolesErr := parseRoles(l.string("ROLE_PERMISSIONS", ""))
+	if rolesErr != nil {
+		l.problem("%v", rolesErr)
+	}
+
+	statuses, transitions, workflowErr := parseWorkflow(l.string("TASK_WORKFLOW", ""))
+	if workflowErr != nil {
+		l.problem("%v", workflowErr)
+	}
+
+	// TIMEOUT bounds reading a request and writing its response unless
+	// READ_TIMEOUT or WRITE_TIMEOUT say otherwise
+	timeout := l.duration("TIMEOUT", 30*time.Second)
+
+	env := &Environment{
+		DatabaseURL: l.string("DATABASE_URL", ""),
+		DatabaseName: l.string("DATABASE_NAME", ""),
+		JwtSecret: l.string("JWT_SECRET", ""),
+		JwtSigningKey: l.string("JWT_SIGNING_KEY", ""),
+		JwtVerificationKeys: l.list("JWT_VERIFICATION_KEYS"),
+		JwtExpiration: l.positiveInt("JWT_EXPIRATION", 15*60),
+		RefreshExpiration: l.positiveInt("REFRESH_TOKEN_EXPIRATION", 30*24*60*60),
+		Timeout: timeout,
+		BindAddress: l.string("BIND_ADDRESS", ""),
+		Port: l.string("PORT", "8080"),
+		ReadTimeout: l.duration("READ_TIMEOUT", timeout),
+		WriteTimeout: l.duration("WRITE_TIMEOUT", timeout),
+		IdleTimeout: l.duration("IDLE_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		ShutdownDelay: l.duration("SHUTDOWN_DELAY", 0),
+		HealthCheckTimeout: l.duration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		LogLevel: l.logLevel("LOG_LEVEL", slog.LevelInfo),
+		LogFormat: strings.ToLower(l.string("LOG_FORMAT", LogFormatJSON)),
+		StorageDriver: strings.ToLower(l.string("STORAGE_DRIVER", StorageMongo)),
+		Roles: roles,
+		TaskStatuses: statuses,
+		TaskTransitions: transitions,
+		TrashRetention: l.duration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: l.duration("TRASH_PURGE_INTERVAL", time.Hour),
+		TrustedProxies: l.list("TRUSTED_PROXIES"),
+		LoginRateLimitIP: l.rateLimit("LOGIN_RATE_LIMIT_IP", RateLimit{Requests: 20, Per: time.Minute}),
+		LoginRateLimitUsername: l.rateLimit("LOGIN_RATE_LIMIT_USERNAME", RateLimit{Requests: 5, Per: time.Minute}),
+		LockoutThreshold: l.positiveInt("LOCKOUT_THRESHOLD", 5),
+		LockoutDuration: l.duration("LOCKOUT_DURATION", time.Minute),
+		LockoutMaxDuration: l.duration("LOCKOUT_MAX_DURATION", time.Hour),
+		MailDriver: strings.ToLower(l.string("MAIL_DRIVER", MailLog)),
+		MailFrom: l.string("MAIL_FROM", "no-reply@localhost"),
+		MailFile: l.string("MAIL_FILE", ""),
+		SMTPHost: l.string("SMTP_HOST", ""),
+		SMTPPort: l.string("SMTP_PORT", "587"),
+		SMTPUsername: l.string("SMTP_USERNAME", ""),
+		SMTPPassword: l.string("SMTP_PASSWORD", ""),
+		PasswordResetTTL: l.duration("PASSWORD_RESET_TTL", time.Hour),
+		PasswordResetURL: l.string("PASSWORD_RESET_URL", ""),
+		RequireEmailVerification: l.boolean("REQUIRE_EMAIL_VERIFICATION", false),
+		EmailVerificationTTL: l.duration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		EmailVerificationURL: l.string("EMAIL_VERIFICATION_URL", ""),
+		VerificationResendRateLimit: l.rateLimit("VERIFICATION_RESEND_RATE_LIMIT", RateLimit{Requests: 3, Per: time.Hour}),
+		MigrateOnStartup: l.boolean("MIGRATE_ON_STARTUP", true),
+	}
+	env.validate(l)
+
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// validate reports settings that are well formed but unusable together.
+func (env *Environment) validate(l *loader) {
+	switch env.StorageDriver {
+	case StorageMongo:
+		if env.DatabaseURL == "" {
+			l.problem("DATABASE_URL is required with the %q storage driver", StorageMongo)
+		} else if u, err := url.Parse(env.DatabaseURL); err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") || u.Host == "" {
+			l.problem("DATABASE_URL must be a mongodb:// or mongodb+srv:// URL")
+		}
+		if env.DatabaseName == "" {
+			l.problem("DATABASE_NAME is required with the %q storage driver", StorageMongo)
+		}
+	case StorageMemory:
+	default:
+		l.problem("unsupported STORAGE_DRIVER %q, expected %q or %q", env.StorageDriver, StorageMongo, StorageMemory)
+	}
+
+	if env.JwtSecret == "" && env.JwtSigningKey == "" {
+		l.problem("either JWT_SECRET or JWT_SIGNING_KEY must be set")
+	} else if env.JwtSecret != "" && len(env.JwtSecret) < minSecretLength {
+		l.problem("JWT_SECRET must be at least %d bytes long", minSecretLength)
+	}
+
+	if env.LogFormat != LogFormatJSON && env.LogFormat != LogFormatText {
+		l.problem("unsupported LOG_FORMAT %q, expected %q or %q", env.LogFormat, LogFormatJSON, LogFormatText)
+	}
+
+	if env.LockoutMaxDuration < env.LockoutDuration {
+		l.problem("LOCKOUT_MAX_DURATION must not be shorter than LOCKOUT_DURATION")
+	}
+
+	for _, proxy := range env.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				l.problem("TRUSTED_PROXIES entry %q must be an IP address or a CIDR range", proxy)
+			}
+		}
+	}
+
+	if port, err := strconv.Atoi(env.Port); err != nil || port < 1 || port > 65535 {
+		l.problem("PORT %q must be a number between 1 and 65535", env.Port)
+	}
+
+	switch env.MailDriver {
+	case MailLog:
+	case MailFile:
+		if env.MailFile == "" {
+			l.problem("MAIL_FILE is required with the %q mail driver", MailFile)
+		}
+	case MailSMTP:
+		if env.SMTPHost == "" {
+			l.problem("SMTP_HOST is required with the %q mail driver", MailSMTP)
+		}
+		if port, err := strconv.Atoi(env.SMTPPort); err != nil || port < 1 || port > 65535 {
+			l.problem("SMTP_PORT %q must be a number between 1 and 65535", env.SMTPPort)
+		}
+	default:
+		l.problem("unsupported MAIL_DRIVER %q, expected %q, %q or %q", env.MailDriver, MailLog, MailFile, MailSMTP)
+	}
+	if address, err := mail.ParseAddress(env.MailFrom); err != nil || address.Address != env.MailFrom {
+		l.problem("MAIL_FROM %q must be a plain email address such as no-reply@example.com", env.MailFrom)
+	}
+	if env.PasswordResetURL != "" {
+		if u, err := url.Parse(env.PasswordResetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			l.problem("PASSWORD_RESET_URL must be an http:// or https:// URL")
+		}
+	}
+	if env.EmailVerificationURL != "" {
+		if u, err := url.Parse(env.EmailVerificationURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			l.problem("EMAIL_VERIFICATION_URL must be an http:// or https:// URL")
+		}
+	}
+}
+
+// Addr is the address the server listens on, BIND_ADDRESS:PORT. An empty
+// BIND_ADDRESS listens on every interface so the API is reachable from
+// outside a container.
+func (env *Environment) Addr() string {
+	return net.JoinHostPort(env.BindAddress, env.Port)
+}
+
+// AccessTokenTTL is how long an access token stays valid, JWT_EXPIRATION
+// seconds or 15 minutes when unset.
+func (env *Environment) AccessTokenTTL() time.Duration {
+	if env.JwtExpiration <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(env.JwtExpiration) * time.Second
+}
+
+// RefreshTokenTTL is how long a refresh token stays valid,
+// REFRESH_TOKEN_EXPIRATION seconds or 30 days when unset.
+func (env *Environment) RefreshTokenTTL() time.Duration {
+	if env.RefreshExpiration <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(env.RefreshExpiration) * time.Second
+}
+
+// LockoutAfter is how long an account is locked after the given number of
+// consecutive failed logins: nothing below LOCKOUT_THRESHOLD, then
+// LOCKOUT_DURATION, doubling with every further failure up to
+// LOCKOUT_MAX_DURATION.
+func (env *Environment) LockoutAfter(failures int) time.Duration {
+	if env.LockoutThreshold <= 0 || failures < env.LockoutThreshold {
+		return 0
+	}
+	lockout := env.LockoutDuration
+	for i := env.LockoutThreshold; i < failures && lockout < env.LockoutMaxDuration; i++ {
+		lockout *= 2
+	}
+	return min(lockout, env.LockoutMaxDuration)
+}
+
+// parseRoles reads ROLE_PERMISSIONS, a semicolon separated list of roles with
+// their comma separated permissions, e.g.
+// "manager=task:create,task:read:any;viewer=task:read:any".
+func parseRoles(value string) (map[string][]string, error) {
+	roles := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		role, permissions, found := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !found || role == "" {
+			return nil, fmt.Errorf("invalid ROLE_PERMISSIONS entry %q, expected role=permission,...", entry)
+		}
+		roles[role] = []string{}
+		for _, permission := range strings.Split(permissions, ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				roles[role] = append(roles[role], permission)
+			}
+		}
+	}
+	return roles, nil
+}
+
+// parseWorkflow reads TASK_WORKFLOW, a semicolon separated list of statuses,
+// each followed by the comma separated statuses it may move to, e.g.
+// "Todo=Doing;Doing=Todo,Done;Done". The first status is the initial one and
+// a status without "=" is final.
+func parseWorkflow(value string) ([]string, map[string][]string, error) {
+	var statuses []string
+	transitions := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		from, targets, _ := strings.Cut(entry, "=")
+		from = strings.TrimSpace(from)
+		if from == "" {
+			return nil, nil, fmt.Errorf("invalid TASK_WORKFLOW entry %q, expected status=status,...", entry)
+		}
+		statuses = append(statuses, from)
+		for _, to := range strings.Split(targets, ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				transitions[from] = append(transitions[from], to)
+			}
+		}
+	}
+	return statuses, transitions, nil
 }
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Error lists every problem found in the configuration, so all of them can be
+// fixed in one go instead of one per restart.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// loader reads settings by their environment variable name. A setting comes
+// from, in order of precedence, the variable itself, a file named by the
+// variable with a _FILE suffix (as mounted for Docker secrets), the config
+// file under the lower case name, or the default. Problems are collected
+// rather than returned so Load can report them together.
+type loader struct {
+	file     map[string]string
+	problems []string
+}
+
+// newLoader reads the optional YAML config file at path. Its top level keys
+// are the lower case names of the environment variables; a list is read as
+// its items joined by commas.
+func newLoader(path string) (*loader, error) {
+	l := &loader{file: make(map[string]string)}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	for key, value := range raw {
+		switch value := value.(type) {
+		case nil:
+		case []interface{}:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			l.file[strings.ToLower(key)] = strings.Join(items, ",")
+		case map[string]interface{}:
+			l.problem("config file key %q must be a single value or a list", key)
+		default:
+			l.file[strings.ToLower(key)] = fmt.Sprint(value)
+		}
+	}
+	return l, nil
+}
+
+func (l *loader) problem(format string, args ...interface{}) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+// err returns the problems found so far as an *Error, or nil.
+func (l *loader) err() error {
+	if len(l.problems) == 0 {
+		return nil
+	}
+	return &Error{Problems: l.problems}
+}
+
+// lookup returns the raw value of the named setting and whether it is set.
+func (l *loader) lookup(name string) (string, bool) {
+	value, inEnv := os.LookupEnv(name)
+	file, inFile := os.LookupEnv(name + "_FILE")
+	switch {
+	case inEnv && inFile:
+		l.problem("%s and %s_FILE are both set, use only one", name, name)
+		return "", false
+	case inEnv:
+		return strings.TrimSpace(value), value != ""
+	case inFile:
+		data, err := os.ReadFile(file)
+		if err != nil {
+			l.problem("%s_FILE: %v", name, err)
+			return "", false
+		}
+		value = strings.TrimSpace(string(data))
+		return value, value != ""
+	}
+	value, ok := l.file[strings.ToLower(name)]
+	return strings.TrimSpace(value), ok && value != ""
+}
+
+func (l *loader) string(name string, fallback string) string {
+	if value, ok := l.lookup(name); ok {
+		return value
+	}
+	return fallback
+}
+
+// list reads a comma separated list.
+func (l *loader) list(name string) []string {
+	var items []string
+	value, _ := l.lookup(name)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// positiveInt reads a whole number above zero.
+func (l *loader) positiveInt(name string, fallback int) int {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		l.problem("%s %q must be a positive whole number", name, value)
+		return fallback
+	}
+	return number
+}
+
+// boolean reads "true" or "false", or any other form strconv.ParseBool knows.
+func (l *loader) boolean(name string, fallback bool) bool {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		l.problem("%s %q must be true or false", name, value)
+		return fallback
+	}
+	return flag
+}
+
+// logLevel reads one of "debug", "info", "warn" or "error".
+func (l *loader) logLevel(name string, fallback slog.Level) slog.Level {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		l.problem("%s %q must be one of debug, info, warn or error", name, value)
+		return fallback
+	}
+	return level
+}
+
+// duration reads a positive duration such as "720h".
+func (l *loader) duration(name string, fallback time.Duration) time.Duration {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		l.problem("%s %q must be a positive duration such as \"24h\"", name, value)
+		return fallback
+	}
+	return duration
+}
+
+// RateLimit lets Requests through every Per, in bursts of up to Requests.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (r RateLimit) String() string {
+	return strconv.Itoa(r.Requests) + "/" + r.Per.String()
+}
+
+// rateLimit reads a rate limit written as requests/duration, such as "5/1m".
+func (l *loader) rateLimit(name string, fallback RateLimit) RateLimit {
+	value, ok := l.lookup(name)
+	if !ok {
+		return fallback
+	}
+	requests, per, _ := strings.Cut(value, "/")
+	limit := RateLimit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(strings.TrimSpace(requests)); err == nil {
+		limit.Per, err = time.ParseDuration(strings.TrimSpace(per))
+	}
+	if err != nil || limit.Requests <= 0 || limit.Per <= 0 {
+		l.problem("%s %q must be a number of requests per duration such as \"5/1m\"", name, value)
+		return fallback
+	}
+	return limit
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Supported values for LOG_FORMAT.
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// Logger returns a logger writing to w at LOG_LEVEL in LOG_FORMAT.
+func (env *Environment) Logger(w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: env.LogLevel}
+	if env.LogFormat == LogFormatText {
+		return slog.New(slog.NewTextHandler(w, options))
+	}
+	return slog.New(slog.NewJSONHandler(w, options))
+}
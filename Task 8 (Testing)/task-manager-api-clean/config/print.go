@@ -0,0 +1,126 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted stands in for secrets in printed configuration.
+const redacted = "[redacted]"
+
+// Print writes the effective configuration as a YAML config file, with the
+// JWT secret, the SMTP password and any database password redacted.
+func (env *Environment) Print(w io.Writer) error {
+	databaseURL := env.DatabaseURL
+	if u, err := url.Parse(databaseURL); err == nil {
+		databaseURL = u.Redacted()
+	}
+	jwtSecret := ""
+	if env.JwtSecret != "" {
+		jwtSecret = redacted
+	}
+	smtpPassword := ""
+	if env.SMTPPassword != "" {
+		smtpPassword = redacted
+	}
+
+	settings := []struct{ key, value string }{
+		{"storage_driver", env.StorageDriver},
+		{"database_url", databaseURL},
+		{"database_name", env.DatabaseName},
+		{"bind_address", env.BindAddress},
+		{"port", env.Port},
+		{"timeout", env.Timeout.String()},
+		{"read_timeout", env.ReadTimeout.String()},
+		{"write_timeout", env.WriteTimeout.String()},
+		{"idle_timeout", env.IdleTimeout.String()},
+		{"shutdown_timeout", env.ShutdownTimeout.String()},
+		{"shutdown_delay", optionalDuration(env.ShutdownDelay)},
+		{"health_check_timeout", env.HealthCheckTimeout.String()},
+		{"log_level", strings.ToLower(env.LogLevel.String())},
+		{"log_format", env.LogFormat},
+		{"jwt_secret", jwtSecret},
+		{"jwt_signing_key", env.JwtSigningKey},
+		{"jwt_verification_keys", strings.Join(env.JwtVerificationKeys, ",")},
+		{"jwt_expiration", strconv.Itoa(env.JwtExpiration)},
+		{"refresh_token_expiration", strconv.Itoa(env.RefreshExpiration)},
+		{"role_permissions", formatRoles(env.Roles)},
+		{"task_workflow", formatWorkflow(env.TaskStatuses, env.TaskTransitions)},
+		{"trash_retention", env.TrashRetention.String()},
+		{"trash_purge_interval", env.TrashPurgeInterval.String()},
+		{"trusted_proxies", strings.Join(env.TrustedProxies, ",")},
+		{"login_rate_limit_ip", env.LoginRateLimitIP.String()},
+		{"login_rate_limit_username", env.LoginRateLimitUsername.String()},
+		{"lockout_threshold", strconv.Itoa(env.LockoutThreshold)},
+		{"lockout_duration", env.LockoutDuration.String()},
+		{"lockout_max_duration", env.LockoutMaxDuration.String()},
+		{"mail_driver", env.MailDriver},
+		{"mail_from", env.MailFrom},
+		{"mail_file", env.MailFile},
+		{"smtp_host", env.SMTPHost},
+		{"smtp_port", env.SMTPPort},
+		{"smtp_username", env.SMTPUsername},
+		{"smtp_password", smtpPassword},
+		{"password_reset_ttl", env.PasswordResetTTL.String()},
+		{"password_reset_url", env.PasswordResetURL},
+		{"require_email_verification", strconv.FormatBool(env.RequireEmailVerification)},
+		{"email_verification_ttl", env.EmailVerificationTTL.String()},
+		{"email_verification_url", env.EmailVerificationURL},
+		{"verification_resend_rate_limit", env.VerificationResendRateLimit.String()},
+		{"migrate_on_startup", strconv.FormatBool(env.MigrateOnStartup)},
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, setting := range settings {
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: setting.key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: setting.value, Style: yaml.DoubleQuotedStyle},
+		)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(doc)
+}
+
+// optionalDuration leaves a duration that is off unset, as zero durations
+// are not accepted back.
+func optionalDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// formatRoles writes roles in the ROLE_PERMISSIONS syntax.
+func formatRoles(roles map[string][]string) string {
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]string, len(names))
+	for i, name := range names {
+		entries[i] = name + "=" + strings.Join(roles[name], ",")
+	}
+	return strings.Join(entries, ";")
+}
+
+// formatWorkflow writes a workflow in the TASK_WORKFLOW syntax.
+func formatWorkflow(statuses []string, transitions map[string][]string) string {
+	entries := make([]string, len(statuses))
+	for i, status := range statuses {
+		entries[i] = status
+		if targets := transitions[status]; len(targets) > 0 {
+			entries[i] += "=" + strings.Join(targets, ",")
+		}
+	}
+	return strings.Join(entries, ";")
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failure. Every error the use cases return for a reason the caller
+// can act on wraps exactly one of them, so the API can pick a status code
+// with errors.Is without knowing which storage backend or use case failed.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrValidation      = errors.New("validation failed")
+	ErrForbidden       = errors.New("forbidden")
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrRateLimited     = errors.New("rate limited")
+	// ErrUnsupportedMediaType is a request body in a format the endpoint does not take.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// Error is a failure of a given kind with a message meant for the client.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NewError returns an error of the given kind, one of ErrNotFound,
+// ErrConflict, ErrValidation, ErrForbidden, ErrUnauthenticated,
+// ErrRateLimited or ErrUnsupportedMediaType.
+func NewError(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "context"
+
+// Health statuses reported by the readiness check.
+const (
+	HealthOK           = "ok"
+	HealthUnavailable  = "unavailable"
+	HealthShuttingDown = "shutting_down"
+)
+
+// HealthCheck probes one dependency the API needs to serve requests. Storage
+// backends provide one so readiness follows whichever backend is configured.
+type HealthCheck interface {
+	Name() string
+	Check(c context.Context) error
+}
+
+// DependencyHealth is the outcome of one HealthCheck. Why a check failed is
+// only logged, so the public readiness answer reveals nothing about the
+// dependencies behind it.
+type DependencyHealth struct {
+	Status string `json:"status"`
+}
+
+// HealthReport is the outcome of all checks, ok only if every one of them is.
+type HealthReport struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyHealth `json:"checks"`
+}
+
+// HealthUseCase runs the registered health checks, each bounded by a timeout.
+type HealthUseCase interface {
+	Register(check HealthCheck)
+	Check(c context.Context) *HealthReport
+}
+
+// HealthCheckFunc turns a function into a named HealthCheck.
+func HealthCheckFunc(name string, check func(c context.Context) error) HealthCheck {
+	return &healthCheckFunc{name: name, check: check}
+}
+
+type healthCheckFunc struct {
+	name  string
+	check func(c context.Context) error
+}
+
+func (h *healthCheckFunc) Name() string {
+	return h.name
+}
+
+func (h *healthCheckFunc) Check(c context.Context) error {
+	return h.check(c)
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Actions recorded in the task history.
+const (
+	HistoryCreate     = "create"
+	HistoryUpdate     = "update"
+	HistoryTransition = "transition"
+	HistoryAssign     = "assign"
+	HistoryDelete     = "delete"
+	HistoryRestore    = "restore"
+	HistoryPurge      = "purge"
+)
+
+// FieldChange is one field of a task changing value. From is nil for a field
+// set by a create, To is nil for a field dropped by a delete.
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	From  interface{} `json:"from" bson:"from"`
+	To    interface{} `json:"to" bson:"to"`
+}
+
+// TaskHistoryEntry records who changed a task, when, and how.
+type TaskHistoryEntry struct {
+	Id            string        `json:"id" bson:"_id"`
+	TaskId        string        `json:"taskId" bson:"taskId"`
+	Action        string        `json:"action" bson:"action"`
+	ActorId       string        `json:"actorId" bson:"actorId"`
+	ActorUsername string        `json:"actorUsername" bson:"actorUsername"`
+	Changes       []FieldChange `json:"changes" bson:"changes"`
+	At            time.Time     `json:"at" bson:"at"`
+}
+
+// HistoryQuery narrows and pages history entries, which are always returned
+// newest first. Zero values mean "no filter".
+type HistoryQuery struct {
+	TaskId  string
+	ActorId string
+	Action  string
+	Limit   int
+	Cursor  string // the NextCursor of the previous page
+}
+
+type HistoryPage struct {
+	Items      []*TaskHistoryEntry `json:"items"`
+	NextCursor string              `json:"next_cursor"`
+}
+
+type HistoryRepository interface {
+	Record(c context.Context, entry *TaskHistoryEntry) error
+	List(c context.Context, query *HistoryQuery) (*HistoryPage, error)
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email, over SMTP in production or to a file or the log
+// during development.
+type Mailer interface {
+	Send(c context.Context, message *Message) error
+}
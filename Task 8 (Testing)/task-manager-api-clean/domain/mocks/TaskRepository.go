@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 	domain "task-manager-api-clean/domain"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -44,17 +45,17 @@ func (_m *TaskRepository) Create(c context.Context, task *domain.Task) (*domain.
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: c, id
-func (_m *TaskRepository) Delete(c context.Context, id string) error {
-	ret := _m.Called(c, id)
+// Delete provides a mock function with given fields: c, id, version
+func (_m *TaskRepository) Delete(c context.Context, id string, version int64) error {
+	ret := _m.Called(c, id, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(c, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(c, id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -62,29 +63,29 @@ func (_m *TaskRepository) Delete(c context.Context, id string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: c
-func (_m *TaskRepository) GetAll(c context.Context) (*[]*domain.Task, error) {
-	ret := _m.Called(c)
+// GetAll provides a mock function with given fields: c, query
+func (_m *TaskRepository) GetAll(c context.Context, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	ret := _m.Called(c, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAll")
 	}
 
-	var r0 *[]*domain.Task
+	var r0 *domain.TaskPage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*[]*domain.Task, error)); ok {
-		return rf(c)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.TaskQuery) (*domain.TaskPage, error)); ok {
+		return rf(c, query)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *[]*domain.Task); ok {
-		r0 = rf(c)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.TaskQuery) *domain.TaskPage); ok {
+		r0 = rf(c, query)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*[]*domain.Task)
+			r0 = ret.Get(0).(*domain.TaskPage)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(c)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.TaskQuery) error); ok {
+		r1 = rf(c, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -122,9 +123,99 @@ func (_m *TaskRepository) GetById(c context.Context, taskId string) (*domain.Tas
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: c, id, task
-func (_m *TaskRepository) Update(c context.Context, id string, task *domain.Task) (*domain.Task, error) {
-	ret := _m.Called(c, id, task)
+// GetTrashed provides a mock function with given fields: c, id
+func (_m *TaskRepository) GetTrashed(c context.Context, id string) (*domain.Task, error) {
+	ret := _m.Called(c, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTrashed")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Task, error)); ok {
+		return rf(c, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Task); ok {
+		r0 = rf(c, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: c, trashedBefore
+func (_m *TaskRepository) Purge(c context.Context, trashedBefore time.Time) ([]*domain.Task, error) {
+	ret := _m.Called(c, trashedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Purge")
+	}
+
+	var r0 []*domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]*domain.Task, error)); ok {
+		return rf(c, trashedBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []*domain.Task); ok {
+		r0 = rf(c, trashedBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(c, trashedBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: c, id
+func (_m *TaskRepository) Restore(c context.Context, id string) (*domain.Task, error) {
+	ret := _m.Called(c, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Task, error)); ok {
+		return rf(c, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Task); ok {
+		r0 = rf(c, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: c, id, task, fields
+func (_m *TaskRepository) Update(c context.Context, id string, task *domain.Task, fields []string) (*domain.Task, error) {
+	ret := _m.Called(c, id, task, fields)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
@@ -132,19 +223,49 @@ func (_m *TaskRepository) Update(c context.Context, id string, task *domain.Task
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Task) (*domain.Task, error)); ok {
-		return rf(c, id, task)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Task, []string) (*domain.Task, error)); ok {
+		return rf(c, id, task, fields)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Task, []string) *domain.Task); ok {
+		r0 = rf(c, id, task, fields)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.Task, []string) error); ok {
+		r1 = rf(c, id, task, fields)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAssignees provides a mock function with given fields: c, id, assigneeIDs, version
+func (_m *TaskRepository) UpdateAssignees(c context.Context, id string, assigneeIDs []string, version int64) (*domain.Task, error) {
+	ret := _m.Called(c, id, assigneeIDs, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAssignees")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int64) (*domain.Task, error)); ok {
+		return rf(c, id, assigneeIDs, version)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Task) *domain.Task); ok {
-		r0 = rf(c, id, task)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, int64) *domain.Task); ok {
+		r0 = rf(c, id, assigneeIDs, version)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.Task) error); ok {
-		r1 = rf(c, id, task)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, int64) error); ok {
+		r1 = rf(c, id, assigneeIDs, version)
 	} else {
 		r1 = ret.Error(1)
 	}
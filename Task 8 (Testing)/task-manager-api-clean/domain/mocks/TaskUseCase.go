@@ -14,9 +14,69 @@ type TaskUseCase struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: c, payload
-func (_m *TaskUseCase) Create(c context.Context, payload *domain.TaskInput) (*domain.Task, error) {
-	ret := _m.Called(c, payload)
+// Assign provides a mock function with given fields: c, actor, taskId, assigneeIDs, version
+func (_m *TaskUseCase) Assign(c context.Context, actor *domain.AuthenticatedUser, taskId string, assigneeIDs []string, version int64) (*domain.Task, error) {
+	ret := _m.Called(c, actor, taskId, assigneeIDs, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Assign")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, []string, int64) (*domain.Task, error)); ok {
+		return rf(c, actor, taskId, assigneeIDs, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, []string, int64) *domain.Task); ok {
+		r0 = rf(c, actor, taskId, assigneeIDs, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string, []string, int64) error); ok {
+		r1 = rf(c, actor, taskId, assigneeIDs, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Audit provides a mock function with given fields: c, actor, query
+func (_m *TaskUseCase) Audit(c context.Context, actor *domain.AuthenticatedUser, query *domain.HistoryQuery) (*domain.HistoryPage, error) {
+	ret := _m.Called(c, actor, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Audit")
+	}
+
+	var r0 *domain.HistoryPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.HistoryQuery) (*domain.HistoryPage, error)); ok {
+		return rf(c, actor, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.HistoryQuery) *domain.HistoryPage); ok {
+		r0 = rf(c, actor, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.HistoryPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, *domain.HistoryQuery) error); ok {
+		r1 = rf(c, actor, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: c, actor, payload
+func (_m *TaskUseCase) Create(c context.Context, actor *domain.AuthenticatedUser, payload *domain.TaskInput) (*domain.Task, error) {
+	ret := _m.Called(c, actor, payload)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
@@ -24,19 +84,19 @@ func (_m *TaskUseCase) Create(c context.Context, payload *domain.TaskInput) (*do
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.TaskInput) (*domain.Task, error)); ok {
-		return rf(c, payload)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskInput) (*domain.Task, error)); ok {
+		return rf(c, actor, payload)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.TaskInput) *domain.Task); ok {
-		r0 = rf(c, payload)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskInput) *domain.Task); ok {
+		r0 = rf(c, actor, payload)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *domain.TaskInput) error); ok {
-		r1 = rf(c, payload)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskInput) error); ok {
+		r1 = rf(c, actor, payload)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,17 +104,17 @@ func (_m *TaskUseCase) Create(c context.Context, payload *domain.TaskInput) (*do
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: c, taskId
-func (_m *TaskUseCase) Delete(c context.Context, taskId string) error {
-	ret := _m.Called(c, taskId)
+// Delete provides a mock function with given fields: c, actor, taskId, version
+func (_m *TaskUseCase) Delete(c context.Context, actor *domain.AuthenticatedUser, taskId string, version int64) error {
+	ret := _m.Called(c, actor, taskId, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(c, taskId)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, int64) error); ok {
+		r0 = rf(c, actor, taskId, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -62,29 +122,29 @@ func (_m *TaskUseCase) Delete(c context.Context, taskId string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: c
-func (_m *TaskUseCase) GetAll(c context.Context) (*[]*domain.Task, error) {
-	ret := _m.Called(c)
+// GetAll provides a mock function with given fields: c, actor, query
+func (_m *TaskUseCase) GetAll(c context.Context, actor *domain.AuthenticatedUser, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	ret := _m.Called(c, actor, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAll")
 	}
 
-	var r0 *[]*domain.Task
+	var r0 *domain.TaskPage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*[]*domain.Task, error)); ok {
-		return rf(c)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskQuery) (*domain.TaskPage, error)); ok {
+		return rf(c, actor, query)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *[]*domain.Task); ok {
-		r0 = rf(c)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskQuery) *domain.TaskPage); ok {
+		r0 = rf(c, actor, query)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*[]*domain.Task)
+			r0 = ret.Get(0).(*domain.TaskPage)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(c)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskQuery) error); ok {
+		r1 = rf(c, actor, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,9 +152,9 @@ func (_m *TaskUseCase) GetAll(c context.Context) (*[]*domain.Task, error) {
 	return r0, r1
 }
 
-// GetById provides a mock function with given fields: c, taskId
-func (_m *TaskUseCase) GetById(c context.Context, taskId string) (*domain.Task, error) {
-	ret := _m.Called(c, taskId)
+// GetById provides a mock function with given fields: c, actor, taskId
+func (_m *TaskUseCase) GetById(c context.Context, actor *domain.AuthenticatedUser, taskId string) (*domain.Task, error) {
+	ret := _m.Called(c, actor, taskId)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetById")
@@ -102,19 +162,199 @@ func (_m *TaskUseCase) GetById(c context.Context, taskId string) (*domain.Task,
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Task, error)); ok {
-		return rf(c, taskId)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string) (*domain.Task, error)); ok {
+		return rf(c, actor, taskId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string) *domain.Task); ok {
+		r0 = rf(c, actor, taskId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string) error); ok {
+		r1 = rf(c, actor, taskId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// History provides a mock function with given fields: c, actor, taskId, query
+func (_m *TaskUseCase) History(c context.Context, actor *domain.AuthenticatedUser, taskId string, query *domain.HistoryQuery) (*domain.HistoryPage, error) {
+	ret := _m.Called(c, actor, taskId, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for History")
+	}
+
+	var r0 *domain.HistoryPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.HistoryQuery) (*domain.HistoryPage, error)); ok {
+		return rf(c, actor, taskId, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.HistoryQuery) *domain.HistoryPage); ok {
+		r0 = rf(c, actor, taskId, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.HistoryPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string, *domain.HistoryQuery) error); ok {
+		r1 = rf(c, actor, taskId, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: c, actor, taskId, patch, version
+func (_m *TaskUseCase) Patch(c context.Context, actor *domain.AuthenticatedUser, taskId string, patch *domain.TaskPatch, version int64) (*domain.Task, error) {
+	ret := _m.Called(c, actor, taskId, patch, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Patch")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskPatch, int64) (*domain.Task, error)); ok {
+		return rf(c, actor, taskId, patch, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskPatch, int64) *domain.Task); ok {
+		r0 = rf(c, actor, taskId, patch, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskPatch, int64) error); ok {
+		r1 = rf(c, actor, taskId, patch, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Replace provides a mock function with given fields: c, actor, taskId, payload, version
+func (_m *TaskUseCase) Replace(c context.Context, actor *domain.AuthenticatedUser, taskId string, payload *domain.TaskInput, version int64) (*domain.Task, error) {
+	ret := _m.Called(c, actor, taskId, payload, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Replace")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskInput, int64) (*domain.Task, error)); ok {
+		return rf(c, actor, taskId, payload, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskInput, int64) *domain.Task); ok {
+		r0 = rf(c, actor, taskId, payload, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskInput, int64) error); ok {
+		r1 = rf(c, actor, taskId, payload, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: c, actor, taskId
+func (_m *TaskUseCase) Restore(c context.Context, actor *domain.AuthenticatedUser, taskId string) (*domain.Task, error) {
+	ret := _m.Called(c, actor, taskId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string) (*domain.Task, error)); ok {
+		return rf(c, actor, taskId)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Task); ok {
-		r0 = rf(c, taskId)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string) *domain.Task); ok {
+		r0 = rf(c, actor, taskId)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(c, taskId)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string) error); ok {
+		r1 = rf(c, actor, taskId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Transition provides a mock function with given fields: c, actor, taskId, status
+func (_m *TaskUseCase) Transition(c context.Context, actor *domain.AuthenticatedUser, taskId string, status string) (*domain.Task, error) {
+	ret := _m.Called(c, actor, taskId, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Transition")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, string) (*domain.Task, error)); ok {
+		return rf(c, actor, taskId, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, string) *domain.Task); ok {
+		r0 = rf(c, actor, taskId, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string, string) error); ok {
+		r1 = rf(c, actor, taskId, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Trash provides a mock function with given fields: c, actor, query
+func (_m *TaskUseCase) Trash(c context.Context, actor *domain.AuthenticatedUser, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	ret := _m.Called(c, actor, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Trash")
+	}
+
+	var r0 *domain.TaskPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskQuery) (*domain.TaskPage, error)); ok {
+		return rf(c, actor, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskQuery) *domain.TaskPage); ok {
+		r0 = rf(c, actor, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TaskPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, *domain.TaskQuery) error); ok {
+		r1 = rf(c, actor, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -122,9 +362,9 @@ func (_m *TaskUseCase) GetById(c context.Context, taskId string) (*domain.Task,
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: c, taskId, payload
-func (_m *TaskUseCase) Update(c context.Context, taskId string, payload *domain.TaskInput) (*domain.Task, error) {
-	ret := _m.Called(c, taskId, payload)
+// Update provides a mock function with given fields: c, actor, taskId, payload, version
+func (_m *TaskUseCase) Update(c context.Context, actor *domain.AuthenticatedUser, taskId string, payload *domain.TaskInput, version int64) (*domain.Task, error) {
+	ret := _m.Called(c, actor, taskId, payload, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Update")
@@ -132,19 +372,19 @@ func (_m *TaskUseCase) Update(c context.Context, taskId string, payload *domain.
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.TaskInput) (*domain.Task, error)); ok {
-		return rf(c, taskId, payload)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskInput, int64) (*domain.Task, error)); ok {
+		return rf(c, actor, taskId, payload, version)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.TaskInput) *domain.Task); ok {
-		r0 = rf(c, taskId, payload)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskInput, int64) *domain.Task); ok {
+		r0 = rf(c, actor, taskId, payload, version)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.TaskInput) error); ok {
-		r1 = rf(c, taskId, payload)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string, *domain.TaskInput, int64) error); ok {
+		r1 = rf(c, actor, taskId, payload, version)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 	domain "task-manager-api-clean/domain"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -44,6 +45,66 @@ func (_m *UserRepository) Create(c context.Context, user *domain.User) (*domain.
 	return r0, r1
 }
 
+// GetByEmail provides a mock function with given fields: c, email
+func (_m *UserRepository) GetByEmail(c context.Context, email string) (*domain.User, error) {
+	ret := _m.Called(c, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByEmail")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(c, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(c, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetById provides a mock function with given fields: c, userID
+func (_m *UserRepository) GetById(c context.Context, userID string) (*domain.User, error) {
+	ret := _m.Called(c, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetById")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(c, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(c, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByUsername provides a mock function with given fields: c, username
 func (_m *UserRepository) GetByUsername(c context.Context, username string) (*domain.User, error) {
 	ret := _m.Called(c, username)
@@ -74,9 +135,234 @@ func (_m *UserRepository) GetByUsername(c context.Context, username string) (*do
 	return r0, r1
 }
 
-// UpdateRole provides a mock function with given fields: c, userID, role
-func (_m *UserRepository) UpdateRole(c context.Context, userID string, role string) (*domain.User, error) {
-	ret := _m.Called(c, userID, role)
+// IncrementTokenVersion provides a mock function with given fields: c, userID
+func (_m *UserRepository) IncrementTokenVersion(c context.Context, userID string) error {
+	ret := _m.Called(c, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementTokenVersion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(c, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields: c, query
+func (_m *UserRepository) List(c context.Context, query *domain.UserQuery) ([]*domain.User, string, error) {
+	ret := _m.Called(c, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []*domain.User
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) ([]*domain.User, string, error)); ok {
+		return rf(c, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) []*domain.User); ok {
+		r0 = rf(c, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.UserQuery) string); ok {
+		r1 = rf(c, query)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *domain.UserQuery) error); ok {
+		r2 = rf(c, query)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// LockUntil provides a mock function with given fields: c, userID, until
+func (_m *UserRepository) LockUntil(c context.Context, userID string, until time.Time) error {
+	ret := _m.Called(c, userID, until)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockUntil")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(c, userID, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkEmailVerified provides a mock function with given fields: c, userID, email
+func (_m *UserRepository) MarkEmailVerified(c context.Context, userID string, email string) (bool, error) {
+	ret := _m.Called(c, userID, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkEmailVerified")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return rf(c, userID, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(c, userID, email)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(c, userID, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordFailedLogin provides a mock function with given fields: c, userID
+func (_m *UserRepository) RecordFailedLogin(c context.Context, userID string) (int, error) {
+	ret := _m.Called(c, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordFailedLogin")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(c, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(c, userID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(c, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetFailedLogins provides a mock function with given fields: c, userID
+func (_m *UserRepository) ResetFailedLogins(c context.Context, userID string) error {
+	ret := _m.Called(c, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetFailedLogins")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(c, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAccess provides a mock function with given fields: c, userID, change
+func (_m *UserRepository) UpdateAccess(c context.Context, userID string, change *domain.UserUpdate) (*domain.User, error) {
+	ret := _m.Called(c, userID, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAccess")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.UserUpdate) (*domain.User, error)); ok {
+		return rf(c, userID, change)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.UserUpdate) *domain.User); ok {
+		r0 = rf(c, userID, change)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.UserUpdate) error); ok {
+		r1 = rf(c, userID, change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePassword provides a mock function with given fields: c, userID, password
+func (_m *UserRepository) UpdatePassword(c context.Context, userID string, password string) error {
+	ret := _m.Called(c, userID, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(c, userID, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: c, userID, username, email
+func (_m *UserRepository) UpdateProfile(c context.Context, userID string, username string, email string) (*domain.User, error) {
+	ret := _m.Called(c, userID, username, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProfile")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.User, error)); ok {
+		return rf(c, userID, username, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.User); ok {
+		r0 = rf(c, userID, username, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(c, userID, username, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateRole provides a mock function with given fields: c, username, role
+func (_m *UserRepository) UpdateRole(c context.Context, username string, role string) (*domain.User, error) {
+	ret := _m.Called(c, username, role)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateRole")
@@ -85,10 +371,10 @@ func (_m *UserRepository) UpdateRole(c context.Context, userID string, role stri
 	var r0 *domain.User
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.User, error)); ok {
-		return rf(c, userID, role)
+		return rf(c, username, role)
 	}
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.User); ok {
-		r0 = rf(c, userID, role)
+		r0 = rf(c, username, role)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
@@ -96,7 +382,7 @@ func (_m *UserRepository) UpdateRole(c context.Context, userID string, role stri
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(c, userID, role)
+		r1 = rf(c, username, role)
 	} else {
 		r1 = ret.Error(1)
 	}
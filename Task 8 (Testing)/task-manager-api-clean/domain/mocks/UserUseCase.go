@@ -14,23 +14,103 @@ type UserUseCase struct {
 	mock.Mock
 }
 
+// BootstrapAdmin provides a mock function with given fields: c
+func (_m *UserUseCase) BootstrapAdmin(c context.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BootstrapAdmin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ChangePassword provides a mock function with given fields: c, user, payload
+func (_m *UserUseCase) ChangePassword(c context.Context, user *domain.AuthenticatedUser, payload *domain.PasswordChange) (*domain.TokenPair, error) {
+	ret := _m.Called(c, user, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 *domain.TokenPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.PasswordChange) (*domain.TokenPair, error)); ok {
+		return rf(c, user, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.PasswordChange) *domain.TokenPair); ok {
+		r0 = rf(c, user, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, *domain.PasswordChange) error); ok {
+		r1 = rf(c, user, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: c, query
+func (_m *UserUseCase) List(c context.Context, query *domain.UserQuery) (*domain.UserPage, error) {
+	ret := _m.Called(c, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 *domain.UserPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) (*domain.UserPage, error)); ok {
+		return rf(c, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) *domain.UserPage); ok {
+		r0 = rf(c, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.UserQuery) error); ok {
+		r1 = rf(c, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Login provides a mock function with given fields: c, payload
-func (_m *UserUseCase) Login(c context.Context, payload *domain.UserLogin) (string, error) {
+func (_m *UserUseCase) Login(c context.Context, payload *domain.UserLogin) (*domain.TokenPair, error) {
 	ret := _m.Called(c, payload)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 *domain.TokenPair
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserLogin) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserLogin) (*domain.TokenPair, error)); ok {
 		return rf(c, payload)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserLogin) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserLogin) *domain.TokenPair); ok {
 		r0 = rf(c, payload)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TokenPair)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, *domain.UserLogin) error); ok {
@@ -42,9 +122,57 @@ func (_m *UserUseCase) Login(c context.Context, payload *domain.UserLogin) (stri
 	return r0, r1
 }
 
-// Promote provides a mock function with given fields: c, username
-func (_m *UserUseCase) Promote(c context.Context, username string) (*domain.UserInfo, error) {
-	ret := _m.Called(c, username)
+// Logout provides a mock function with given fields: c, payload
+func (_m *UserUseCase) Logout(c context.Context, payload *domain.LogoutRequest) error {
+	ret := _m.Called(c, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.LogoutRequest) error); ok {
+		r0 = rf(c, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Profile provides a mock function with given fields: c, user
+func (_m *UserUseCase) Profile(c context.Context, user *domain.AuthenticatedUser) (*domain.UserInfo, error) {
+	ret := _m.Called(c, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Profile")
+	}
+
+	var r0 *domain.UserInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser) (*domain.UserInfo, error)); ok {
+		return rf(c, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser) *domain.UserInfo); ok {
+		r0 = rf(c, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser) error); ok {
+		r1 = rf(c, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Promote provides a mock function with given fields: c, username, role
+func (_m *UserUseCase) Promote(c context.Context, username string, role string) (*domain.UserInfo, error) {
+	ret := _m.Called(c, username, role)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Promote")
@@ -52,19 +180,49 @@ func (_m *UserUseCase) Promote(c context.Context, username string) (*domain.User
 
 	var r0 *domain.UserInfo
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.UserInfo, error)); ok {
-		return rf(c, username)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.UserInfo, error)); ok {
+		return rf(c, username, role)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.UserInfo); ok {
-		r0 = rf(c, username)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.UserInfo); ok {
+		r0 = rf(c, username, role)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.UserInfo)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(c, username)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(c, username, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: c, payload
+func (_m *UserUseCase) Refresh(c context.Context, payload *domain.RefreshRequest) (*domain.TokenPair, error) {
+	ret := _m.Called(c, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *domain.TokenPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.RefreshRequest) (*domain.TokenPair, error)); ok {
+		return rf(c, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.RefreshRequest) *domain.TokenPair); ok {
+		r0 = rf(c, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.RefreshRequest) error); ok {
+		r1 = rf(c, payload)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,6 +260,195 @@ func (_m *UserUseCase) RegisterUser(c context.Context, payload *domain.UserCreat
 	return r0, r1
 }
 
+// RequestPasswordReset provides a mock function with given fields: c, payload
+func (_m *UserUseCase) RequestPasswordReset(c context.Context, payload *domain.PasswordResetRequest) error {
+	ret := _m.Called(c, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestPasswordReset")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PasswordResetRequest) error); ok {
+		r0 = rf(c, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResendVerification provides a mock function with given fields: c, payload
+func (_m *UserUseCase) ResendVerification(c context.Context, payload *domain.VerificationResendRequest) error {
+	ret := _m.Called(c, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResendVerification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.VerificationResendRequest) error); ok {
+		r0 = rf(c, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: c, payload
+func (_m *UserUseCase) ResetPassword(c context.Context, payload *domain.PasswordReset) error {
+	ret := _m.Called(c, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PasswordReset) error); ok {
+		r0 = rf(c, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Setup provides a mock function with given fields: c, payload
+func (_m *UserUseCase) Setup(c context.Context, payload *domain.AdminSetup) (*domain.UserInfo, error) {
+	ret := _m.Called(c, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Setup")
+	}
+
+	var r0 *domain.UserInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AdminSetup) (*domain.UserInfo, error)); ok {
+		return rf(c, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AdminSetup) *domain.UserInfo); ok {
+		r0 = rf(c, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AdminSetup) error); ok {
+		r1 = rf(c, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unlock provides a mock function with given fields: c, username
+func (_m *UserUseCase) Unlock(c context.Context, username string) error {
+	ret := _m.Called(c, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unlock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(c, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: c, actor, userID, payload
+func (_m *UserUseCase) Update(c context.Context, actor *domain.AuthenticatedUser, userID string, payload *domain.UserUpdate) (*domain.UserInfo, error) {
+	ret := _m.Called(c, actor, userID, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 *domain.UserInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.UserUpdate) (*domain.UserInfo, error)); ok {
+		return rf(c, actor, userID, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, string, *domain.UserUpdate) *domain.UserInfo); ok {
+		r0 = rf(c, actor, userID, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, string, *domain.UserUpdate) error); ok {
+		r1 = rf(c, actor, userID, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProfile provides a mock function with given fields: c, user, payload
+func (_m *UserUseCase) UpdateProfile(c context.Context, user *domain.AuthenticatedUser, payload *domain.UserProfileUpdate) (*domain.UserInfo, *domain.TokenPair, error) {
+	ret := _m.Called(c, user, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProfile")
+	}
+
+	var r0 *domain.UserInfo
+	var r1 *domain.TokenPair
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.UserProfileUpdate) (*domain.UserInfo, *domain.TokenPair, error)); ok {
+		return rf(c, user, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.AuthenticatedUser, *domain.UserProfileUpdate) *domain.UserInfo); ok {
+		r0 = rf(c, user, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.AuthenticatedUser, *domain.UserProfileUpdate) *domain.TokenPair); ok {
+		r1 = rf(c, user, payload)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *domain.AuthenticatedUser, *domain.UserProfileUpdate) error); ok {
+		r2 = rf(c, user, payload)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// VerifyEmail provides a mock function with given fields: c, token
+func (_m *UserUseCase) VerifyEmail(c context.Context, token string) error {
+	ret := _m.Called(c, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(c, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserUseCase creates a new instance of UserUseCase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserUseCase(t interface {
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// ErrWrongPassword is returned when a password change gives the wrong current password.
+	ErrWrongPassword = NewError(ErrForbidden, "current password is incorrect")
+	// ErrInvalidResetToken is returned for unknown, expired or already used password reset tokens.
+	ErrInvalidResetToken = NewError(ErrValidation, "invalid or expired password reset token")
+)
+
+// PasswordResetToken is the server-side record of a password reset token
+// sent by email. Like refresh tokens, only a hash of the token is stored.
+type PasswordResetToken struct {
+	Id        string     `bson:"_id"`
+	UserID    string     `bson:"userId"`
+	TokenHash string     `bson:"tokenHash"`
+	CreatedAt time.Time  `bson:"createdAt"`
+	ExpiresAt time.Time  `bson:"expiresAt"`
+	UsedAt    *time.Time `bson:"usedAt,omitempty"`
+}
+
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordReset struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type PasswordResetRepository interface {
+	Create(c context.Context, token *PasswordResetToken) error
+	GetByHash(c context.Context, tokenHash string) (*PasswordResetToken, error)
+	// MarkUsed uses up a token and reports whether this call did so, which
+	// is false when the token had already been used.
+	MarkUsed(c context.Context, id string) (bool, error)
+}
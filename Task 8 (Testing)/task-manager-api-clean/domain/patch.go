@@ -0,0 +1,27 @@
+package domain
+
+// Media types of the patch documents PATCH /tasks/:id takes besides plain JSON.
+const (
+	// MediaTypeMergePatch is a JSON Merge Patch (RFC 7386): the fields given
+	// replace the task's, and a null clears one.
+	MediaTypeMergePatch = "application/merge-patch+json"
+	// MediaTypeJSONPatch is a JSON Patch (RFC 6902): a list of operations
+	// applied in order, all or none.
+	MediaTypeJSONPatch = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is wrapped by every error caused by a malformed patch document.
+	ErrInvalidPatch = NewError(ErrValidation, "invalid patch")
+	// ErrPatchTestFailed is returned when a test operation of a JSON Patch
+	// does not hold, leaving the task as it was.
+	ErrPatchTestFailed = NewError(ErrConflict, "patch test failed")
+	// ErrUnsupportedPatch is returned for a patch document in a format that is not supported.
+	ErrUnsupportedPatch = NewError(ErrUnsupportedMediaType, "patch must be "+MediaTypeMergePatch+", "+MediaTypeJSONPatch+" or application/json")
+)
+
+// TaskPatch is a patch document and the media type saying how to apply it.
+type TaskPatch struct {
+	MediaType string
+	Document  []byte
+}
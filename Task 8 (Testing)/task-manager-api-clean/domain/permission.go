@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+type Permission string
+
+const (
+	PermTaskCreate     Permission = "task:create"
+	PermTaskRead       Permission = "task:read"       // tasks the user created or is assigned to
+	PermTaskReadAny    Permission = "task:read:any"   // every task
+	PermTaskUpdate     Permission = "task:update"     // the status of tasks assigned to the user
+	PermTaskUpdateAny  Permission = "task:update:any" // any field of any task
+	PermTaskDelete     Permission = "task:delete"
+	PermTaskAssign     Permission = "task:assign"
+	PermUserPromote    Permission = "user:promote"
+	PermUserUnlock     Permission = "user:unlock"
+	PermUserRead       Permission = "user:read" // listing every user
+	PermUserDeactivate Permission = "user:deactivate"
+	PermAuditRead      Permission = "audit:read" // the history of every task
+)
+
+// AllPermissions lists every permission the API checks.
+var AllPermissions = []Permission{
+	PermTaskCreate,
+	PermTaskRead,
+	PermTaskReadAny,
+	PermTaskUpdate,
+	PermTaskUpdateAny,
+	PermTaskDelete,
+	PermTaskAssign,
+	PermUserPromote,
+	PermUserUnlock,
+	PermUserRead,
+	PermUserDeactivate,
+	PermAuditRead,
+}
+
+// DefaultRoles is the role mapping used when the configuration does not
+// override it. "admin" and "user" keep the rights the API always gave them.
+var DefaultRoles = map[string][]Permission{
+	"admin":   AllPermissions,
+	"manager": {PermTaskCreate, PermTaskReadAny, PermTaskUpdateAny, PermTaskAssign},
+	"user":    {PermTaskRead, PermTaskUpdate},
+	"viewer":  {PermTaskReadAny},
+}
+
+var ErrUnknownRole = NewError(ErrValidation, "unknown role")
+
+// Policy answers which role holds which permission.
+type Policy struct {
+	roles map[string][]Permission
+}
+
+// NewPolicy starts from DefaultRoles and applies the configured roles on top,
+// replacing the permissions of a role it names or adding it as a new role.
+// The permission "*" grants every permission.
+func NewPolicy(roles map[string][]string) (*Policy, error) {
+	policy := &Policy{roles: make(map[string][]Permission)}
+	for role, permissions := range DefaultRoles {
+		policy.roles[role] = permissions
+	}
+
+	for role, names := range roles {
+		permissions := []Permission{}
+		for _, name := range names {
+			if name == "*" {
+				permissions = AllPermissions
+				break
+			}
+			if !slices.Contains(AllPermissions, Permission(name)) {
+				return nil, fmt.Errorf("role %q: unknown permission %q", role, name)
+			}
+			permissions = append(permissions, Permission(name))
+		}
+		policy.roles[role] = permissions
+	}
+	return policy, nil
+}
+
+// Allows reports whether the role holds the permission.
+func (p *Policy) Allows(role string, permission Permission) bool {
+	return slices.Contains(p.roles[role], permission)
+}
+
+// HasRole reports whether the role is defined at all.
+func (p *Policy) HasRole(role string) bool {
+	_, ok := p.roles[role]
+	return ok
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ErrTooManyRequests is returned once a client has used up its rate limit.
+var ErrTooManyRequests = NewError(ErrRateLimited, "too many requests, try again later")
+
+// RateLimiter keeps a token bucket per key, such as a client IP. Each request
+// takes a token and the bucket refills at a steady rate up to its size.
+type RateLimiter interface {
+	// Allow takes a token from the bucket of key. When the bucket is empty
+	// it returns false and how long until the next token.
+	Allow(c context.Context, key string) (bool, time.Duration, error)
+}
@@ -0,0 +1,12 @@
+package domain
+
+// ErrInvalidSetupToken is returned by Setup for a wrong setup token, or once
+// the token has been used or an admin exists.
+var ErrInvalidSetupToken = NewError(ErrForbidden, "invalid or used setup token")
+
+// AdminSetup creates the first admin, proving with the one-time setup token
+// printed at startup that it comes from whoever runs the API.
+type AdminSetup struct {
+	UserCreate
+	SetupToken string `json:"setup_token"`
+}
@@ -12,6 +12,13 @@ type Task struct {
 	Description string `json:"description" bson:"description"`
 	Status      string   `json:"status" bson:"status"`
 	DueDate     time.Time `json:"dueDate" bson:"dueDate"`
+	CreatedBy   string    `json:"createdBy" bson:"createdBy"`
+	AssigneeIDs []string  `json:"assigneeIds" bson:"assigneeIds"`
+	StatusChangedBy string    `json:"statusChangedBy" bson:"statusChangedBy"`
+	StatusChangedAt time.Time `json:"statusChangedAt" bson:"statusChangedAt"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	// Version goes up by one with every change the repository makes to the task.
+	Version int64 `json:"version" bson:"version"`
 }
 
 type TaskInput struct {
@@ -19,20 +26,120 @@ type TaskInput struct {
 	Description string    `json:"description"`
 	Status      string     `json:"status"`
 	DueDate     time.Time `json:"dueDate"`
+	AssigneeIDs []string  `json:"assigneeIds"`
 }
 
+type TaskAssignment struct {
+	AssigneeIDs []string `json:"assigneeIds"`
+}
+
+type TaskTransition struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// Fields of a task a change can write, named as in JSON.
+const (
+	TaskFieldTitle       = "title"
+	TaskFieldDescription = "description"
+	TaskFieldStatus      = "status"
+	TaskFieldDueDate     = "dueDate"
+)
+
+// Fields a task list can be sorted by.
+const (
+	TaskSortById    = "id"
+	TaskSortTitle   = "title"
+	TaskSortStatus  = "status"
+	TaskSortDueDate = "dueDate"
+)
+
+var (
+	// ErrInvalidTaskQuery is wrapped by every error caused by a malformed TaskQuery.
+	ErrInvalidTaskQuery = NewError(ErrValidation, "invalid task query")
+	// ErrTaskNotFound is returned when a task does not exist or is not visible to the caller.
+	ErrTaskNotFound = NewError(ErrNotFound, "task not found")
+	// ErrTaskForbidden is returned when the caller may see a task but not make the requested change.
+	ErrTaskForbidden = NewError(ErrForbidden, "you are not allowed to make this change to the task")
+	// ErrVersionMismatch is returned when a task changed since the version the caller based its change on.
+	ErrVersionMismatch = NewError(ErrConflict, "task has been modified since it was read")
+	// ErrTaskUnchanged is returned by an update that would leave the task as it is.
+	ErrTaskUnchanged = NewError(ErrValidation, "task not updated, no new information is provided")
+)
+
+// TaskQuery narrows, orders and pages the tasks returned by GetAll.
+// Zero values mean "no filter".
+type TaskQuery struct {
+	Status    string
+	DueBefore time.Time
+	DueAfter  time.Time
+	Title     string // case-insensitive substring match
+	SortBy    string
+	SortDesc  bool
+	Limit     int
+	Cursor    string // opaque, taken from a previous TaskPage.NextCursor
+
+	AssigneeId string // only tasks assigned to this user
+	VisibleTo  string // only tasks created by or assigned to this user
+	Trashed    bool   // tasks in the trash instead of live ones
+}
+
+// TaskPage is one page of tasks. Total counts every task matching the
+// query filters, not just the ones on this page.
+type TaskPage struct {
+	Items      []*Task `json:"items"`
+	NextCursor string  `json:"next_cursor"`
+	Total      int64   `json:"total"`
+}
+
+// TaskRepository stores tasks. Deleting a task only moves it to the trash:
+// trashed tasks are left out of everything but GetAll with query.Trashed,
+// Restore and Purge.
+//
+// Update and Delete only apply to the given version of the task, failing
+// with ErrVersionMismatch otherwise; version 0 applies to any version.
 type TaskRepository interface {
 	Create(c context.Context, task *Task) (*Task, error)
-	Update(c context.Context, id string, task *Task) (*Task, error)
-	Delete(c context.Context, id string) error
-	GetAll(c context.Context) (*[]*Task, error)
+	// Update writes the given fields of task, one of the TaskField names, in
+	// one go. Zero values are written too, clearing the field; fields left
+	// out stay as they are. A status is written along with StatusChangedBy
+	// and StatusChangedAt. An update changing nothing fails with ErrTaskUnchanged.
+	Update(c context.Context, id string, task *Task, fields []string) (*Task, error)
+	Delete(c context.Context, id string, version int64) error
+	GetAll(c context.Context, query *TaskQuery) (*TaskPage, error)
 	GetById(c context.Context, taskId string) (*Task, error)
+	// UpdateAssignees replaces the assignees of the task if it is still at the
+	// given version, 0 when any version will do.
+	UpdateAssignees(c context.Context, id string, assigneeIDs []string, version int64) (*Task, error)
+	// GetTrashed returns a task in the trash.
+	GetTrashed(c context.Context, id string) (*Task, error)
+	// Restore takes a task back out of the trash.
+	Restore(c context.Context, id string) (*Task, error)
+	// Purge permanently removes the tasks trashed before the given time and
+	// returns the ones this call removed, also when it fails partway.
+	Purge(c context.Context, trashedBefore time.Time) ([]*Task, error)
 }
 
 type TaskUseCase interface {
-	Create(c context.Context, payload *TaskInput) (*Task, error)
-	Update(c context.Context, taskId string, payload *TaskInput) (*Task, error)
-	Delete(c context.Context, taskId string) error
-	GetAll(c context.Context) (*[]*Task, error)
-	GetById(c context.Context, taskId string) (*Task, error)
+	Create(c context.Context, actor *AuthenticatedUser, payload *TaskInput) (*Task, error)
+	// Update, Replace, Patch, Delete and Assign take the version the caller last saw, 0 when it does not care.
+	// Update leaves out the fields left empty in the payload.
+	Update(c context.Context, actor *AuthenticatedUser, taskId string, payload *TaskInput, version int64) (*Task, error)
+	// Replace sets every field of the payload, clearing the description and due date when left empty.
+	Replace(c context.Context, actor *AuthenticatedUser, taskId string, payload *TaskInput, version int64) (*Task, error)
+	// Patch applies a JSON Merge Patch or a JSON Patch to the task as GetById returns it.
+	Patch(c context.Context, actor *AuthenticatedUser, taskId string, patch *TaskPatch, version int64) (*Task, error)
+	// Delete moves the task to the trash.
+	Delete(c context.Context, actor *AuthenticatedUser, taskId string, version int64) error
+	Trash(c context.Context, actor *AuthenticatedUser, query *TaskQuery) (*TaskPage, error)
+	Restore(c context.Context, actor *AuthenticatedUser, taskId string) (*Task, error)
+	GetAll(c context.Context, actor *AuthenticatedUser, query *TaskQuery) (*TaskPage, error)
+	GetById(c context.Context, actor *AuthenticatedUser, taskId string) (*Task, error)
+	// Assign replaces the assignees of the task, each of which must be a known user.
+	Assign(c context.Context, actor *AuthenticatedUser, taskId string, assigneeIDs []string, version int64) (*Task, error)
+	// Transition moves the task to a new status along the workflow, recording who moved it and when.
+	Transition(c context.Context, actor *AuthenticatedUser, taskId string, status string) (*Task, error)
+	// History pages through the changes made to a task the actor can see, newest first.
+	History(c context.Context, actor *AuthenticatedUser, taskId string, query *HistoryQuery) (*HistoryPage, error)
+	// Audit pages through the changes made to every task, newest first.
+	Audit(c context.Context, actor *AuthenticatedUser, query *HistoryQuery) (*HistoryPage, error)
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ErrInvalidRefreshToken is returned for unknown, expired, revoked or reused refresh tokens.
+var ErrInvalidRefreshToken = NewError(ErrUnauthenticated, "invalid or expired refresh token")
+
+// RefreshToken is the server-side record of an issued refresh token. Only a
+// hash of the token is stored. Every token obtained by rotating another one
+// shares its FamilyId, so a replayed token can revoke the whole chain.
+type RefreshToken struct {
+	Id        string     `bson:"_id"`
+	UserID    string     `bson:"userId"`
+	FamilyId  string     `bson:"familyId"`
+	TokenHash string     `bson:"tokenHash"`
+	CreatedAt time.Time  `bson:"createdAt"`
+	ExpiresAt time.Time  `bson:"expiresAt"`
+	RevokedAt *time.Time `bson:"revokedAt,omitempty"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	AllSessions  bool   `json:"all_sessions"`
+}
+
+type RefreshTokenRepository interface {
+	Create(c context.Context, token *RefreshToken) error
+	GetByHash(c context.Context, tokenHash string) (*RefreshToken, error)
+	// Revoke marks a token as revoked and reports whether this call did so,
+	// which is false when the token had already been revoked.
+	Revoke(c context.Context, id string) (bool, error)
+	RevokeFamily(c context.Context, familyId string) error
+	RevokeAllForUser(c context.Context, userID string) error
+}
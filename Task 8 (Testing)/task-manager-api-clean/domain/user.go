@@ -2,26 +2,62 @@ package domain
 
 import (
 	"context"
+	"time"
+)
+
+var (
+	ErrUserNotFound       = NewError(ErrNotFound, "user not found")
+	ErrUserExists         = NewError(ErrConflict, "user already exists")
+	ErrUserAlreadyAdmin   = NewError(ErrConflict, "user is already an admin")
+	ErrInvalidCredentials = NewError(ErrUnauthenticated, "invalid username or password")
+	// ErrLastAdmin keeps the last active admin from being demoted or deactivated.
+	ErrLastAdmin          = NewError(ErrConflict, "the last active admin cannot be demoted or deactivated")
+	ErrUserDeactivated    = NewError(ErrForbidden, "account has been deactivated")
+	ErrUserForbidden      = NewError(ErrForbidden, "you are not allowed to make this change to the user")
+	ErrUserUnchanged      = NewError(ErrValidation, "user not updated, no new information is provided")
 )
 
 type User struct {
 	UserID    string    `json:"user_id" bson:"_id"`
 	Username  string    `json:"username" bson:"username"`
 	Email     string    `json:"email" bson:"email"`
+	// EmailVerified is set once the user has followed the link sent to Email.
+	EmailVerified bool  `json:"email_verified" bson:"emailVerified"`
 	Password  string    `json:"password" bson:"password"`
 	Role      string    `json:"role" bson:"role"`
+	TokenVersion int    `json:"-" bson:"tokenVersion"`
+	// FailedLogins counts the failed logins since the last successful one.
+	FailedLogins int        `json:"-" bson:"failedLogins"`
+	LockedUntil  *time.Time `json:"-" bson:"lockedUntil,omitempty"`
+	// Deactivated users can neither log in nor use the tokens they hold.
+	Deactivated bool `json:"-" bson:"deactivated"`
+}
+
+// Info is the public view of the user.
+func (u *User) Info() *UserInfo {
+	return &UserInfo{
+		UserId:        u.UserID,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		Deactivated:   u.Deactivated,
+	}
 }
 
 type UserInfo struct {
 	UserId string `json:"user_id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	EmailVerified bool `json:"email_verified"`
+	Role     string `json:"role"`
+	Deactivated bool `json:"deactivated"`
 }
 
 type UserCreate struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Email    string `json:"email" binding:"required"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
 }
 
 type UserLogin struct {
@@ -30,24 +66,128 @@ type UserLogin struct {
 }
 
 
+type UserPromotion struct {
+	Role string `json:"role"`
+}
+
+// UserProfileUpdate changes the caller's own account. Fields left out keep
+// their value.
+type UserProfileUpdate struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+}
+
+// UserUpdate is an admin's change to another account. Fields left out keep
+// their value.
+type UserUpdate struct {
+	Role   *string `json:"role"`
+	Active *bool   `json:"active"`
+}
+
+// UserQuery narrows and pages the users returned by List, which are always
+// in the order they registered. Zero values mean "no filter".
+type UserQuery struct {
+	Role   string
+	Limit  int
+	Cursor string // the NextCursor of the previous page
+}
+
+type UserPage struct {
+	Items      []*UserInfo `json:"items"`
+	NextCursor string      `json:"next_cursor"`
+}
+
 type AuthenticatedUser struct {
 	UserID   string
 	Username string
 	Role     string
+	TokenVersion int
 }
 
 
 type UserRepository interface {
 	Create(c context.Context, user *User) (*User, error)
 	GetByUsername(c context.Context,username string) (*User, error)
-	UpdateRole(c context.Context, userID string, role string) (*User, error)
-
+	GetById(c context.Context, userID string) (*User, error)
+	GetByEmail(c context.Context, email string) (*User, error)
+	// List returns a page of users and the cursor of the next page, which
+	// is empty on the last one.
+	List(c context.Context, query *UserQuery) ([]*User, string, error)
+	// UpdateRole bumps the user's token version when the role changes,
+	// revoking their access tokens, and leaves a user who already has the
+	// role alone. It fails with ErrUserAlreadyAdmin when making an admin an
+	// admin again and with ErrLastAdmin when demoting the last active admin.
+	UpdateRole(c context.Context, username string, role string) (*User, error)
+	// UpdateProfile sets the username and email address of the user. A new
+	// address is no longer verified, and a new username bumps the token
+	// version, as access tokens carry the username.
+	UpdateProfile(c context.Context, userID string, username string, email string) (*User, error)
+	// UpdateAccess changes the role of the user and whether they are active
+	// in one step, keeping what change leaves out. A new role or a
+	// deactivation bumps the token version. It fails with ErrLastAdmin,
+	// changing nothing, when it would leave no active admin.
+	UpdateAccess(c context.Context, userID string, change *UserUpdate) (*User, error)
+	IncrementTokenVersion(c context.Context, userID string) error
+	// RecordFailedLogin counts one more failed login and returns how many
+	// there have been since the last successful one.
+	RecordFailedLogin(c context.Context, userID string) (int, error)
+	LockUntil(c context.Context, userID string, until time.Time) error
+	// ResetFailedLogins forgets the failed logins and lifts any lock.
+	ResetFailedLogins(c context.Context, userID string) error
+	// UpdatePassword hashes and stores a new password and bumps the token
+	// version, revoking the user's access tokens.
+	UpdatePassword(c context.Context, userID string, password string) error
+	// MarkEmailVerified records that the user controls the given address.
+	// It reports false, changing nothing, when the user's address is no
+	// longer the one that was verified.
+	MarkEmailVerified(c context.Context, userID string, email string) (bool, error)
 }
 
 type UserUseCase interface {
+	// RegisterUser creates an ordinary user; admins come from Setup or
+	// promotion.
 	RegisterUser(c context.Context, payload *UserCreate) (*UserInfo, error)
-	Login(c context.Context, payload *UserLogin) (string, error)
-	Promote(c context.Context, username string) (*UserInfo, error)
+	// BootstrapAdmin runs at startup. When there is no active admin, it logs
+	// a one-time setup token for Setup.
+	BootstrapAdmin(c context.Context) error
+	// Setup creates an admin given the setup token logged by BootstrapAdmin,
+	// which it then retires.
+	Setup(c context.Context, payload *AdminSetup) (*UserInfo, error)
+	Login(c context.Context, payload *UserLogin) (*TokenPair, error)
+	Refresh(c context.Context, payload *RefreshRequest) (*TokenPair, error)
+	Logout(c context.Context, payload *LogoutRequest) error
+	// Promote gives the user a role, which must be defined by the access policy.
+	Promote(c context.Context, username string, role string) (*UserInfo, error)
+	List(c context.Context, query *UserQuery) (*UserPage, error)
+	// Profile is the caller's own account.
+	Profile(c context.Context, user *AuthenticatedUser) (*UserInfo, error)
+	// UpdateProfile changes the caller's username or email address. A new
+	// address has to be verified again. A new username revokes the caller's
+	// access tokens, so a new token pair is returned with it; it is nil
+	// otherwise.
+	UpdateProfile(c context.Context, user *AuthenticatedUser, payload *UserProfileUpdate) (*UserInfo, *TokenPair, error)
+	// Update changes the role of another user, which takes user:promote, or
+	// deactivates or reactivates them, which takes user:deactivate.
+	Update(c context.Context, actor *AuthenticatedUser, userID string, payload *UserUpdate) (*UserInfo, error)
+	// Unlock lifts a lock put on the account by failed logins.
+	Unlock(c context.Context, username string) error
+	// ChangePassword replaces the password of the user, who must give the
+	// current one, ends every session and starts a new one.
+	ChangePassword(c context.Context, user *AuthenticatedUser, payload *PasswordChange) (*TokenPair, error)
+	// RequestPasswordReset emails a reset token to the user with the given
+	// address. An unknown address is not an error, so the endpoint cannot be
+	// used to find out which addresses have an account.
+	RequestPasswordReset(c context.Context, payload *PasswordResetRequest) error
+	// ResetPassword sets a new password with a token from
+	// RequestPasswordReset and ends every session of the user.
+	ResetPassword(c context.Context, payload *PasswordReset) error
+	// VerifyEmail marks the address of the user a verification token was
+	// sent to as verified.
+	VerifyEmail(c context.Context, token string) error
+	// ResendVerification sends a new verification link to the address if it
+	// belongs to an unverified account. Like RequestPasswordReset, an
+	// unknown address is not an error.
+	ResendVerification(c context.Context, payload *VerificationResendRequest) error
 }
 
 
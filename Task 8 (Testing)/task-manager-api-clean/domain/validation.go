@@ -0,0 +1,54 @@
+package domain
+
+import "strings"
+
+// Codes identifying why a field was rejected.
+const (
+	CodeRequired      = "required"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidType   = "invalid_type"
+	CodeInPast        = "in_past"
+	CodeUnknownField  = "unknown_field"
+	CodeReadOnly      = "read_only"
+	CodeUnknownValue  = "unknown_value"
+)
+
+// FieldError says what is wrong with one input field, named as in the JSON body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field of an input that was rejected. It is an
+// ErrValidation, answered with 422 and the list of fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return "invalid input: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// Add records a rejected field.
+func (e *ValidationError) Add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns the validation error, or nil when no field was rejected.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,16 @@
+package domain
+
+var (
+	// ErrEmailTaken is returned when another user already has the email address.
+	ErrEmailTaken = NewError(ErrConflict, "email address is already in use")
+	// ErrEmailNotVerified is returned by Login for unverified accounts when
+	// REQUIRE_EMAIL_VERIFICATION is on.
+	ErrEmailNotVerified = NewError(ErrForbidden, "email address has not been verified")
+	// ErrInvalidVerificationToken is returned for badly signed, expired or
+	// outdated email verification tokens.
+	ErrInvalidVerificationToken = NewError(ErrValidation, "invalid or expired email verification token")
+)
+
+type VerificationResendRequest struct {
+	Email string `json:"email"`
+}
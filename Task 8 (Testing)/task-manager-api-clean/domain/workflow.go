@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Statuses of the default task workflow.
+const (
+	StatusPending    = "Pending"
+	StatusInProgress = "In Progress"
+	StatusBlocked    = "Blocked"
+	StatusCompleted  = "Completed"
+	StatusCancelled  = "Cancelled"
+)
+
+// DefaultStatuses lists the statuses of the default workflow, the first one
+// being the status new tasks start in.
+var DefaultStatuses = []string{StatusPending, StatusInProgress, StatusBlocked, StatusCompleted, StatusCancelled}
+
+// DefaultTransitions says which statuses a task may move to from each status
+// of the default workflow. Completed and Cancelled are final.
+var DefaultTransitions = map[string][]string{
+	StatusPending:    {StatusInProgress, StatusBlocked, StatusCancelled},
+	StatusInProgress: {StatusPending, StatusBlocked, StatusCompleted, StatusCancelled},
+	StatusBlocked:    {StatusPending, StatusInProgress, StatusCancelled},
+}
+
+// TransitionError is returned when a task is moved along a transition the
+// workflow does not allow.
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("cannot move a task from %q to %q, %q is a final status", e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("cannot move a task from %q to %q, expected one of: %s", e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+// Unwrap makes a disallowed move a conflict with the task's current status.
+func (e *TransitionError) Unwrap() error {
+	return ErrConflict
+}
+
+// Workflow is the state machine task statuses follow.
+type Workflow struct {
+	statuses    []string
+	transitions map[string][]string
+}
+
+// NewWorkflow builds a workflow from the configured statuses and transitions,
+// falling back to the default workflow when no statuses are given.
+func NewWorkflow(statuses []string, transitions map[string][]string) (*Workflow, error) {
+	if len(statuses) == 0 {
+		statuses, transitions = DefaultStatuses, DefaultTransitions
+	}
+
+	for from, targets := range transitions {
+		if !slices.Contains(statuses, from) {
+			return nil, fmt.Errorf("workflow: transition from unknown status %q", from)
+		}
+		for _, to := range targets {
+			if !slices.Contains(statuses, to) {
+				return nil, fmt.Errorf("workflow: transition from %q to unknown status %q", from, to)
+			}
+		}
+	}
+
+	return &Workflow{statuses: statuses, transitions: transitions}, nil
+}
+
+// Initial is the status new tasks start in.
+func (w *Workflow) Initial() string {
+	return w.statuses[0]
+}
+
+// CheckStatus returns a *ValidationError on the status field when the status
+// is not part of the workflow.
+func (w *Workflow) CheckStatus(status string) error {
+	if !slices.Contains(w.statuses, status) {
+		invalid := &ValidationError{}
+		invalid.Add("status", CodeUnknownValue, fmt.Sprintf("unknown status %q, expected one of: %s", status, strings.Join(w.statuses, ", ")))
+		return invalid
+	}
+	return nil
+}
+
+// CheckTransition returns the error of CheckStatus for an unknown status, and
+// a *TransitionError unless a task may move from one
+// status to the other. Tasks whose status predates the workflow may move to
+// any known status.
+func (w *Workflow) CheckTransition(from, to string) error {
+	if err := w.CheckStatus(to); err != nil {
+		return err
+	}
+	if !slices.Contains(w.statuses, from) {
+		return nil
+	}
+	if allowed := w.transitions[from]; !slices.Contains(allowed, to) {
+		return &TransitionError{From: from, To: to, Allowed: allowed}
+	}
+	return nil
+}
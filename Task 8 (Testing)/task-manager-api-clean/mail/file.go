@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"task-manager-api-clean/domain"
+)
+
+// FileMailer appends every message to a file instead of sending it, for
+// development and tests. Messages are separated by a line of dashes.
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func NewFileMailer(path string, from string) domain.Mailer {
+	return &FileMailer{
+		path: path,
+		from: from,
+	}
+}
+
+func (fm *FileMailer) Send(c context.Context, message *domain.Message) error {
+	data, err := format(fm.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	file, err := os.OpenFile(fm.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	if _, err := file.Write(append(data, "----------\r\n"...)); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,22 @@
+package mail
+
+import (
+	"context"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+)
+
+// LogMailer logs every message, body included, instead of sending it. It is
+// meant for development only, as the log then holds whatever the messages
+// carry, such as password reset tokens.
+type LogMailer struct{}
+
+func NewLogMailer() domain.Mailer {
+	return &LogMailer{}
+}
+
+func (lm *LogMailer) Send(c context.Context, message *domain.Message) error {
+	utils.Logger(c).Info("mail not sent, logged instead", "to", message.To, "subject", message.Subject, "body", message.Body)
+	return nil
+}
@@ -0,0 +1,112 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/mail"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MailTestSuite struct {
+	suite.Suite
+}
+
+func (suite *MailTestSuite) TestFileMailer_AppendsMessages() {
+	path := filepath.Join(suite.T().TempDir(), "mail.txt")
+	mailer := mail.NewFileMailer(path, "no-reply@example.com")
+
+	suite.NoError(mailer.Send(context.Background(), &domain.Message{To: "alice@example.com", Subject: "Hello", Body: "first\nmessage"}))
+	suite.NoError(mailer.Send(context.Background(), &domain.Message{To: "bob@example.com", Subject: "Hello", Body: "second"}))
+
+	data, err := os.ReadFile(path)
+	suite.NoError(err)
+	suite.Contains(string(data), "From: no-reply@example.com\r\nTo: alice@example.com\r\nSubject: Hello\r\n")
+	suite.Contains(string(data), "\r\n\r\nfirst\r\nmessage\r\n")
+	suite.Contains(string(data), "To: bob@example.com\r\n")
+	suite.Equal(2, strings.Count(string(data), "----------\r\n"))
+}
+
+func (suite *MailTestSuite) TestSend_RejectsHeaderInjection() {
+	mailer := mail.NewFileMailer(filepath.Join(suite.T().TempDir(), "mail.txt"), "no-reply@example.com")
+
+	err := mailer.Send(context.Background(), &domain.Message{To: "alice@example.com\r\nBcc: eve@example.com", Subject: "Hello", Body: "hi"})
+	suite.Error(err)
+}
+
+func (suite *MailTestSuite) TestSMTPMailer_Sends() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go serveSMTP(listener, received)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	mailer := mail.NewSMTPMailer(host, port, "", "", "no-reply@example.com")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = mailer.Send(ctx, &domain.Message{To: "alice@example.com", Subject: "Reset your password", Body: "token"})
+	suite.Require().NoError(err)
+
+	commands := <-received
+	suite.Contains(commands, "MAIL FROM:<no-reply@example.com>")
+	suite.Contains(commands, "RCPT TO:<alice@example.com>")
+	suite.Contains(commands, "Subject: Reset your password")
+	suite.Contains(commands, "token")
+}
+
+// serveSMTP answers a single SMTP session without extensions and reports
+// every line the client sent.
+func serveSMTP(listener net.Listener, received chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var lines []string
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		switch {
+		case inData && line == ".":
+			inData = false
+			reply("250 queued")
+		case inData:
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+			reply("250 localhost")
+		case line == "DATA":
+			inData = true
+			reply("354 go ahead")
+		case line == "QUIT":
+			reply("221 bye")
+			received <- lines
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+	received <- lines
+}
+
+func TestMailTestSuite(t *testing.T) {
+	suite.Run(t, new(MailTestSuite))
+}
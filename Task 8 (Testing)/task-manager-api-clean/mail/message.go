@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"task-manager-api-clean/domain"
+)
+
+// errHeaderInjection is returned for a recipient or subject spanning lines,
+// which would let it add headers of its own to the message.
+var errHeaderInjection = errors.New("mail header contains a line break")
+
+// format writes the message in the Internet Message Format, with the body
+// as UTF-8 plain text.
+func format(from string, message *domain.Message, now time.Time) ([]byte, error) {
+	for _, header := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
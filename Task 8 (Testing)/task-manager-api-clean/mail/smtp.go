@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+
+	"task-manager-api-clean/domain"
+)
+
+// SMTPMailer sends messages through an SMTP server, upgrading the connection
+// with STARTTLS whenever the server offers it.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer sends messages from the address from through the server at
+// host:port, logging in when username is set.
+func NewSMTPMailer(host string, port string, username string, password string, from string) domain.Mailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (sm *SMTPMailer) Send(c context.Context, message *domain.Message) error {
+	data, err := format(sm.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(c, "tcp", sm.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+	// net/smtp knows nothing of contexts, the deadline bounds the whole exchange
+	if deadline, ok := c.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, sm.host)
+	if err != nil {
+		return fmt.Errorf("failed to greet SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: sm.host}); err != nil {
+			return fmt.Errorf("failed to start TLS with SMTP server: %w", err)
+		}
+	}
+	if sm.username != "" {
+		// PlainAuth refuses to send the password unencrypted to anything but localhost
+		if err := client.Auth(smtp.PlainAuth("", sm.username, sm.password, sm.host)); err != nil {
+			return fmt.Errorf("failed to log in to SMTP server: %w", err)
+		}
+	}
+
+	if err := client.Mail(sm.from); err != nil {
+		return fmt.Errorf("SMTP server refused sender: %w", err)
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return fmt.Errorf("SMTP server refused recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server refused message: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server refused message: %w", err)
+	}
+	return client.Quit()
+}
@@ -1,17 +1,119 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"task-manager-api-clean/api/middleware"
 	"task-manager-api-clean/api/router"
+	"task-manager-api-clean/api/server"
 	"github.com/gin-gonic/gin"
 	"task-manager-api-clean/config"
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	
 )
 
 func main() {
-	r := gin.Default()
-	env, _ := config.Load()
-	db , _ := config.GetClient(env.DatabaseURL, env.DatabaseName)
-	router.Setup(env, db, r)
-	r.Run("localhost:" + env.Port)
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path of a YAML config file, overridden by environment variables")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
+	if *printConfig {
+		env, err := config.LoadFile(*configFile)
+		if err == nil {
+			err = env.Print(os.Stdout)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "migrate" {
+		if err := migrate(*configFile, flag.Arg(1), os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(*configFile); err != nil {
+		slog.Error("task manager stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the API and blocks until SIGINT or SIGTERM, then drains the
+// server and closes the database connection.
+func run(configFile string) error {
+	env, err := config.LoadFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	// Everything, the standard log package included, logs through slog
+	logger := env.Logger(os.Stdout)
+	slog.SetDefault(logger)
+
+	keys, err := utils.LoadKeyRing(env.JwtSecret, env.JwtSigningKey, env.JwtVerificationKeys)
+	if err != nil {
+		return err
+	}
+
+	policy, err := domain.NewPolicy(env.Roles)
+	if err != nil {
+		return err
+	}
+
+	workflow, err := domain.NewWorkflow(env.TaskStatuses, env.TaskTransitions)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The in-memory driver needs no database connection at all
+	var db *mongo.Database
+	if env.StorageDriver == config.StorageMongo {
+		db, err = config.GetClient(env.DatabaseURL, env.DatabaseName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			disconnectCtx, cancel := context.WithTimeout(context.Background(), env.ShutdownTimeout)
+			defer cancel()
+			if err := config.Disconnect(disconnectCtx); err != nil {
+				slog.Error("failed to disconnect from MongoDB", "error", err)
+			}
+		}()
+
+		if err := migrateOnStartup(ctx, env, db); err != nil {
+			return err
+		}
+	}
+
+	r := gin.New()
+	// Handlers hand the gin context to use cases, which need the values of
+	// the request context, the request logger above all
+	r.ContextWithFallback = true
+	// Only trusted proxies may say who the client is, or anyone could dodge
+	// the rate limits with a made up X-Forwarded-For
+	if err := r.SetTrustedProxies(env.TrustedProxies); err != nil {
+		return err
+	}
+	r.Use(middleware.RequestLogger(logger), gin.Recovery())
+	if err := router.Setup(ctx, env, keys, policy, workflow, db, r); err != nil {
+		return err
+	}
+	return server.Run(ctx, server.New(env, r), server.Lifecycle{ShutdownDelay: env.ShutdownDelay, ShutdownTimeout: env.ShutdownTimeout})
 }
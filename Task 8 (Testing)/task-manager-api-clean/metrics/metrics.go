@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"task-manager-api-clean/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Outcomes an operation is labelled with. Expected failures get their own
+// label so alerts can fire on real errors only.
+const (
+	OutcomeOK       = "ok"
+	OutcomeRejected = "rejected"
+	OutcomeError    = "error"
+)
+
+// Metrics holds the collectors of the API, registered on their own registry
+// so tests can create as many as they like.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	HTTPRequests        *prometheus.CounterVec
+	HTTPRequestDuration *prometheus.HistogramVec
+	Logins              *prometheus.CounterVec
+	Registrations       *prometheus.CounterVec
+	RepositoryDuration  *prometheus.HistogramVec
+}
+
+// New creates and registers the collectors, along with the Go runtime and
+// process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_logins_total",
+			Help: "Login attempts, by outcome: ok, rejected for bad credentials or error.",
+		}, []string{"outcome"}),
+		Registrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_registrations_total",
+			Help: "User registrations, by outcome: ok, rejected for invalid input or a taken username, or error.",
+		}, []string{"outcome"}),
+		RepositoryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Time taken by storage operations, by repository, operation and outcome.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "operation", "outcome"}),
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPRequestDuration,
+		m.Logins,
+		m.Registrations,
+		m.RepositoryDuration,
+	)
+	return m
+}
+
+// Handler serves the collected metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// outcome labels the result of an operation. Errors of a domain kind are the
+// caller's doing, anything else is a failure of the service.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrConflict),
+		errors.Is(err, domain.ErrValidation), errors.Is(err, domain.ErrForbidden),
+		errors.Is(err, domain.ErrUnauthenticated):
+		return OutcomeRejected
+	default:
+		return OutcomeError
+	}
+}
@@ -0,0 +1,116 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/domain/mocks"
+	"task-manager-api-clean/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsTestSuite struct {
+	suite.Suite
+	metrics *metrics.Metrics
+}
+
+func (suite *MetricsTestSuite) SetupTest() {
+	suite.metrics = metrics.New()
+}
+
+func (suite *MetricsTestSuite) TestTaskRepository_TimesOperationsByOutcome() {
+	next := new(mocks.TaskRepository)
+	next.On("GetById", mock.Anything, "1").Return(&domain.Task{Id: "1"}, nil)
+	next.On("GetById", mock.Anything, "2").Return(nil, domain.ErrTaskNotFound)
+	next.On("Delete", mock.Anything, "1", int64(0)).Return(errors.New("connection reset"))
+	repo := metrics.NewTaskRepository(next, suite.metrics)
+
+	task, err := repo.GetById(context.Background(), "1")
+	suite.NoError(err)
+	suite.Equal("1", task.Id)
+	_, err = repo.GetById(context.Background(), "2")
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+	suite.EqualError(repo.Delete(context.Background(), "1", 0), "connection reset")
+
+	suite.Equal(3, testutil.CollectAndCount(suite.metrics.RepositoryDuration))
+	suite.Equal(uint64(1), suite.sampleCount("task", "GetById", metrics.OutcomeOK))
+	suite.Equal(uint64(1), suite.sampleCount("task", "GetById", metrics.OutcomeRejected))
+	suite.Equal(uint64(1), suite.sampleCount("task", "Delete", metrics.OutcomeError))
+}
+
+func (suite *MetricsTestSuite) TestUserRepository_TimesOperations() {
+	next := new(mocks.UserRepository)
+	next.On("GetByUsername", mock.Anything, "alice").Return(&domain.User{Username: "alice"}, nil)
+	repo := metrics.NewUserRepository(next, suite.metrics)
+
+	user, err := repo.GetByUsername(context.Background(), "alice")
+	suite.NoError(err)
+	suite.Equal("alice", user.Username)
+	suite.Equal(uint64(1), suite.sampleCount("user", "GetByUsername", metrics.OutcomeOK))
+}
+
+func (suite *MetricsTestSuite) TestUserUseCase_CountsLoginsAndRegistrations() {
+	next := new(mocks.UserUseCase)
+	next.On("Login", mock.Anything, &domain.UserLogin{Username: "alice", Password: "right"}).Return(&domain.TokenPair{}, nil)
+	next.On("Login", mock.Anything, &domain.UserLogin{Username: "alice", Password: "wrong"}).Return(nil, domain.ErrInvalidCredentials)
+	next.On("RegisterUser", mock.Anything, mock.Anything).Return(nil, domain.ErrUserExists)
+	next.On("Logout", mock.Anything, mock.Anything).Return(nil)
+	useCase := metrics.NewUserUseCase(next, suite.metrics)
+
+	useCase.Login(context.Background(), &domain.UserLogin{Username: "alice", Password: "right"})
+	useCase.Login(context.Background(), &domain.UserLogin{Username: "alice", Password: "wrong"})
+	useCase.Login(context.Background(), &domain.UserLogin{Username: "alice", Password: "wrong"})
+	useCase.RegisterUser(context.Background(), &domain.UserCreate{Username: "alice"})
+	suite.NoError(useCase.Logout(context.Background(), &domain.LogoutRequest{}))
+
+	suite.Equal(1.0, testutil.ToFloat64(suite.metrics.Logins.WithLabelValues(metrics.OutcomeOK)))
+	suite.Equal(2.0, testutil.ToFloat64(suite.metrics.Logins.WithLabelValues(metrics.OutcomeRejected)))
+	suite.Equal(1.0, testutil.ToFloat64(suite.metrics.Registrations.WithLabelValues(metrics.OutcomeRejected)))
+	next.AssertCalled(suite.T(), "Logout", mock.Anything, mock.Anything)
+}
+
+func (suite *MetricsTestSuite) TestHandler_ServesTextFormat() {
+	suite.metrics.Logins.WithLabelValues(metrics.OutcomeOK).Inc()
+
+	resp := httptest.NewRecorder()
+	suite.metrics.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	suite.Equal(http.StatusOK, resp.Code)
+	suite.True(strings.HasPrefix(resp.Header().Get("Content-Type"), "text/plain"))
+	suite.Contains(resp.Body.String(), `auth_logins_total{outcome="ok"} 1`)
+	suite.Contains(resp.Body.String(), "go_goroutines")
+}
+
+// sampleCount is how many operations the repository histogram recorded for
+// the given labels.
+func (suite *MetricsTestSuite) sampleCount(repository, operation, outcome string) uint64 {
+	families, err := suite.metrics.Registry.Gather()
+	suite.Require().NoError(err)
+	for _, family := range families {
+		if family.GetName() != "repository_operation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["repository"] == repository && labels["operation"] == operation && labels["outcome"] == outcome {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestMetricsTestSuite(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"task-manager-api-clean/domain"
+)
+
+// observe records how long an operation of a repository took since start.
+func (m *Metrics) observe(repository, operation string, start time.Time, err error) {
+	m.RepositoryDuration.WithLabelValues(repository, operation, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+type taskRepository struct {
+	next    domain.TaskRepository
+	metrics *Metrics
+}
+
+// NewTaskRepository times every operation of next.
+func NewTaskRepository(next domain.TaskRepository, m *Metrics) domain.TaskRepository {
+	return &taskRepository{next: next, metrics: m}
+}
+
+func (r *taskRepository) Create(c context.Context, task *domain.Task) (*domain.Task, error) {
+	start := time.Now()
+	created, err := r.next.Create(c, task)
+	r.metrics.observe("task", "Create", start, err)
+	return created, err
+}
+
+func (r *taskRepository) Update(c context.Context, id string, task *domain.Task, fields []string) (*domain.Task, error) {
+	start := time.Now()
+	updated, err := r.next.Update(c, id, task, fields)
+	r.metrics.observe("task", "Update", start, err)
+	return updated, err
+}
+
+func (r *taskRepository) Delete(c context.Context, id string, version int64) error {
+	start := time.Now()
+	err := r.next.Delete(c, id, version)
+	r.metrics.observe("task", "Delete", start, err)
+	return err
+}
+
+func (r *taskRepository) GetAll(c context.Context, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	start := time.Now()
+	page, err := r.next.GetAll(c, query)
+	r.metrics.observe("task", "GetAll", start, err)
+	return page, err
+}
+
+func (r *taskRepository) GetById(c context.Context, taskId string) (*domain.Task, error) {
+	start := time.Now()
+	task, err := r.next.GetById(c, taskId)
+	r.metrics.observe("task", "GetById", start, err)
+	return task, err
+}
+
+func (r *taskRepository) UpdateAssignees(c context.Context, id string, assigneeIDs []string, version int64) (*domain.Task, error) {
+	start := time.Now()
+	task, err := r.next.UpdateAssignees(c, id, assigneeIDs, version)
+	r.metrics.observe("task", "UpdateAssignees", start, err)
+	return task, err
+}
+
+func (r *taskRepository) GetTrashed(c context.Context, id string) (*domain.Task, error) {
+	start := time.Now()
+	task, err := r.next.GetTrashed(c, id)
+	r.metrics.observe("task", "GetTrashed", start, err)
+	return task, err
+}
+
+func (r *taskRepository) Restore(c context.Context, id string) (*domain.Task, error) {
+	start := time.Now()
+	task, err := r.next.Restore(c, id)
+	r.metrics.observe("task", "Restore", start, err)
+	return task, err
+}
+
+func (r *taskRepository) Purge(c context.Context, trashedBefore time.Time) ([]*domain.Task, error) {
+	start := time.Now()
+	purged, err := r.next.Purge(c, trashedBefore)
+	r.metrics.observe("task", "Purge", start, err)
+	return purged, err
+}
+
+type userRepository struct {
+	next    domain.UserRepository
+	metrics *Metrics
+}
+
+// NewUserRepository times every operation of next.
+func NewUserRepository(next domain.UserRepository, m *Metrics) domain.UserRepository {
+	return &userRepository{next: next, metrics: m}
+}
+
+func (r *userRepository) Create(c context.Context, user *domain.User) (*domain.User, error) {
+	start := time.Now()
+	created, err := r.next.Create(c, user)
+	r.metrics.observe("user", "Create", start, err)
+	return created, err
+}
+
+func (r *userRepository) GetByUsername(c context.Context, username string) (*domain.User, error) {
+	start := time.Now()
+	user, err := r.next.GetByUsername(c, username)
+	r.metrics.observe("user", "GetByUsername", start, err)
+	return user, err
+}
+
+func (r *userRepository) GetById(c context.Context, userID string) (*domain.User, error) {
+	start := time.Now()
+	user, err := r.next.GetById(c, userID)
+	r.metrics.observe("user", "GetById", start, err)
+	return user, err
+}
+
+func (r *userRepository) GetByEmail(c context.Context, email string) (*domain.User, error) {
+	start := time.Now()
+	user, err := r.next.GetByEmail(c, email)
+	r.metrics.observe("user", "GetByEmail", start, err)
+	return user, err
+}
+
+func (r *userRepository) UpdateRole(c context.Context, username string, role string) (*domain.User, error) {
+	start := time.Now()
+	user, err := r.next.UpdateRole(c, username, role)
+	r.metrics.observe("user", "UpdateRole", start, err)
+	return user, err
+}
+
+func (r *userRepository) IncrementTokenVersion(c context.Context, userID string) error {
+	start := time.Now()
+	err := r.next.IncrementTokenVersion(c, userID)
+	r.metrics.observe("user", "IncrementTokenVersion", start, err)
+	return err
+}
+
+func (r *userRepository) RecordFailedLogin(c context.Context, userID string) (int, error) {
+	start := time.Now()
+	failures, err := r.next.RecordFailedLogin(c, userID)
+	r.metrics.observe("user", "RecordFailedLogin", start, err)
+	return failures, err
+}
+
+func (r *userRepository) LockUntil(c context.Context, userID string, until time.Time) error {
+	start := time.Now()
+	err := r.next.LockUntil(c, userID, until)
+	r.metrics.observe("user", "LockUntil", start, err)
+	return err
+}
+
+func (r *userRepository) ResetFailedLogins(c context.Context, userID string) error {
+	start := time.Now()
+	err := r.next.ResetFailedLogins(c, userID)
+	r.metrics.observe("user", "ResetFailedLogins", start, err)
+	return err
+}
+
+func (r *userRepository) UpdatePassword(c context.Context, userID string, password string) error {
+	start := time.Now()
+	err := r.next.UpdatePassword(c, userID, password)
+	r.metrics.observe("user", "UpdatePassword", start, err)
+	return err
+}
+
+func (r *userRepository) List(c context.Context, query *domain.UserQuery) ([]*domain.User, string, error) {
+	start := time.Now()
+	users, next, err := r.next.List(c, query)
+	r.metrics.observe("user", "List", start, err)
+	return users, next, err
+}
+
+func (r *userRepository) UpdateProfile(c context.Context, userID string, username string, email string) (*domain.User, error) {
+	start := time.Now()
+	user, err := r.next.UpdateProfile(c, userID, username, email)
+	r.metrics.observe("user", "UpdateProfile", start, err)
+	return user, err
+}
+
+func (r *userRepository) UpdateAccess(c context.Context, userID string, change *domain.UserUpdate) (*domain.User, error) {
+	start := time.Now()
+	user, err := r.next.UpdateAccess(c, userID, change)
+	r.metrics.observe("user", "UpdateAccess", start, err)
+	return user, err
+}
+
+func (r *userRepository) MarkEmailVerified(c context.Context, userID string, email string) (bool, error) {
+	start := time.Now()
+	marked, err := r.next.MarkEmailVerified(c, userID, email)
+	r.metrics.observe("user", "MarkEmailVerified", start, err)
+	return marked, err
+}
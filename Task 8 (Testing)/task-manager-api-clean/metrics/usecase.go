@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"context"
+
+	"task-manager-api-clean/domain"
+)
+
+// userUseCase counts logins and registrations, passing every other call
+// straight through.
+type userUseCase struct {
+	domain.UserUseCase
+	metrics *Metrics
+}
+
+// NewUserUseCase counts the logins and registrations handled by next.
+func NewUserUseCase(next domain.UserUseCase, m *Metrics) domain.UserUseCase {
+	return &userUseCase{UserUseCase: next, metrics: m}
+}
+
+func (u *userUseCase) RegisterUser(c context.Context, payload *domain.UserCreate) (*domain.UserInfo, error) {
+	info, err := u.UserUseCase.RegisterUser(c, payload)
+	u.metrics.Registrations.WithLabelValues(outcome(err)).Inc()
+	return info, err
+}
+
+func (u *userUseCase) Login(c context.Context, payload *domain.UserLogin) (*domain.TokenPair, error) {
+	tokens, err := u.UserUseCase.Login(c, payload)
+	u.metrics.Logins.WithLabelValues(outcome(err)).Inc()
+	return tokens, err
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"task-manager-api-clean/config"
+	"task-manager-api-clean/repository/migrations"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrate runs `migrate up|down|status` against the configured database: up
+// applies the pending migrations, down undoes the latest one and status
+// lists them all. It writes the outcome to out and logs to stderr.
+func migrate(configFile string, command string, out io.Writer) error {
+	if command != "up" && command != "down" && command != "status" {
+		return fmt.Errorf("usage: %s [-config file] migrate up|down|status", os.Args[0])
+	}
+
+	env, err := config.LoadFile(configFile)
+	if err != nil {
+		return err
+	}
+	if env.StorageDriver != config.StorageMongo {
+		return fmt.Errorf("migrations only apply to STORAGE_DRIVER=%s", config.StorageMongo)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := config.GetClient(env.DatabaseURL, env.DatabaseName)
+	if err != nil {
+		return err
+	}
+	defer config.Disconnect(context.Background())
+
+	migrator := migrations.NewMigrator(db, migrations.All)
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %d: %s\n", migration.Version, migration.Description)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "the schema is up to date")
+		}
+	case "down":
+		undone, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if undone == nil {
+			fmt.Fprintln(out, "no migration to undo")
+			return nil
+		}
+		fmt.Fprintf(out, "undid %d: %s\n", undone.Version, undone.Description)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tDESCRIPTION\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Description, applied)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// migrateOnStartup brings the schema up to date before the API serves
+// anything. With MIGRATE_ON_STARTUP off migrations are left to `migrate up`,
+// and the API refuses to start on a schema that is behind.
+func migrateOnStartup(ctx context.Context, env *config.Environment, db *mongo.Database) error {
+	migrator := migrations.NewMigrator(db, migrations.All)
+	if env.MigrateOnStartup {
+		_, err := migrator.Up(ctx)
+		return err
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d schema migrations are pending, run `migrate up` first", len(pending))
+	}
+	return nil
+}
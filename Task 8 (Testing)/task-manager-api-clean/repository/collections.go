@@ -0,0 +1,11 @@
+package repository
+
+// The collections the API keeps its data in, shared by the router wiring the
+// repositories and the schema migrations.
+const (
+	UsersCollection          = "users"
+	TasksCollection          = "tasks"
+	RefreshTokensCollection  = "refresh_tokens"
+	HistoryCollection        = "task_history"
+	PasswordResetsCollection = "password_resets"
+)
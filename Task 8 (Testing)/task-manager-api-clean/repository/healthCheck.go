@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"task-manager-api-clean/domain"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// NewHealthCheck returns a check that pings the primary of the database's
+// cluster, since every write goes there.
+func NewHealthCheck(db *mongo.Database) domain.HealthCheck {
+	return domain.HealthCheckFunc("mongo", func(c context.Context) error {
+		return db.Client().Ping(c, readpref.Primary())
+	})
+}
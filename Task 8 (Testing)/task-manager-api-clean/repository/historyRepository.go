@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"task-manager-api-clean/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type HistoryRepository struct {
+	database   *mongo.Database
+	collection string
+}
+
+func NewHistoryRepository(db *mongo.Database, collection string) domain.HistoryRepository {
+	return &HistoryRepository{
+		database:   db,
+		collection: collection,
+	}
+}
+
+func (hr *HistoryRepository) Record(c context.Context, entry *domain.TaskHistoryEntry) error {
+	entry.Id = primitive.NewObjectID().Hex()
+	_, err := hr.database.Collection(hr.collection).InsertOne(c, entry)
+	return err
+}
+
+func (hr *HistoryRepository) List(c context.Context, query *domain.HistoryQuery) (*domain.HistoryPage, error) {
+	filter := bson.M{}
+	if query.TaskId != "" {
+		filter["taskId"] = query.TaskId
+	}
+	if query.ActorId != "" {
+		filter["actorId"] = query.ActorId
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	// entry ids are object ids, so they sort in the order entries were recorded
+	if query.Cursor != "" {
+		filter["_id"] = bson.M{"$lt": query.Cursor}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit) + 1)
+	}
+
+	cursor, err := hr.database.Collection(hr.collection).Find(c, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	entries := []*domain.TaskHistoryEntry{}
+	if err := cursor.All(c, &entries); err != nil {
+		return nil, err
+	}
+
+	page := &domain.HistoryPage{Items: entries}
+	if query.Limit > 0 && len(entries) > query.Limit {
+		page.Items = entries[:query.Limit]
+		page.NextCursor = page.Items[query.Limit-1].Id
+	}
+	return page, nil
+}
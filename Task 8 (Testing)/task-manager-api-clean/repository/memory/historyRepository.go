@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"task-manager-api-clean/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HistoryRepository keeps task history entries in process memory, oldest first.
+type HistoryRepository struct {
+	mu      sync.RWMutex
+	entries []*domain.TaskHistoryEntry
+}
+
+func NewHistoryRepository() domain.HistoryRepository {
+	return &HistoryRepository{}
+}
+
+func (hr *HistoryRepository) Record(c context.Context, entry *domain.TaskHistoryEntry) error {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	entry.Id = primitive.NewObjectID().Hex()
+	stored := *entry
+	stored.Changes = append([]domain.FieldChange{}, entry.Changes...)
+	hr.entries = append(hr.entries, &stored)
+	return nil
+}
+
+func (hr *HistoryRepository) List(c context.Context, query *domain.HistoryQuery) (*domain.HistoryPage, error) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	page := &domain.HistoryPage{Items: []*domain.TaskHistoryEntry{}}
+	for i := len(hr.entries) - 1; i >= 0; i-- {
+		entry := hr.entries[i]
+		if (query.TaskId != "" && entry.TaskId != query.TaskId) ||
+			(query.ActorId != "" && entry.ActorId != query.ActorId) ||
+			(query.Action != "" && entry.Action != query.Action) ||
+			(query.Cursor != "" && entry.Id >= query.Cursor) {
+			continue
+		}
+
+		if query.Limit > 0 && len(page.Items) == query.Limit {
+			page.NextCursor = page.Items[query.Limit-1].Id
+			break
+		}
+		copied := *entry
+		page.Items = append(page.Items, &copied)
+	}
+	return page, nil
+}
@@ -0,0 +1,68 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/repository/memory"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HistoryRepositoryTestSuite struct {
+	suite.Suite
+	repo domain.HistoryRepository
+}
+
+func (suite *HistoryRepositoryTestSuite) SetupTest() {
+	suite.repo = memory.NewHistoryRepository()
+}
+
+func (suite *HistoryRepositoryTestSuite) record(taskId, actorId, action string) {
+	suite.NoError(suite.repo.Record(context.Background(), &domain.TaskHistoryEntry{TaskId: taskId, ActorId: actorId, Action: action}))
+}
+
+func (suite *HistoryRepositoryTestSuite) TestList_NewestFirstWithFilters() {
+	suite.record("t1", "u1", domain.HistoryCreate)
+	suite.record("t2", "u1", domain.HistoryCreate)
+	suite.record("t1", "u2", domain.HistoryUpdate)
+
+	page, err := suite.repo.List(context.Background(), &domain.HistoryQuery{TaskId: "t1"})
+	suite.NoError(err)
+	suite.Len(page.Items, 2)
+	suite.Equal(domain.HistoryUpdate, page.Items[0].Action)
+	suite.Equal(domain.HistoryCreate, page.Items[1].Action)
+
+	page, err = suite.repo.List(context.Background(), &domain.HistoryQuery{ActorId: "u1", Action: domain.HistoryCreate})
+	suite.NoError(err)
+	suite.Len(page.Items, 2)
+	suite.Empty(page.NextCursor)
+}
+
+func (suite *HistoryRepositoryTestSuite) TestList_Paginates() {
+	for i := 0; i < 5; i++ {
+		suite.record("t1", "u1", domain.HistoryUpdate)
+	}
+
+	var ids []string
+	cursor := ""
+	for pages := 0; pages < 3; pages++ {
+		page, err := suite.repo.List(context.Background(), &domain.HistoryQuery{Limit: 2, Cursor: cursor})
+		suite.NoError(err)
+		for _, entry := range page.Items {
+			ids = append(ids, entry.Id)
+		}
+		cursor = page.NextCursor
+	}
+
+	suite.Len(ids, 5)
+	suite.Empty(cursor)
+	for i := 1; i < len(ids); i++ {
+		suite.Greater(ids[i-1], ids[i])
+	}
+}
+
+func TestHistoryRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(HistoryRepositoryTestSuite))
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task-manager-api-clean/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordResetRepository keeps password reset tokens in process memory, indexed by hash.
+type PasswordResetRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*domain.PasswordResetToken
+}
+
+func NewPasswordResetRepository() domain.PasswordResetRepository {
+	return &PasswordResetRepository{
+		tokens: make(map[string]*domain.PasswordResetToken),
+	}
+}
+
+func (pr *PasswordResetRepository) Create(c context.Context, token *domain.PasswordResetToken) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	token.Id = primitive.NewObjectID().Hex()
+	stored := *token
+	pr.tokens[token.TokenHash] = &stored
+	return nil
+}
+
+func (pr *PasswordResetRepository) GetByHash(c context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	stored, ok := pr.tokens[tokenHash]
+	if !ok {
+		return nil, domain.ErrInvalidResetToken
+	}
+	token := *stored
+	return &token, nil
+}
+
+func (pr *PasswordResetRepository) MarkUsed(c context.Context, id string) (bool, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	for _, token := range pr.tokens {
+		if token.Id == id {
+			if token.UsedAt != nil {
+				return false, nil
+			}
+			now := time.Now()
+			token.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
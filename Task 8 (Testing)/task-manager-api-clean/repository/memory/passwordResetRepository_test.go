@@ -0,0 +1,55 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/repository/memory"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PasswordResetRepositoryTestSuite struct {
+	suite.Suite
+	repo domain.PasswordResetRepository
+}
+
+func (suite *PasswordResetRepositoryTestSuite) SetupTest() {
+	suite.repo = memory.NewPasswordResetRepository()
+}
+
+func (suite *PasswordResetRepositoryTestSuite) TestGetByHash() {
+	token := &domain.PasswordResetToken{UserID: "u1", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	suite.NoError(suite.repo.Create(context.Background(), token))
+	suite.NotEmpty(token.Id)
+
+	fetched, err := suite.repo.GetByHash(context.Background(), "hash")
+	suite.NoError(err)
+	suite.Equal(token.Id, fetched.Id)
+	suite.Equal("u1", fetched.UserID)
+
+	_, err = suite.repo.GetByHash(context.Background(), "unknown")
+	suite.ErrorIs(err, domain.ErrInvalidResetToken)
+}
+
+func (suite *PasswordResetRepositoryTestSuite) TestMarkUsed_OnlyOnce() {
+	token := &domain.PasswordResetToken{UserID: "u1", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	suite.NoError(suite.repo.Create(context.Background(), token))
+
+	used, err := suite.repo.MarkUsed(context.Background(), token.Id)
+	suite.NoError(err)
+	suite.True(used)
+
+	used, err = suite.repo.MarkUsed(context.Background(), token.Id)
+	suite.NoError(err)
+	suite.False(used)
+
+	fetched, _ := suite.repo.GetByHash(context.Background(), "hash")
+	suite.NotNil(fetched.UsedAt)
+}
+
+func TestPasswordResetRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(PasswordResetRepositoryTestSuite))
+}
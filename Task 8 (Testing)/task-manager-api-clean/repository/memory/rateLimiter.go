@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task-manager-api-clean/domain"
+)
+
+// RateLimiter keeps token buckets in process memory, so every instance of
+// the API limits on its own. Buckets that have filled up again are dropped
+// from time to time, as they are no different from a new one.
+type RateLimiter struct {
+	mu        sync.Mutex
+	size      float64
+	interval  time.Duration // time to refill one token
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time // when tokens was last brought up to date
+}
+
+// NewRateLimiter lets through requests per key every period, in bursts of
+// up to requests.
+func NewRateLimiter(requests int, period time.Duration) domain.RateLimiter {
+	return &RateLimiter{
+		size:      float64(requests),
+		interval:  period / time.Duration(requests),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (rl *RateLimiter) Allow(c context.Context, key string) (bool, time.Duration, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.sweep(now)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.size, at: now}
+		rl.buckets[key] = b
+	}
+	rl.refill(b, now)
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) * float64(rl.interval))
+		return false, wait, nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// refill adds the tokens earned since the bucket was last brought up to date.
+func (rl *RateLimiter) refill(b *bucket, now time.Time) {
+	if elapsed := now.Sub(b.at); elapsed > 0 {
+		b.tokens = min(rl.size, b.tokens+float64(elapsed)/float64(rl.interval))
+		b.at = now
+	}
+}
+
+// sweep drops the full buckets, at most once per time it takes to refill one.
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < time.Duration(rl.size)*rl.interval {
+		return
+	}
+	rl.lastSweep = now
+	for key, b := range rl.buckets {
+		rl.refill(b, now)
+		if b.tokens >= rl.size {
+			delete(rl.buckets, key)
+		}
+	}
+}
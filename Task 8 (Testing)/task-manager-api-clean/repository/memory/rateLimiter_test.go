@@ -0,0 +1,53 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/repository/memory"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimiterTestSuite struct {
+	suite.Suite
+}
+
+func (suite *RateLimiterTestSuite) TestAllow_LimitsEachKeyToItsBurst() {
+	limiter := memory.NewRateLimiter(2, 200*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := limiter.Allow(context.Background(), "10.0.0.1")
+		suite.NoError(err)
+		suite.True(allowed)
+	}
+
+	allowed, retryAfter, err := limiter.Allow(context.Background(), "10.0.0.1")
+	suite.NoError(err)
+	suite.False(allowed)
+	suite.Greater(retryAfter, time.Duration(0))
+	suite.LessOrEqual(retryAfter, 100*time.Millisecond)
+
+	// Other keys have buckets of their own
+	allowed, _, err = limiter.Allow(context.Background(), "10.0.0.2")
+	suite.NoError(err)
+	suite.True(allowed)
+}
+
+func (suite *RateLimiterTestSuite) TestAllow_RefillsOverTime() {
+	limiter := memory.NewRateLimiter(1, 50*time.Millisecond)
+
+	allowed, _, _ := limiter.Allow(context.Background(), "alice")
+	suite.True(allowed)
+	allowed, retryAfter, _ := limiter.Allow(context.Background(), "alice")
+	suite.False(allowed)
+
+	time.Sleep(retryAfter)
+	allowed, _, _ = limiter.Allow(context.Background(), "alice")
+	suite.True(allowed)
+}
+
+func TestRateLimiterTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimiterTestSuite))
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task-manager-api-clean/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshTokenRepository keeps refresh tokens in process memory, indexed by hash.
+type RefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*domain.RefreshToken
+}
+
+func NewRefreshTokenRepository() domain.RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		tokens: make(map[string]*domain.RefreshToken),
+	}
+}
+
+func (rr *RefreshTokenRepository) Create(c context.Context, token *domain.RefreshToken) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	token.Id = primitive.NewObjectID().Hex()
+	stored := *token
+	rr.tokens[token.TokenHash] = &stored
+	return nil
+}
+
+func (rr *RefreshTokenRepository) GetByHash(c context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	stored, ok := rr.tokens[tokenHash]
+	if !ok {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	token := *stored
+	return &token, nil
+}
+
+func (rr *RefreshTokenRepository) Revoke(c context.Context, id string) (bool, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	for _, token := range rr.tokens {
+		if token.Id == id {
+			if token.RevokedAt != nil {
+				return false, nil
+			}
+			now := time.Now()
+			token.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (rr *RefreshTokenRepository) RevokeFamily(c context.Context, familyId string) error {
+	rr.revokeWhere(func(token *domain.RefreshToken) bool { return token.FamilyId == familyId })
+	return nil
+}
+
+func (rr *RefreshTokenRepository) RevokeAllForUser(c context.Context, userID string) error {
+	rr.revokeWhere(func(token *domain.RefreshToken) bool { return token.UserID == userID })
+	return nil
+}
+
+func (rr *RefreshTokenRepository) revokeWhere(match func(token *domain.RefreshToken) bool) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range rr.tokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+		}
+	}
+}
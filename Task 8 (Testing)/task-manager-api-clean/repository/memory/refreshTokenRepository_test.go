@@ -0,0 +1,73 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/repository/memory"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RefreshTokenRepositoryTestSuite struct {
+	suite.Suite
+	repo domain.RefreshTokenRepository
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) SetupTest() {
+	suite.repo = memory.NewRefreshTokenRepository()
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) create(hash, userID, familyId string) *domain.RefreshToken {
+	token := &domain.RefreshToken{UserID: userID, FamilyId: familyId, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
+	suite.NoError(suite.repo.Create(context.Background(), token))
+	return token
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) TestGetByHash() {
+	token := suite.create("hash", "u1", "f1")
+
+	fetched, err := suite.repo.GetByHash(context.Background(), "hash")
+	suite.NoError(err)
+	suite.Equal(token.Id, fetched.Id)
+
+	_, err = suite.repo.GetByHash(context.Background(), "unknown")
+	suite.ErrorIs(err, domain.ErrInvalidRefreshToken)
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) TestRevoke_OnlyOnce() {
+	token := suite.create("hash", "u1", "f1")
+
+	revoked, err := suite.repo.Revoke(context.Background(), token.Id)
+	suite.NoError(err)
+	suite.True(revoked)
+
+	revoked, err = suite.repo.Revoke(context.Background(), token.Id)
+	suite.NoError(err)
+	suite.False(revoked)
+}
+
+func (suite *RefreshTokenRepositoryTestSuite) TestRevokeFamilyAndUser() {
+	suite.create("a", "u1", "f1")
+	suite.create("b", "u1", "f1")
+	suite.create("c", "u1", "f2")
+	suite.create("d", "u2", "f3")
+
+	suite.NoError(suite.repo.RevokeFamily(context.Background(), "f1"))
+	for hash, revoked := range map[string]bool{"a": true, "b": true, "c": false, "d": false} {
+		token, _ := suite.repo.GetByHash(context.Background(), hash)
+		suite.Equal(revoked, token.RevokedAt != nil, hash)
+	}
+
+	suite.NoError(suite.repo.RevokeAllForUser(context.Background(), "u1"))
+	token, _ := suite.repo.GetByHash(context.Background(), "c")
+	suite.NotNil(token.RevokedAt)
+	token, _ = suite.repo.GetByHash(context.Background(), "d")
+	suite.Nil(token.RevokedAt)
+}
+
+func TestRefreshTokenRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RefreshTokenRepositoryTestSuite))
+}
@@ -0,0 +1,275 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskRepository keeps tasks in process memory. It mirrors the behaviour and
+// errors of repository.TaskRepository so the rest of the API cannot tell the
+// two apart.
+type TaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[string]*domain.Task
+	order []string
+}
+
+func NewTaskRepository() domain.TaskRepository {
+	return &TaskRepository{
+		tasks: make(map[string]*domain.Task),
+	}
+}
+
+func (repo *TaskRepository) Create(c context.Context, task *domain.Task) (*domain.Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	task.Id = primitive.NewObjectID().Hex()
+	task.Version = 1
+	repo.tasks[task.Id] = cloneTask(task)
+	repo.order = append(repo.order, task.Id)
+	return task, nil
+}
+
+func (repo *TaskRepository) Update(c context.Context, id string, updateTask *domain.Task, fields []string) (*domain.Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.tasks[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, domain.ErrTaskNotFound
+	}
+	if updateTask.Version != 0 && updateTask.Version != stored.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	updated := cloneTask(stored)
+	for _, field := range fields {
+		switch field {
+		case domain.TaskFieldTitle:
+			updated.Title = updateTask.Title
+		case domain.TaskFieldDescription:
+			updated.Description = updateTask.Description
+		case domain.TaskFieldDueDate:
+			updated.DueDate = updateTask.DueDate
+		case domain.TaskFieldStatus:
+			updated.Status = updateTask.Status
+			updated.StatusChangedBy = updateTask.StatusChangedBy
+			updated.StatusChangedAt = updateTask.StatusChangedAt
+		default:
+			return nil, fmt.Errorf("task field %q cannot be updated", field)
+		}
+	}
+
+	if updated.Title == stored.Title && updated.Description == stored.Description &&
+		updated.Status == stored.Status && updated.DueDate.Equal(stored.DueDate) &&
+		updated.StatusChangedBy == stored.StatusChangedBy && updated.StatusChangedAt.Equal(stored.StatusChangedAt) {
+		return nil, domain.ErrTaskUnchanged
+	}
+
+	updated.Version++
+	repo.tasks[id] = updated
+	return cloneTask(updated), nil
+}
+
+func (repo *TaskRepository) Delete(c context.Context, id string, version int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.tasks[id]
+	if !ok || stored.DeletedAt != nil {
+		return domain.ErrTaskNotFound
+	}
+	if version != 0 && version != stored.Version {
+		return domain.ErrVersionMismatch
+	}
+
+	now := time.Now().UTC()
+	stored.DeletedAt = &now
+	stored.Version++
+	return nil
+}
+
+func (repo *TaskRepository) GetAll(c context.Context, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	if query == nil {
+		query = &domain.TaskQuery{}
+	}
+
+	var pivot *domain.Task
+	if query.Cursor != "" {
+		cursor, err := utils.DecodeTaskCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if pivot, err = cursor.Pivot(); err != nil {
+			return nil, err
+		}
+	}
+
+	repo.mu.RLock()
+	matching := make([]*domain.Task, 0, len(repo.order))
+	for _, id := range repo.order {
+		if task := repo.tasks[id]; matchesTaskQuery(task, query) {
+			matching = append(matching, cloneTask(task))
+		}
+	}
+	repo.mu.RUnlock()
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		return compareTasks(matching[i], matching[j], query) < 0
+	})
+
+	tasks := matching
+	if pivot != nil {
+		start := sort.Search(len(tasks), func(i int) bool {
+			return compareTasks(tasks[i], pivot, query) > 0
+		})
+		tasks = tasks[start:]
+	}
+
+	page := &domain.TaskPage{Items: tasks, Total: int64(len(matching))}
+	if query.Limit > 0 && len(tasks) > query.Limit {
+		page.Items = tasks[:query.Limit]
+		page.NextCursor = utils.EncodeTaskCursor(page.Items[query.Limit-1], query)
+	}
+	return page, nil
+}
+
+func (repo *TaskRepository) GetById(c context.Context, id string) (*domain.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.tasks[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, domain.ErrTaskNotFound
+	}
+	return cloneTask(stored), nil
+}
+
+func (repo *TaskRepository) UpdateAssignees(c context.Context, id string, assigneeIDs []string, version int64) (*domain.Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.tasks[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, domain.ErrTaskNotFound
+	}
+	if version != 0 && version != stored.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+	stored.AssigneeIDs = append([]string{}, assigneeIDs...)
+	stored.Version++
+	return cloneTask(stored), nil
+}
+
+func (repo *TaskRepository) GetTrashed(c context.Context, id string) (*domain.Task, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stored, ok := repo.tasks[id]
+	if !ok || stored.DeletedAt == nil {
+		return nil, domain.ErrTaskNotFound
+	}
+	return cloneTask(stored), nil
+}
+
+func (repo *TaskRepository) Restore(c context.Context, id string) (*domain.Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.tasks[id]
+	if !ok || stored.DeletedAt == nil {
+		return nil, domain.ErrTaskNotFound
+	}
+	stored.DeletedAt = nil
+	stored.Version++
+	return cloneTask(stored), nil
+}
+
+func (repo *TaskRepository) Purge(c context.Context, trashedBefore time.Time) ([]*domain.Task, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	purged := []*domain.Task{}
+	kept := repo.order[:0]
+	for _, id := range repo.order {
+		task := repo.tasks[id]
+		if task.DeletedAt != nil && task.DeletedAt.Before(trashedBefore) {
+			purged = append(purged, task)
+			delete(repo.tasks, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	repo.order = kept
+	return purged, nil
+}
+
+// cloneTask deep copies a task so callers never share memory with the store.
+func cloneTask(task *domain.Task) *domain.Task {
+	cloned := *task
+	if task.AssigneeIDs != nil {
+		cloned.AssigneeIDs = append([]string{}, task.AssigneeIDs...)
+	}
+	if task.DeletedAt != nil {
+		deletedAt := *task.DeletedAt
+		cloned.DeletedAt = &deletedAt
+	}
+	return &cloned
+}
+
+func matchesTaskQuery(task *domain.Task, query *domain.TaskQuery) bool {
+	if (task.DeletedAt != nil) != query.Trashed {
+		return false
+	}
+	if query.Status != "" && task.Status != query.Status {
+		return false
+	}
+	if query.AssigneeId != "" && !slices.Contains(task.AssigneeIDs, query.AssigneeId) {
+		return false
+	}
+	if query.VisibleTo != "" && task.CreatedBy != query.VisibleTo && !slices.Contains(task.AssigneeIDs, query.VisibleTo) {
+		return false
+	}
+	if query.Title != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(query.Title)) {
+		return false
+	}
+	if !query.DueBefore.IsZero() && !task.DueDate.Before(query.DueBefore) {
+		return false
+	}
+	if !query.DueAfter.IsZero() && !task.DueDate.After(query.DueAfter) {
+		return false
+	}
+	return true
+}
+
+// compareTasks orders tasks by the query's sort field, breaking ties by id
+// the same way the Mongo repository does.
+func compareTasks(a, b *domain.Task, query *domain.TaskQuery) int {
+	result := 0
+	switch query.SortBy {
+	case domain.TaskSortTitle:
+		result = strings.Compare(a.Title, b.Title)
+	case domain.TaskSortStatus:
+		result = strings.Compare(a.Status, b.Status)
+	case domain.TaskSortDueDate:
+		result = a.DueDate.Compare(b.DueDate)
+	}
+	if result == 0 {
+		result = strings.Compare(a.Id, b.Id)
+	}
+	if query.SortDesc {
+		return -result
+	}
+	return result
+}
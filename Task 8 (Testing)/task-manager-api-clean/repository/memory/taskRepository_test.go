@@ -0,0 +1,368 @@
+package memory_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/repository/memory"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TaskRepositoryTestSuite struct {
+	suite.Suite
+	repo domain.TaskRepository
+}
+
+func (suite *TaskRepositoryTestSuite) SetupTest() {
+	suite.repo = memory.NewTaskRepository()
+}
+
+func (suite *TaskRepositoryTestSuite) TestCreate_Success() {
+	task := &domain.Task{Title: "Test Task", Description: "Description of test task", Status: "Pending", DueDate: time.Now()}
+	createdTask, err := suite.repo.Create(context.Background(), task)
+	suite.NoError(err)
+	suite.NotEmpty(createdTask.Id)
+
+	fetchedTask, err := suite.repo.GetById(context.Background(), createdTask.Id)
+	suite.NoError(err)
+	suite.Equal(task.Title, fetchedTask.Title)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_Success() {
+	task := &domain.Task{Title: "Test Task", Description: "Description of test task", Status: "Pending", DueDate: time.Now()}
+	suite.repo.Create(context.Background(), task)
+
+	updateTask := &domain.Task{Title: "Updated Task Title", Status: "Completed"}
+	updatedTask, err := suite.repo.Update(context.Background(), task.Id, updateTask, []string{domain.TaskFieldTitle, domain.TaskFieldStatus})
+	suite.NoError(err)
+	suite.Equal("Updated Task Title", updatedTask.Title)
+	suite.Equal("Completed", updatedTask.Status)
+	suite.Equal(task.Description, updatedTask.Description)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_StatusChange() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending", StatusChangedBy: "creator", StatusChangedAt: time.Now().Add(-time.Hour)}
+	suite.repo.Create(context.Background(), task)
+
+	changedAt := time.Now()
+	updatedTask, err := suite.repo.Update(context.Background(), task.Id, &domain.Task{Status: "In Progress", StatusChangedBy: "mover", StatusChangedAt: changedAt}, []string{domain.TaskFieldStatus})
+	suite.NoError(err)
+	suite.Equal("In Progress", updatedTask.Status)
+	suite.Equal("mover", updatedTask.StatusChangedBy)
+	suite.True(changedAt.Equal(updatedTask.StatusChangedAt))
+	suite.Equal("Test Task", updatedTask.Title)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_Failure() {
+	_, err := suite.repo.Update(context.Background(), "nonExistentId", &domain.Task{Title: "New Title"}, []string{domain.TaskFieldTitle})
+	suite.Error(err)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_NoFieldsUpdated() {
+	task := &domain.Task{Title: "Test Task", Description: "Description of test task", Status: "Pending", DueDate: time.Now()}
+	suite.repo.Create(context.Background(), task)
+
+	updateTask := &domain.Task{Title: "Test Task", Description: "Description of test task"}
+	_, err := suite.repo.Update(context.Background(), task.Id, updateTask, []string{domain.TaskFieldTitle, domain.TaskFieldDescription})
+	suite.ErrorIs(err, domain.ErrTaskUnchanged)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_ClearsMaskedFields() {
+	task := &domain.Task{Title: "Test Task", Description: "Description of test task", Status: "Pending", DueDate: time.Now()}
+	suite.repo.Create(context.Background(), task)
+
+	updated, err := suite.repo.Update(context.Background(), task.Id, &domain.Task{}, []string{domain.TaskFieldDescription, domain.TaskFieldDueDate})
+	suite.NoError(err)
+	suite.Empty(updated.Description)
+	suite.True(updated.DueDate.IsZero())
+	suite.Equal("Test Task", updated.Title)
+	suite.Equal("Pending", updated.Status)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_UnknownField() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+
+	_, err := suite.repo.Update(context.Background(), task.Id, &domain.Task{}, []string{"createdBy"})
+	suite.Error(err)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_IncrementsVersion() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+	suite.Equal(int64(1), task.Version)
+
+	updated, err := suite.repo.Update(context.Background(), task.Id, &domain.Task{Title: "New Title", Version: 1}, []string{domain.TaskFieldTitle})
+	suite.NoError(err)
+	suite.Equal(int64(2), updated.Version)
+
+	assigned, err := suite.repo.UpdateAssignees(context.Background(), task.Id, []string{"user1"}, 0)
+	suite.NoError(err)
+	suite.Equal(int64(3), assigned.Version)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdate_VersionMismatch() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+	suite.repo.Update(context.Background(), task.Id, &domain.Task{Title: "First Edit", Version: 1}, []string{domain.TaskFieldTitle})
+
+	_, err := suite.repo.Update(context.Background(), task.Id, &domain.Task{Title: "Second Edit", Version: 1}, []string{domain.TaskFieldTitle})
+	suite.ErrorIs(err, domain.ErrVersionMismatch)
+	suite.ErrorIs(suite.repo.Delete(context.Background(), task.Id, 1), domain.ErrVersionMismatch)
+
+	fetched, _ := suite.repo.GetById(context.Background(), task.Id)
+	suite.Equal("First Edit", fetched.Title)
+	suite.NoError(suite.repo.Delete(context.Background(), task.Id, 2))
+}
+
+func (suite *TaskRepositoryTestSuite) TestDelete_Success() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+
+	err := suite.repo.Delete(context.Background(), task.Id, 0)
+	suite.NoError(err)
+
+	_, err = suite.repo.GetById(context.Background(), task.Id)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositoryTestSuite) TestDelete_Failure() {
+	err := suite.repo.Delete(context.Background(), "nonExistentId", 0)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositoryTestSuite) TestDelete_MovesTaskToTrash() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+	suite.repo.Create(context.Background(), &domain.Task{Title: "Other Task", Status: "Pending"})
+	suite.NoError(suite.repo.Delete(context.Background(), task.Id, 0))
+
+	page, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{})
+	suite.NoError(err)
+	suite.Len(page.Items, 1)
+	suite.Equal("Other Task", page.Items[0].Title)
+
+	trash, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{Trashed: true})
+	suite.NoError(err)
+	suite.Len(trash.Items, 1)
+	suite.Equal(task.Id, trash.Items[0].Id)
+	suite.NotNil(trash.Items[0].DeletedAt)
+
+	_, err = suite.repo.Update(context.Background(), task.Id, &domain.Task{Title: "New Title"}, []string{domain.TaskFieldTitle})
+	suite.Error(err)
+	_, err = suite.repo.UpdateAssignees(context.Background(), task.Id, []string{"user1"}, 0)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+	suite.ErrorIs(suite.repo.Delete(context.Background(), task.Id, 0), domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositoryTestSuite) TestRestore_Success() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+	suite.repo.Delete(context.Background(), task.Id, 0)
+
+	restored, err := suite.repo.Restore(context.Background(), task.Id)
+	suite.NoError(err)
+	suite.Nil(restored.DeletedAt)
+
+	fetched, err := suite.repo.GetById(context.Background(), task.Id)
+	suite.NoError(err)
+	suite.Equal("Test Task", fetched.Title)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetTrashed() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+
+	_, err := suite.repo.GetTrashed(context.Background(), task.Id)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+
+	suite.repo.Delete(context.Background(), task.Id, 0)
+	trashed, err := suite.repo.GetTrashed(context.Background(), task.Id)
+	suite.NoError(err)
+	suite.NotNil(trashed.DeletedAt)
+}
+
+func (suite *TaskRepositoryTestSuite) TestRestore_NotTrashed() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+
+	_, err := suite.repo.Restore(context.Background(), task.Id)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+	_, err = suite.repo.Restore(context.Background(), "nonExistentId")
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositoryTestSuite) TestPurge_RemovesTasksTrashedBeforeCutoff() {
+	old := &domain.Task{Title: "Old Task", Status: "Pending"}
+	live := &domain.Task{Title: "Live Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), old)
+	suite.repo.Create(context.Background(), live)
+	suite.repo.Delete(context.Background(), old.Id, 0)
+
+	purged, err := suite.repo.Purge(context.Background(), time.Now().Add(-time.Hour))
+	suite.NoError(err)
+	suite.Empty(purged)
+
+	purged, err = suite.repo.Purge(context.Background(), time.Now().Add(time.Second))
+	suite.NoError(err)
+	suite.Len(purged, 1)
+	suite.Equal(old.Id, purged[0].Id)
+
+	_, err = suite.repo.Restore(context.Background(), old.Id)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+	_, err = suite.repo.GetById(context.Background(), live.Id)
+	suite.NoError(err)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetAll_Success() {
+	suite.repo.Create(context.Background(), &domain.Task{Title: "Test Task 1", Status: "Pending"})
+	suite.repo.Create(context.Background(), &domain.Task{Title: "Test Task 2", Status: "Completed"})
+
+	page, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{})
+	suite.NoError(err)
+	suite.Len(page.Items, 2)
+	suite.Equal("Test Task 1", page.Items[0].Title)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetAll_Filters() {
+	now := time.Now()
+	suite.repo.Create(context.Background(), &domain.Task{Title: "Write report", Status: "Pending", DueDate: now.Add(time.Hour)})
+	suite.repo.Create(context.Background(), &domain.Task{Title: "Review report", Status: "Completed", DueDate: now.Add(2 * time.Hour)})
+	suite.repo.Create(context.Background(), &domain.Task{Title: "Ship release", Status: "Pending", DueDate: now.Add(3 * time.Hour)})
+
+	page, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{Status: "Pending"})
+	suite.NoError(err)
+	suite.Equal(int64(2), page.Total)
+
+	page, err = suite.repo.GetAll(context.Background(), &domain.TaskQuery{Title: "REPORT"})
+	suite.NoError(err)
+	suite.Equal(int64(2), page.Total)
+
+	page, err = suite.repo.GetAll(context.Background(), &domain.TaskQuery{DueAfter: now.Add(90 * time.Minute), DueBefore: now.Add(4 * time.Hour)})
+	suite.NoError(err)
+	suite.Len(page.Items, 2)
+	suite.Equal("Review report", page.Items[0].Title)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetAll_SortAndPaginate() {
+	for _, title := range []string{"b", "d", "a", "e", "c"} {
+		suite.repo.Create(context.Background(), &domain.Task{Title: title, Status: "Pending"})
+	}
+
+	query := &domain.TaskQuery{SortBy: domain.TaskSortTitle, SortDesc: true, Limit: 2}
+	var titles []string
+	for {
+		page, err := suite.repo.GetAll(context.Background(), query)
+		suite.NoError(err)
+		suite.Equal(int64(5), page.Total)
+		for _, task := range page.Items {
+			titles = append(titles, task.Title)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	suite.Equal([]string{"e", "d", "c", "b", "a"}, titles)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetAll_Visibility() {
+	suite.repo.Create(context.Background(), &domain.Task{Title: "created", CreatedBy: "u1"})
+	suite.repo.Create(context.Background(), &domain.Task{Title: "assigned", CreatedBy: "admin", AssigneeIDs: []string{"u2", "u1"}})
+	suite.repo.Create(context.Background(), &domain.Task{Title: "unrelated", CreatedBy: "admin", AssigneeIDs: []string{"u2"}})
+
+	page, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{VisibleTo: "u1"})
+	suite.NoError(err)
+	suite.Equal(int64(2), page.Total)
+
+	page, err = suite.repo.GetAll(context.Background(), &domain.TaskQuery{VisibleTo: "u1", AssigneeId: "u1"})
+	suite.NoError(err)
+	suite.Len(page.Items, 1)
+	suite.Equal("assigned", page.Items[0].Title)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdateAssignees_Success() {
+	task := &domain.Task{Title: "Test Task", AssigneeIDs: []string{"u1"}}
+	suite.repo.Create(context.Background(), task)
+
+	assignees := []string{"u2", "u3"}
+	updated, err := suite.repo.UpdateAssignees(context.Background(), task.Id, assignees, 0)
+	suite.NoError(err)
+	suite.Equal([]string{"u2", "u3"}, updated.AssigneeIDs)
+
+	// the stored task must not share the caller's slice
+	assignees[0] = "changed"
+	fetched, _ := suite.repo.GetById(context.Background(), task.Id)
+	suite.Equal([]string{"u2", "u3"}, fetched.AssigneeIDs)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdateAssignees_NotFound() {
+	_, err := suite.repo.UpdateAssignees(context.Background(), "nonExistentId", []string{"u1"}, 0)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdateAssignees_VersionMismatch() {
+	task := &domain.Task{Title: "Test Task"}
+	suite.repo.Create(context.Background(), task)
+
+	_, err := suite.repo.UpdateAssignees(context.Background(), task.Id, []string{"u1"}, 2)
+	suite.ErrorIs(err, domain.ErrVersionMismatch)
+
+	updated, err := suite.repo.UpdateAssignees(context.Background(), task.Id, []string{"u1"}, 1)
+	suite.NoError(err)
+	suite.Equal(int64(2), updated.Version)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetAll_InvalidCursor() {
+	_, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{Cursor: "not a cursor"})
+	suite.ErrorIs(err, domain.ErrInvalidTaskQuery)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetAll_NoTasks() {
+	page, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{})
+	suite.NoError(err)
+	suite.Len(page.Items, 0)
+	suite.Empty(page.NextCursor)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetById_ReturnsCopy() {
+	task := &domain.Task{Title: "Test Task", Status: "Pending"}
+	suite.repo.Create(context.Background(), task)
+
+	fetchedTask, err := suite.repo.GetById(context.Background(), task.Id)
+	suite.NoError(err)
+	fetchedTask.Title = "Changed outside the repository"
+
+	fetchedAgain, err := suite.repo.GetById(context.Background(), task.Id)
+	suite.NoError(err)
+	suite.Equal("Test Task", fetchedAgain.Title)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetById_Failure() {
+	_, err := suite.repo.GetById(context.Background(), "nonExistentId")
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositoryTestSuite) TestConcurrentCreate() {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			suite.repo.Create(context.Background(), &domain.Task{Title: "Concurrent Task", Status: "Pending"})
+		}()
+	}
+	wg.Wait()
+
+	page, err := suite.repo.GetAll(context.Background(), &domain.TaskQuery{})
+	suite.NoError(err)
+	suite.Len(page.Items, 50)
+}
+
+func TestTaskRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskRepositoryTestSuite))
+}
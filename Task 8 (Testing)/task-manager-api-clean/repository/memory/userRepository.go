@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserRepository keeps users in process memory, indexed by username. Like
+// the unique indexes of repository.UserRepository, it refuses a username or
+// email address another user already has.
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[string]*domain.User
+	ids   map[string]string // user id -> username
+}
+
+func NewUserRepository() domain.UserRepository {
+	return &UserRepository{
+		users: make(map[string]*domain.User),
+		ids:   make(map[string]string),
+	}
+}
+
+func (ur *UserRepository) Create(c context.Context, user *domain.User) (*domain.User, error) {
+	// hash the password before taking the lock, bcrypt is slow
+	hashedPassword, err := utils.EncryptPassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	if _, ok := ur.users[user.Username]; ok {
+		return nil, domain.ErrUserExists
+	}
+	if ur.emailTaken(user.Email, "") {
+		return nil, domain.ErrEmailTaken
+	}
+
+	// the caller decides the role, a user without one is an ordinary user
+	if user.Role == "" {
+		user.Role = "user"
+	}
+	user.Password = hashedPassword
+	user.UserID = primitive.NewObjectID().Hex()
+
+	stored := *user
+	ur.users[user.Username] = &stored
+	ur.ids[user.UserID] = user.Username
+	return user, nil
+}
+
+func (ur *UserRepository) GetByUsername(c context.Context, username string) (*domain.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	stored, ok := ur.users[username]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	user := *stored
+	return &user, nil
+}
+
+func (ur *UserRepository) GetById(c context.Context, userID string) (*domain.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	user := *stored
+	return &user, nil
+}
+
+func (ur *UserRepository) GetByEmail(c context.Context, email string) (*domain.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	for _, stored := range ur.users {
+		if stored.Email == email {
+			user := *stored
+			return &user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (ur *UserRepository) IncrementTokenVersion(c context.Context, userID string) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	stored.TokenVersion++
+	return nil
+}
+
+func (ur *UserRepository) RecordFailedLogin(c context.Context, userID string) (int, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return 0, domain.ErrUserNotFound
+	}
+	stored.FailedLogins++
+	return stored.FailedLogins, nil
+}
+
+func (ur *UserRepository) LockUntil(c context.Context, userID string, until time.Time) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	stored.LockedUntil = &until
+	return nil
+}
+
+func (ur *UserRepository) ResetFailedLogins(c context.Context, userID string) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	stored.FailedLogins = 0
+	stored.LockedUntil = nil
+	return nil
+}
+
+func (ur *UserRepository) UpdateRole(c context.Context, username string, role string) (*domain.User, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[username]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if stored.Role == role {
+		if role == "admin" {
+			return nil, domain.ErrUserAlreadyAdmin
+		}
+		user := *stored
+		return &user, nil
+	}
+	if stored.Role == "admin" && !stored.Deactivated && !ur.hasOtherAdmin(stored.UserID) {
+		return nil, domain.ErrLastAdmin
+	}
+
+	stored.Role = role
+	stored.TokenVersion++
+	user := *stored
+	return &user, nil
+}
+
+func (ur *UserRepository) UpdatePassword(c context.Context, userID string, password string) error {
+	// hash the password before taking the lock, bcrypt is slow
+	hashedPassword, err := utils.EncryptPassword(password)
+	if err != nil {
+		return err
+	}
+
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	stored.Password = hashedPassword
+	stored.TokenVersion++
+	return nil
+}
+
+func (ur *UserRepository) MarkEmailVerified(c context.Context, userID string, email string) (bool, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return false, domain.ErrUserNotFound
+	}
+	if stored.Email != email {
+		return false, nil
+	}
+	stored.EmailVerified = true
+	return true, nil
+}
+
+func (ur *UserRepository) List(c context.Context, query *domain.UserQuery) ([]*domain.User, string, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	// user ids are object ids, so they sort in the order users registered
+	ids := make([]string, 0, len(ur.ids))
+	for id := range ur.ids {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	users := []*domain.User{}
+	for _, id := range ids {
+		stored := ur.users[ur.ids[id]]
+		if (query.Role != "" && stored.Role != query.Role) ||
+			(query.Cursor != "" && id <= query.Cursor) {
+			continue
+		}
+
+		if query.Limit > 0 && len(users) == query.Limit {
+			return users, users[query.Limit-1].UserID, nil
+		}
+		user := *stored
+		users = append(users, &user)
+	}
+	return users, "", nil
+}
+
+func (ur *UserRepository) UpdateProfile(c context.Context, userID string, username string, email string) (*domain.User, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if _, ok := ur.users[username]; ok && username != stored.Username {
+		return nil, domain.ErrUserExists
+	}
+	if ur.emailTaken(email, userID) {
+		return nil, domain.ErrEmailTaken
+	}
+
+	if username != stored.Username {
+		delete(ur.users, stored.Username)
+		ur.users[username] = stored
+		ur.ids[userID] = username
+		stored.Username = username
+		stored.TokenVersion++
+	}
+	if email != stored.Email {
+		stored.Email = email
+		stored.EmailVerified = false
+	}
+	user := *stored
+	return &user, nil
+}
+
+func (ur *UserRepository) UpdateAccess(c context.Context, userID string, change *domain.UserUpdate) (*domain.User, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	stored, ok := ur.users[ur.ids[userID]]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	role, deactivated := stored.Role, stored.Deactivated
+	if change.Role != nil {
+		role = *change.Role
+	}
+	if change.Active != nil {
+		deactivated = !*change.Active
+	}
+	if stored.Role == "admin" && !stored.Deactivated && (role != "admin" || deactivated) && !ur.hasOtherAdmin(userID) {
+		return nil, domain.ErrLastAdmin
+	}
+
+	if role != stored.Role || (deactivated && !stored.Deactivated) {
+		stored.TokenVersion++
+	}
+	stored.Role = role
+	stored.Deactivated = deactivated
+	user := *stored
+	return &user, nil
+}
+
+// hasOtherAdmin reports whether an active admin other than the user is left.
+// The caller holds the lock.
+func (ur *UserRepository) hasOtherAdmin(userID string) bool {
+	for _, stored := range ur.users {
+		if stored.UserID != userID && stored.Role == "admin" && !stored.Deactivated {
+			return true
+		}
+	}
+	return false
+}
+
+// emailTaken reports whether a user other than userID has the address.
+// Accounts without an address never clash. The caller holds the lock.
+func (ur *UserRepository) emailTaken(email string, userID string) bool {
+	if email == "" {
+		return false
+	}
+	for _, stored := range ur.users {
+		if stored.UserID != userID && stored.Email == email {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,321 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/repository/memory"
+	"task-manager-api-clean/utils"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UserRepositoryTestSuite struct {
+	suite.Suite
+	repo domain.UserRepository
+}
+
+func (suite *UserRepositoryTestSuite) SetupTest() {
+	suite.repo = memory.NewUserRepository()
+}
+
+func (suite *UserRepositoryTestSuite) TestCreate_KeepsRole() {
+	// the first user is no different from the others
+	first, err := suite.repo.Create(context.Background(), &domain.User{Username: "first", Password: "first", Email: "first@example.com"})
+	suite.NoError(err)
+	suite.Equal("user", first.Role)
+	suite.NoError(utils.ComparePasswords(first.Password, "first"))
+
+	admin, err := suite.repo.Create(context.Background(), &domain.User{Username: "second", Password: "second", Email: "second@example.com", Role: "admin"})
+	suite.NoError(err)
+	suite.Equal("admin", admin.Role)
+}
+
+func (suite *UserRepositoryTestSuite) TestCreate_Duplicate() {
+	suite.repo.Create(context.Background(), &domain.User{Username: "taken", Password: "taken", Email: "taken@example.com"})
+
+	_, err := suite.repo.Create(context.Background(), &domain.User{Username: "taken", Password: "other", Email: "other@example.com"})
+	suite.ErrorIs(err, domain.ErrUserExists)
+	_, err = suite.repo.Create(context.Background(), &domain.User{Username: "other", Password: "other", Email: "taken@example.com"})
+	suite.ErrorIs(err, domain.ErrEmailTaken)
+	_, err = suite.repo.GetByUsername(context.Background(), "other")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+
+	// accounts without an address do not clash
+	suite.repo.Create(context.Background(), &domain.User{Username: "noemail1", Password: "noemail1"})
+	_, err = suite.repo.Create(context.Background(), &domain.User{Username: "noemail2", Password: "noemail2"})
+	suite.NoError(err)
+}
+
+func (suite *UserRepositoryTestSuite) TestCreate_Concurrent() {
+	// of many registrations racing for one username, exactly one wins
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := suite.repo.Create(context.Background(), &domain.User{Username: "racer", Password: "racer", Email: fmt.Sprintf("racer%d@example.com", i)})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		suite.ErrorIs(err, domain.ErrUserExists)
+	}
+	suite.Equal(1, created)
+}
+
+func (suite *UserRepositoryTestSuite) TestGetByUsername_Success() {
+	suite.repo.Create(context.Background(), &domain.User{Username: "test3", Password: "test3", Email: "test3@example.com"})
+
+	fetchedUser, err := suite.repo.GetByUsername(context.Background(), "test3")
+	suite.NoError(err)
+	suite.Equal("test3@example.com", fetchedUser.Email)
+}
+
+func (suite *UserRepositoryTestSuite) TestGetByUsername_Failure() {
+	_, err := suite.repo.GetByUsername(context.Background(), "nonExistentUser")
+	suite.Error(err)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateRole_Success() {
+	suite.repo.Create(context.Background(), &domain.User{Username: "test0", Password: "test0"})
+	suite.repo.Create(context.Background(), &domain.User{Username: "test4", Password: "test4"})
+
+	updatedUser, err := suite.repo.UpdateRole(context.Background(), "test4", "admin")
+	suite.NoError(err)
+	suite.Equal("admin", updatedUser.Role)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateRole_AlreadyAdmin() {
+	suite.repo.Create(context.Background(), &domain.User{Username: "test5", Password: "test5", Role: "admin"})
+
+	updatedUser, err := suite.repo.UpdateRole(context.Background(), "test5", "admin")
+	suite.Nil(updatedUser)
+	suite.ErrorIs(err, domain.ErrUserAlreadyAdmin)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateRole_SameRole() {
+	created, _ := suite.repo.Create(context.Background(), &domain.User{Username: "test5b", Password: "test5b", Role: "manager"})
+
+	// the user keeps their sessions
+	updatedUser, err := suite.repo.UpdateRole(context.Background(), "test5b", "manager")
+	suite.NoError(err)
+	suite.Equal("manager", updatedUser.Role)
+	suite.Equal(created.TokenVersion, updatedUser.TokenVersion)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateRole_Failure() {
+	_, err := suite.repo.UpdateRole(context.Background(), "nonExistentUser", "admin")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestGetById_Success() {
+	created, _ := suite.repo.Create(context.Background(), &domain.User{Username: "test6", Password: "test6"})
+
+	fetchedUser, err := suite.repo.GetById(context.Background(), created.UserID)
+	suite.NoError(err)
+	suite.Equal("test6", fetchedUser.Username)
+
+	_, err = suite.repo.GetById(context.Background(), "nonExistentId")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestTokenVersion() {
+	suite.repo.Create(context.Background(), &domain.User{Username: "admin", Password: "admin"})
+	created, _ := suite.repo.Create(context.Background(), &domain.User{Username: "test7", Password: "test7"})
+	suite.Equal(0, created.TokenVersion)
+
+	suite.NoError(suite.repo.IncrementTokenVersion(context.Background(), created.UserID))
+	promoted, err := suite.repo.UpdateRole(context.Background(), "test7", "admin")
+	suite.NoError(err)
+	suite.Equal(2, promoted.TokenVersion)
+
+	suite.ErrorIs(suite.repo.IncrementTokenVersion(context.Background(), "nonExistentId"), domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestFailedLogins() {
+	created, _ := suite.repo.Create(context.Background(), &domain.User{Username: "test8", Password: "test8"})
+
+	for want := 1; want <= 3; want++ {
+		failures, err := suite.repo.RecordFailedLogin(context.Background(), created.UserID)
+		suite.NoError(err)
+		suite.Equal(want, failures)
+	}
+	until := time.Now().Add(time.Minute)
+	suite.NoError(suite.repo.LockUntil(context.Background(), created.UserID, until))
+
+	locked, _ := suite.repo.GetByUsername(context.Background(), "test8")
+	suite.Equal(3, locked.FailedLogins)
+	suite.True(until.Equal(*locked.LockedUntil))
+
+	suite.NoError(suite.repo.ResetFailedLogins(context.Background(), created.UserID))
+	unlocked, _ := suite.repo.GetByUsername(context.Background(), "test8")
+	suite.Equal(0, unlocked.FailedLogins)
+	suite.Nil(unlocked.LockedUntil)
+
+	_, err := suite.repo.RecordFailedLogin(context.Background(), "nonExistentId")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdatePassword() {
+	created, _ := suite.repo.Create(context.Background(), &domain.User{Username: "test9", Password: "old", Email: "test9@example.com"})
+
+	suite.NoError(suite.repo.UpdatePassword(context.Background(), created.UserID, "new"))
+
+	updated, err := suite.repo.GetByEmail(context.Background(), "test9@example.com")
+	suite.NoError(err)
+	suite.NoError(utils.ComparePasswords(updated.Password, "new"))
+	suite.Equal(created.TokenVersion+1, updated.TokenVersion)
+
+	_, err = suite.repo.GetByEmail(context.Background(), "nobody@example.com")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+	suite.ErrorIs(suite.repo.UpdatePassword(context.Background(), "nonExistentId", "new"), domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestMarkEmailVerified() {
+	created, _ := suite.repo.Create(context.Background(), &domain.User{Username: "test10", Password: "test10", Email: "test10@example.com"})
+	suite.False(created.EmailVerified)
+
+	// a link sent to an earlier address verifies nothing
+	marked, err := suite.repo.MarkEmailVerified(context.Background(), created.UserID, "old10@example.com")
+	suite.NoError(err)
+	suite.False(marked)
+
+	marked, err = suite.repo.MarkEmailVerified(context.Background(), created.UserID, "test10@example.com")
+	suite.NoError(err)
+	suite.True(marked)
+	verified, err := suite.repo.GetById(context.Background(), created.UserID)
+	suite.NoError(err)
+	suite.True(verified.EmailVerified)
+
+	_, err = suite.repo.MarkEmailVerified(context.Background(), "nonExistentId", "test10@example.com")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateRole_LastAdmin() {
+	suite.repo.Create(context.Background(), &domain.User{Username: "admin1", Password: "admin1", Role: "admin"})
+	suite.repo.Create(context.Background(), &domain.User{Username: "admin2", Password: "admin2"})
+	_, err := suite.repo.UpdateRole(context.Background(), "admin2", "admin")
+	suite.NoError(err)
+
+	demoted, err := suite.repo.UpdateRole(context.Background(), "admin1", "user")
+	suite.NoError(err)
+	suite.Equal("user", demoted.Role)
+
+	_, err = suite.repo.UpdateRole(context.Background(), "admin2", "user")
+	suite.ErrorIs(err, domain.ErrLastAdmin)
+	kept, _ := suite.repo.GetByUsername(context.Background(), "admin2")
+	suite.Equal("admin", kept.Role)
+}
+
+func (suite *UserRepositoryTestSuite) TestList() {
+	suite.repo.Create(context.Background(), &domain.User{Username: "list0", Password: "list0", Role: "admin"})
+	for _, name := range []string{"list1", "list2", "list3", "list4"} {
+		suite.repo.Create(context.Background(), &domain.User{Username: name, Password: name})
+	}
+
+	users, next, err := suite.repo.List(context.Background(), &domain.UserQuery{Limit: 2})
+	suite.NoError(err)
+	suite.Equal([]string{"list0", "list1"}, []string{users[0].Username, users[1].Username})
+	suite.Equal(users[1].UserID, next)
+
+	users, next, err = suite.repo.List(context.Background(), &domain.UserQuery{Limit: 2, Cursor: next})
+	suite.NoError(err)
+	suite.Equal([]string{"list2", "list3"}, []string{users[0].Username, users[1].Username})
+
+	users, next, err = suite.repo.List(context.Background(), &domain.UserQuery{Limit: 2, Cursor: next})
+	suite.NoError(err)
+	suite.Len(users, 1)
+	suite.Empty(next)
+
+	users, _, err = suite.repo.List(context.Background(), &domain.UserQuery{Role: "admin"})
+	suite.NoError(err)
+	suite.Len(users, 1)
+	suite.Equal("list0", users[0].Username)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateProfile() {
+	created, _ := suite.repo.Create(context.Background(), &domain.User{Username: "test11", Password: "test11", Email: "test11@example.com"})
+	suite.repo.MarkEmailVerified(context.Background(), created.UserID, "test11@example.com")
+
+	// the same username and address change nothing
+	same, err := suite.repo.UpdateProfile(context.Background(), created.UserID, "test11", "test11@example.com")
+	suite.NoError(err)
+	suite.True(same.EmailVerified)
+	suite.Equal(created.TokenVersion, same.TokenVersion)
+
+	renamed, err := suite.repo.UpdateProfile(context.Background(), created.UserID, "renamed11", "new11@example.com")
+	suite.NoError(err)
+	suite.Equal("renamed11", renamed.Username)
+	suite.False(renamed.EmailVerified)
+	suite.Equal(created.TokenVersion+1, renamed.TokenVersion)
+
+	_, err = suite.repo.GetByUsername(context.Background(), "test11")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+	fetched, err := suite.repo.GetById(context.Background(), created.UserID)
+	suite.NoError(err)
+	suite.Equal("renamed11", fetched.Username)
+
+	// another user's username or address is refused, and changes nothing
+	suite.repo.Create(context.Background(), &domain.User{Username: "other11", Password: "other11", Email: "other11@example.com"})
+	_, err = suite.repo.UpdateProfile(context.Background(), created.UserID, "other11", "new11@example.com")
+	suite.ErrorIs(err, domain.ErrUserExists)
+	_, err = suite.repo.UpdateProfile(context.Background(), created.UserID, "renamed11", "other11@example.com")
+	suite.ErrorIs(err, domain.ErrEmailTaken)
+	fetched, _ = suite.repo.GetById(context.Background(), created.UserID)
+	suite.Equal("new11@example.com", fetched.Email)
+
+	_, err = suite.repo.UpdateProfile(context.Background(), "nonExistentId", "x", "x@example.com")
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateAccess() {
+	admin, _ := suite.repo.Create(context.Background(), &domain.User{Username: "admin12", Password: "admin12", Role: "admin"})
+	user, _ := suite.repo.Create(context.Background(), &domain.User{Username: "user12", Password: "user12"})
+	inactive, active, manager := false, true, "manager"
+
+	deactivated, err := suite.repo.UpdateAccess(context.Background(), user.UserID, &domain.UserUpdate{Active: &inactive})
+	suite.NoError(err)
+	suite.True(deactivated.Deactivated)
+	suite.Equal(user.TokenVersion+1, deactivated.TokenVersion)
+
+	// deactivating twice does not revoke anything more
+	again, err := suite.repo.UpdateAccess(context.Background(), user.UserID, &domain.UserUpdate{Active: &inactive})
+	suite.NoError(err)
+	suite.Equal(deactivated.TokenVersion, again.TokenVersion)
+
+	// a role and a reactivation are made in one step, revoking once
+	reactivated, err := suite.repo.UpdateAccess(context.Background(), user.UserID, &domain.UserUpdate{Role: &manager, Active: &active})
+	suite.NoError(err)
+	suite.False(reactivated.Deactivated)
+	suite.Equal("manager", reactivated.Role)
+	suite.Equal(deactivated.TokenVersion+1, reactivated.TokenVersion)
+
+	// refused as a whole, the role of the last admin included
+	_, err = suite.repo.UpdateAccess(context.Background(), admin.UserID, &domain.UserUpdate{Role: &manager, Active: &inactive})
+	suite.ErrorIs(err, domain.ErrLastAdmin)
+	kept, _ := suite.repo.GetById(context.Background(), admin.UserID)
+	suite.False(kept.Deactivated)
+	suite.Equal("admin", kept.Role)
+	suite.Equal(admin.TokenVersion, kept.TokenVersion)
+
+	_, err = suite.repo.UpdateAccess(context.Background(), "nonExistentId", &domain.UserUpdate{Active: &inactive})
+	suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func TestUserRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(UserRepositoryTestSuite))
+}
@@ -0,0 +1,228 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+
+	"task-manager-api-clean/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All is the schema of the API, oldest step first. New steps go at the end
+// with the next version.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "unique usernames and email addresses",
+		Up: func(c context.Context, db *mongo.Database) error {
+			return repository.CreateUserIndexes(c, db, repository.UsersCollection)
+		},
+		Down: func(c context.Context, db *mongo.Database) error {
+			return repository.DropUserIndexes(c, db, repository.UsersCollection)
+		},
+	},
+	{
+		// TaskRepository.Update used to write the due date to due_date, where
+		// nothing reads it. The value it wrote there is the latest one.
+		Version:     2,
+		Description: "move tasks' due_date to dueDate",
+		Up: func(c context.Context, db *mongo.Database) error {
+			update := mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"dueDate": "$due_date"}}},
+				{{Key: "$unset", Value: "due_date"}},
+			}
+			_, err := db.Collection(repository.TasksCollection).UpdateMany(c, bson.M{"due_date": bson.M{"$exists": true}}, update)
+			return err
+		},
+		Down: noop,
+	},
+	{
+		// tasks created before optimistic locking have no version
+		Version:     3,
+		Description: "backfill task versions",
+		Up: func(c context.Context, db *mongo.Database) error {
+			_, err := db.Collection(repository.TasksCollection).UpdateMany(c, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": 1}})
+			return err
+		},
+		Down: noop,
+	},
+	{
+		Version:     4,
+		Description: "index tasks by due date and status",
+		Up: func(c context.Context, db *mongo.Database) error {
+			_, err := db.Collection(repository.TasksCollection).Indexes().CreateMany(c, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "dueDate", Value: 1}}, Options: options.Index().SetName("dueDate")},
+				{Keys: bson.D{{Key: "status", Value: 1}}, Options: options.Index().SetName("status")},
+			})
+			return err
+		},
+		Down: func(c context.Context, db *mongo.Database) error {
+			return dropIndexes(c, db.Collection(repository.TasksCollection), "dueDate", "status")
+		},
+	},
+	{
+		Version:     5,
+		Description: "validate users and tasks with $jsonSchema",
+		Up: func(c context.Context, db *mongo.Database) error {
+			if err := setValidator(c, db, repository.UsersCollection, userSchema); err != nil {
+				return err
+			}
+			return setValidator(c, db, repository.TasksCollection, taskSchema)
+		},
+		Down: func(c context.Context, db *mongo.Database) error {
+			if err := removeValidator(c, db, repository.UsersCollection); err != nil {
+				return err
+			}
+			return removeValidator(c, db, repository.TasksCollection)
+		},
+	},
+	{
+		Version:     6,
+		Description: "unique refresh token hashes, dropped once expired",
+		Up: func(c context.Context, db *mongo.Database) error {
+			return createTokenIndexes(c, db.Collection(repository.RefreshTokensCollection))
+		},
+		Down: func(c context.Context, db *mongo.Database) error {
+			return dropIndexes(c, db.Collection(repository.RefreshTokensCollection), tokenHashIndex, expiresAtIndex)
+		},
+	},
+	{
+		Version:     7,
+		Description: "unique password reset token hashes, dropped once expired",
+		Up: func(c context.Context, db *mongo.Database) error {
+			return createTokenIndexes(c, db.Collection(repository.PasswordResetsCollection))
+		},
+		Down: func(c context.Context, db *mongo.Database) error {
+			return dropIndexes(c, db.Collection(repository.PasswordResetsCollection), tokenHashIndex, expiresAtIndex)
+		},
+	},
+	{
+		// GET /tasks/:id/history and GET /audit?task= read one task's entries
+		Version:     8,
+		Description: "index task history by task and time",
+		Up: func(c context.Context, db *mongo.Database) error {
+			_, err := db.Collection(repository.HistoryCollection).Indexes().CreateOne(c, mongo.IndexModel{
+				Keys:    bson.D{{Key: "taskId", Value: 1}, {Key: "at", Value: -1}},
+				Options: options.Index().SetName("taskId_at"),
+			})
+			return err
+		},
+		Down: func(c context.Context, db *mongo.Database) error {
+			return dropIndexes(c, db.Collection(repository.HistoryCollection), "taskId_at")
+		},
+	},
+}
+
+// Indexes of the collections holding hashed one-time tokens.
+const (
+	tokenHashIndex = "tokenHash_unique"
+	expiresAtIndex = "expiresAt_ttl"
+)
+
+// createTokenIndexes makes the hash tokens are looked up by unique, and has
+// MongoDB remove tokens once they expire. Expired tokens are refused anyway,
+// so nothing is lost.
+func createTokenIndexes(c context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(c, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetName(tokenHashIndex).SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName(expiresAtIndex).SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// userSchema is what UserRepository writes. Fields it does not know about
+// are allowed, so a rollback to an older build keeps working.
+var userSchema = bson.M{
+	"bsonType": "object",
+	"required": bson.A{"_id", "username", "password", "role"},
+	"properties": bson.M{
+		"_id":           bson.M{"bsonType": "string"},
+		"username":      bson.M{"bsonType": "string", "minLength": 1},
+		"email":         bson.M{"bsonType": "string"},
+		"emailVerified": bson.M{"bsonType": "bool"},
+		"password":      bson.M{"bsonType": "string", "minLength": 1},
+		"role":          bson.M{"bsonType": "string", "minLength": 1},
+		"tokenVersion":  bson.M{"bsonType": bson.A{"int", "long"}},
+		"failedLogins":  bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+		"lockedUntil":   bson.M{"bsonType": "date"},
+		"deactivated":   bson.M{"bsonType": "bool"},
+	},
+}
+
+// taskSchema is what TaskRepository writes.
+var taskSchema = bson.M{
+	"bsonType": "object",
+	"required": bson.A{"_id", "title", "status", "version"},
+	"properties": bson.M{
+		"_id":             bson.M{"bsonType": "string"},
+		"title":           bson.M{"bsonType": "string", "minLength": 1},
+		"description":     bson.M{"bsonType": "string"},
+		"status":          bson.M{"bsonType": "string", "minLength": 1},
+		"dueDate":         bson.M{"bsonType": "date"},
+		"createdBy":       bson.M{"bsonType": "string"},
+		"assigneeIds":     bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+		"statusChangedBy": bson.M{"bsonType": "string"},
+		"statusChangedAt": bson.M{"bsonType": "date"},
+		"deletedAt":       bson.M{"bsonType": "date"},
+		"version":         bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1},
+	},
+}
+
+// noop is the Down of steps that only repair data: undoing the repair would
+// bring the damage back.
+func noop(c context.Context, db *mongo.Database) error {
+	return nil
+}
+
+func dropIndexes(c context.Context, collection *mongo.Collection, names ...string) error {
+	for _, name := range names {
+		if _, err := collection.Indexes().DropOne(c, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setValidator rejects writes of documents that do not match the schema.
+// The validation level is moderate, so a document stored before that does
+// not match can still be updated, but not made any worse.
+func setValidator(c context.Context, db *mongo.Database, collection string, schema bson.M) error {
+	command := bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: bson.M{"$jsonSchema": schema}},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}
+	err := db.RunCommand(c, command).Err()
+	if !isNamespaceNotFound(err) {
+		return err
+	}
+
+	// a fresh database has no collections yet
+	opts := options.CreateCollection().
+		SetValidator(bson.M{"$jsonSchema": schema}).
+		SetValidationLevel("moderate").
+		SetValidationAction("error")
+	return db.CreateCollection(c, collection, opts)
+}
+
+func removeValidator(c context.Context, db *mongo.Database, collection string) error {
+	command := bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: bson.M{}},
+		{Key: "validationLevel", Value: "off"},
+	}
+	err := db.RunCommand(c, command).Err()
+	if isNamespaceNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func isNamespaceNotFound(err error) bool {
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && commandErr.Code == 26
+}
@@ -0,0 +1,231 @@
+// Package migrations versions the MongoDB schema: the indexes, validators
+// and data fixes the repositories rely on are applied in order, once, and
+// recorded in the schema_migrations collection.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"task-manager-api-clean/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Collection records the applied migrations, and holds the lock taken while
+// migrating.
+const Collection = "schema_migrations"
+
+const (
+	lockID = "lock"
+	// staleLockAge is how old a lock has to be before it is taken to be
+	// left over by a run that crashed, and taken over.
+	staleLockAge = 15 * time.Minute
+	lockPoll     = 500 * time.Millisecond
+)
+
+// Migration is one step of the schema. Versions order the steps and, once
+// released, never change.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(c context.Context, db *mongo.Database) error
+	// Down undoes Up. Steps that only repair data have nothing to undo.
+	Down func(c context.Context, db *mongo.Database) error
+}
+
+// Status tells whether a migration has been applied, and when.
+type Status struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+type record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+type Migrator struct {
+	database   *mongo.Database
+	migrations []Migration
+}
+
+// NewMigrator migrates db through the migrations, All in the API.
+func NewMigrator(db *mongo.Database, migrations []Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{database: db, migrations: sorted}
+}
+
+// Up applies every migration not applied yet, in order, and returns those
+// it applied. It stops at the first one to fail.
+func (m *Migrator) Up(c context.Context) ([]Migration, error) {
+	unlock, err := m.lock(c)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(c)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := migration.Up(c, m.database); err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+		rec := record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now().UTC()}
+		if _, err := m.database.Collection(Collection).InsertOne(c, rec); err != nil {
+			return done, err
+		}
+		utils.Logger(c).Info("migration applied", "version", migration.Version, "description", migration.Description)
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down undoes the latest applied migration and returns it, or nil when none
+// is applied.
+func (m *Migrator) Down(c context.Context) (*Migration, error) {
+	unlock, err := m.lock(c)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(c)
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	for version := range applied {
+		if version > latest {
+			latest = version
+		}
+	}
+	if latest == 0 {
+		return nil, nil
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version != latest {
+			continue
+		}
+		if err := migration.Down(c, m.database); err != nil {
+			return nil, fmt.Errorf("undoing migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+		if _, err := m.database.Collection(Collection).DeleteOne(c, bson.M{"_id": migration.Version}); err != nil {
+			return nil, err
+		}
+		utils.Logger(c).Info("migration undone", "version", migration.Version, "description", migration.Description)
+		return &migration, nil
+	}
+	return nil, fmt.Errorf("the database is at migration %d, which this build does not know", latest)
+}
+
+// Status lists every migration, applied or not, in order.
+func (m *Migrator) Status(c context.Context) ([]Status, error) {
+	applied, err := m.applied(c)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Description: migration.Description}
+		if rec, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &rec.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations not applied yet.
+func (m *Migrator) Pending(c context.Context) ([]Migration, error) {
+	applied, err := m.applied(c)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// applied returns the records of the applied migrations by version.
+func (m *Migrator) applied(c context.Context) (map[int]record, error) {
+	// the lock shares the collection, under a string id
+	cursor, err := m.database.Collection(Collection).Find(c, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	records := []record{}
+	if err := cursor.All(c, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// lock keeps instances starting together from migrating at the same time.
+// It waits for the lock until c is done, and returns the function giving it
+// back.
+func (m *Migrator) lock(c context.Context) (func(), error) {
+	collection := m.database.Collection(Collection)
+	unlock := func() {
+		// given back even when c was cancelled half way
+		collection.DeleteOne(context.WithoutCancel(c), bson.M{"_id": lockID})
+	}
+
+	waiting := false
+	for {
+		now := time.Now().UTC()
+		_, err := collection.InsertOne(c, bson.M{"_id": lockID, "lockedAt": now})
+		if err == nil {
+			return unlock, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		// a lock left by a run that crashed is taken over
+		filter := bson.M{"_id": lockID, "lockedAt": bson.M{"$lt": now.Add(-staleLockAge)}}
+		result, err := collection.UpdateOne(c, filter, bson.M{"$set": bson.M{"lockedAt": now}})
+		if err != nil {
+			return nil, err
+		}
+		if result.ModifiedCount == 1 {
+			utils.Logger(c).Warn("took over a stale migration lock")
+			return unlock, nil
+		}
+
+		if !waiting {
+			utils.Logger(c).Info("waiting for another instance to finish migrating")
+			waiting = true
+		}
+		select {
+		case <-c.Done():
+			return nil, fmt.Errorf("waiting for the migration lock: %w", c.Err())
+		case <-time.After(lockPoll):
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package repository_test
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "sync"
+    "testing"
+    "time"
+
+    "task-manager-api-clean/repository"
+    "task-manager-api-clean/repository/migrations"
+
+    "github.com/stretchr/testify/suite"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MigrationsTestSuite struct {
+    suite.Suite
+    database *mongo.Database
+    client   *mongo.Client
+}
+
+func (suite *MigrationsTestSuite) SetupSuite() {
+    mongoURI := os.Getenv("MONGODB_URI")
+    if mongoURI == "" {
+        mongoURI = "mongodb://localhost:27017"
+    }
+
+    client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+    suite.NoError(err)
+
+    suite.client = client
+    // a database of its own, as migrations change whole collections
+    suite.database = client.Database("test_migrations")
+}
+
+func (suite *MigrationsTestSuite) TearDownSuite() {
+    if suite.client != nil {
+        suite.database.Drop(context.Background())
+        suite.NoError(suite.client.Disconnect(context.Background()))
+    }
+}
+
+func (suite *MigrationsTestSuite) SetupTest() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    suite.database.Drop(ctx)
+}
+
+func (suite *MigrationsTestSuite) TestUp_AppliesEveryMigrationOnce() {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    // a task written before the due date fix and before versions
+    tasks := suite.database.Collection(repository.TasksCollection)
+    _, err := tasks.InsertOne(ctx, bson.M{"_id": "1", "title": "Old", "status": "Pending", "dueDate": time.Unix(0, 0), "due_date": time.Unix(1000, 0)})
+    suite.Require().NoError(err)
+
+    migrator := migrations.NewMigrator(suite.database, migrations.All)
+    applied, err := migrator.Up(ctx)
+    suite.Require().NoError(err)
+    suite.Len(applied, len(migrations.All))
+
+    var task bson.M
+    suite.NoError(tasks.FindOne(ctx, bson.M{"_id": "1"}).Decode(&task))
+    suite.NotContains(task, "due_date")
+    suite.Equal(time.Unix(1000, 0).UnixMilli(), int64(task["dueDate"].(primitive.DateTime)))
+    suite.EqualValues(1, task["version"])
+
+    statuses, err := migrator.Status(ctx)
+    suite.NoError(err)
+    for _, status := range statuses {
+        suite.NotNil(status.AppliedAt, status.Description)
+    }
+
+    applied, err = migrator.Up(ctx)
+    suite.NoError(err)
+    suite.Empty(applied)
+
+    // the validators refuse what the repositories would never write
+    _, err = tasks.InsertOne(ctx, bson.M{"_id": "2", "status": "Pending", "version": 1})
+    suite.Error(err)
+    _, err = suite.database.Collection(repository.UsersCollection).InsertOne(ctx, bson.M{"_id": "1", "username": "test", "password": 42, "role": "user"})
+    suite.Error(err)
+
+    // refresh tokens are looked up by a hash that must be unique, and expire on their own
+    refreshTokens := suite.database.Collection(repository.RefreshTokensCollection)
+    _, err = refreshTokens.InsertOne(ctx, bson.M{"_id": "1", "tokenHash": "hash", "expiresAt": time.Now().Add(time.Hour)})
+    suite.NoError(err)
+    _, err = refreshTokens.InsertOne(ctx, bson.M{"_id": "2", "tokenHash": "hash", "expiresAt": time.Now().Add(time.Hour)})
+    suite.True(mongo.IsDuplicateKeyError(err))
+    suite.Equal(int32(0), suite.expireAfterSeconds(ctx, refreshTokens))
+
+    // and so are password reset tokens
+    passwordResets := suite.database.Collection(repository.PasswordResetsCollection)
+    _, err = passwordResets.InsertOne(ctx, bson.M{"_id": "1", "tokenHash": "hash", "expiresAt": time.Now().Add(time.Hour)})
+    suite.NoError(err)
+    _, err = passwordResets.InsertOne(ctx, bson.M{"_id": "2", "tokenHash": "hash", "expiresAt": time.Now().Add(time.Hour)})
+    suite.True(mongo.IsDuplicateKeyError(err))
+    suite.Equal(int32(0), suite.expireAfterSeconds(ctx, passwordResets))
+
+    // a task's history is read through an index rather than a scan
+    var plan bson.M
+    history := suite.database.Collection(repository.HistoryCollection)
+    suite.Require().NoError(history.Database().RunCommand(ctx, bson.D{
+        {Key: "explain", Value: bson.D{{Key: "find", Value: history.Name()}, {Key: "filter", Value: bson.M{"taskId": "1"}}}},
+    }).Decode(&plan))
+    suite.Contains(fmt.Sprint(plan["queryPlanner"]), "taskId_at")
+}
+
+// expireAfterSeconds returns the expiry of the TTL index on expiresAt, or -1 without one.
+func (suite *MigrationsTestSuite) expireAfterSeconds(ctx context.Context, collection *mongo.Collection) int32 {
+    cursor, err := collection.Indexes().List(ctx)
+    suite.Require().NoError(err)
+    var indexes []bson.M
+    suite.Require().NoError(cursor.All(ctx, &indexes))
+    for _, index := range indexes {
+        if expiry, ok := index["expireAfterSeconds"]; ok && index["key"].(bson.M)["expiresAt"] != nil {
+            return expiry.(int32)
+        }
+    }
+    return -1
+}
+
+func (suite *MigrationsTestSuite) TestDown_UndoesTheLatest() {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    migrator := migrations.NewMigrator(suite.database, migrations.All)
+    _, err := migrator.Up(ctx)
+    suite.Require().NoError(err)
+
+    undone, err := migrator.Down(ctx)
+    suite.NoError(err)
+    suite.Equal(migrations.All[len(migrations.All)-1].Version, undone.Version)
+
+    pending, err := migrator.Pending(ctx)
+    suite.NoError(err)
+    suite.Len(pending, 1)
+
+    for range migrations.All[1:] {
+        _, err = migrator.Down(ctx)
+        suite.NoError(err)
+    }
+    _, err = suite.database.Collection(repository.TasksCollection).InsertOne(ctx, bson.M{"_id": "2", "status": "Pending"})
+    suite.NoError(err)
+    undone, err = migrator.Down(ctx)
+    suite.NoError(err)
+    suite.Nil(undone)
+}
+
+func (suite *MigrationsTestSuite) TestUp_Concurrent() {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    // instances starting together apply every migration exactly once
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    total := 0
+    for i := 0; i < 3; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            applied, err := migrations.NewMigrator(suite.database, migrations.All).Up(ctx)
+            suite.NoError(err)
+            mu.Lock()
+            total += len(applied)
+            mu.Unlock()
+        }()
+    }
+    wg.Wait()
+    suite.Equal(len(migrations.All), total)
+}
+
+func TestMigrationsTestSuite(t *testing.T) {
+    suite.Run(t, new(MigrationsTestSuite))
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task-manager-api-clean/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PasswordResetRepository struct {
+	database   *mongo.Database
+	collection string
+}
+
+func NewPasswordResetRepository(db *mongo.Database, collection string) domain.PasswordResetRepository {
+	return &PasswordResetRepository{
+		database:   db,
+		collection: collection,
+	}
+}
+
+func (pr *PasswordResetRepository) Create(c context.Context, token *domain.PasswordResetToken) error {
+	token.Id = primitive.NewObjectID().Hex()
+	_, err := pr.database.Collection(pr.collection).InsertOne(c, token)
+	return err
+}
+
+func (pr *PasswordResetRepository) GetByHash(c context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	var token domain.PasswordResetToken
+	err := pr.database.Collection(pr.collection).FindOne(c, bson.M{"tokenHash": tokenHash}).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (pr *PasswordResetRepository) MarkUsed(c context.Context, id string) (bool, error) {
+	// matching on a missing usedAt makes this a compare-and-set, so two
+	// concurrent resets with the same token cannot both succeed
+	filter := bson.M{"_id": id, "usedAt": nil}
+	update := bson.M{"$set": bson.M{"usedAt": time.Now()}}
+	result, err := pr.database.Collection(pr.collection).UpdateOne(c, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"task-manager-api-clean/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RefreshTokenRepository struct {
+	database   *mongo.Database
+	collection string
+}
+
+func NewRefreshTokenRepository(db *mongo.Database, collection string) domain.RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		database:   db,
+		collection: collection,
+	}
+}
+
+func (rr *RefreshTokenRepository) Create(c context.Context, token *domain.RefreshToken) error {
+	token.Id = primitive.NewObjectID().Hex()
+	_, err := rr.database.Collection(rr.collection).InsertOne(c, token)
+	return err
+}
+
+func (rr *RefreshTokenRepository) GetByHash(c context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := rr.database.Collection(rr.collection).FindOne(c, bson.M{"tokenHash": tokenHash}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (rr *RefreshTokenRepository) Revoke(c context.Context, id string) (bool, error) {
+	// matching on a missing revokedAt makes the revocation a compare-and-set,
+	// so two concurrent refreshes with the same token cannot both succeed
+	filter := bson.M{"_id": id, "revokedAt": nil}
+	update := bson.M{"$set": bson.M{"revokedAt": time.Now()}}
+	result, err := rr.database.Collection(rr.collection).UpdateOne(c, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (rr *RefreshTokenRepository) RevokeFamily(c context.Context, familyId string) error {
+	return rr.revokeMany(c, bson.M{"familyId": familyId})
+}
+
+func (rr *RefreshTokenRepository) RevokeAllForUser(c context.Context, userID string) error {
+	return rr.revokeMany(c, bson.M{"userId": userID})
+}
+
+func (rr *RefreshTokenRepository) revokeMany(c context.Context, filter bson.M) error {
+	filter["revokedAt"] = nil
+	update := bson.M{"$set": bson.M{"revokedAt": time.Now()}}
+	_, err := rr.database.Collection(rr.collection).UpdateMany(c, filter, update)
+	return err
+}
@@ -3,10 +3,15 @@ package repository
 import (
 	"context"
 	"errors"
-	"task-manager-api-clean/domain"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+	"time"
 )
 
 type TaskRepository struct {
@@ -23,6 +28,7 @@ func NewTaskRepository(db *mongo.Database, collection string) domain.TaskReposit
 
 func (repo *TaskRepository) Create(c context.Context, task *domain.Task) (*domain.Task, error) {
 	task.Id = primitive.NewObjectID().Hex()
+	task.Version = 1
 	_, err := repo.database.Collection(repo.collection).InsertOne(c, task)
 	if err != nil {
 		return nil, err
@@ -30,79 +36,299 @@ func (repo *TaskRepository) Create(c context.Context, task *domain.Task) (*domai
 	return task, nil
 }
 
-func (repo *TaskRepository) Update(c context.Context, id string, updateTask *domain.Task) (*domain.Task, error) {
-
-
+func (repo *TaskRepository) Update(c context.Context, id string, updateTask *domain.Task, fields []string) (*domain.Task, error) {
 	update := bson.M{}
 	setFields := bson.M{}
-	
-	if updateTask.Title != "" {
-		setFields["title"] = updateTask.Title
+	for _, field := range fields {
+		switch field {
+		case domain.TaskFieldTitle:
+			setFields["title"] = updateTask.Title
+		case domain.TaskFieldDescription:
+			setFields["description"] = updateTask.Description
+		case domain.TaskFieldDueDate:
+			setFields["dueDate"] = updateTask.DueDate
+		case domain.TaskFieldStatus:
+			setFields["status"] = updateTask.Status
+			setFields["statusChangedBy"] = updateTask.StatusChangedBy
+			setFields["statusChangedAt"] = updateTask.StatusChangedAt
+		default:
+			return nil, fmt.Errorf("task field %q cannot be updated", field)
+		}
 	}
-	if !updateTask.DueDate.IsZero() {
-		setFields["due_date"] = updateTask.DueDate
-	}
-	if updateTask.Status != "" {
-		setFields["status"] = updateTask.Status
-	}
-	if updateTask.Description != "" {
-		setFields["description"] = updateTask.Description
-	}
-	
+
 	if len(setFields) > 0 {
 		update["$set"] = setFields
 	}
+	update["$inc"] = bson.M{"version": 1}
+
+	// Only match when a field actually changes, so the version is not bumped for nothing
+	changes := bson.A{}
+	for field, value := range setFields {
+		changes = append(changes, bson.M{field: bson.M{"$ne": value}})
+	}
+	filter := versionFilter(id, updateTask.Version)
+	filter["$or"] = changes
 
-    result, err := repo.database.Collection(repo.collection).UpdateOne(c, bson.M{"_id": id}, update)
-    if err != nil {
-        return nil, err
-    }
+	if len(changes) == 0 {
+		return nil, domain.ErrTaskUnchanged
+	}
 
-    if result.ModifiedCount == 0 {
-        return nil, errors.New("task not updated, no new information is provided")
-    }
+	result, err := repo.database.Collection(repo.collection).UpdateOne(c, filter, update)
+	if err != nil {
+		return nil, err
+	}
 
-    var updated domain.Task
-    if err = repo.database.Collection(repo.collection).FindOne(c, bson.M{"_id": id}).Decode(&updated); err != nil {
-        return nil, err
-    }
+	if result.MatchedCount == 0 {
+		if repo.versionChanged(c, id, updateTask.Version) {
+			return nil, domain.ErrVersionMismatch
+		}
+		if _, err := repo.GetById(c, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrTaskUnchanged
+	}
 
-    return &updated, nil
+	var updated domain.Task
+	if err = repo.database.Collection(repo.collection).FindOne(c, bson.M{"_id": id}).Decode(&updated); err != nil {
+		return nil, taskError(err)
+	}
+
+	return &updated, nil
 }
 
-func (repo *TaskRepository) Delete(c context.Context, id string) error {
-	result, err := repo.database.Collection(repo.collection).DeleteOne(c, bson.M{"_id": id})
+func (repo *TaskRepository) Delete(c context.Context, id string, version int64) error {
+	update := bson.M{"$set": bson.M{"deletedAt": time.Now().UTC()}, "$inc": bson.M{"version": 1}}
+	result, err := repo.database.Collection(repo.collection).UpdateOne(c, versionFilter(id, version), update)
 	if err != nil {
-        return err
-    }
+		return err
+	}
 
-	if result.DeletedCount == 0 {
-        return errors.New("no document found with the given id")
-    }
+	if result.MatchedCount == 0 {
+		if repo.versionChanged(c, id, version) {
+			return domain.ErrVersionMismatch
+		}
+		return domain.ErrTaskNotFound
+	}
 
-    return nil
+	return nil
 }
 
-func (repo *TaskRepository) GetAll(c context.Context) (*[]*domain.Task, error) {
-	cursor, err := repo.database.Collection(repo.collection).Find(c, bson.M{})
+func (repo *TaskRepository) GetAll(c context.Context, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	if query == nil {
+		query = &domain.TaskQuery{}
+	}
+	collection := repo.database.Collection(repo.collection)
+
+	filter := taskFilter(query)
+	total, err := collection.CountDocuments(c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortField := taskSortField(query.SortBy)
+	direction, op := 1, "$gt"
+	if query.SortDesc {
+		direction, op = -1, "$lt"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := utils.DecodeTaskCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		pivot, err := cursor.Pivot()
+		if err != nil {
+			return nil, err
+		}
+
+		// Keyset pagination: continue strictly after the last task of the previous page
+		after := bson.M{"_id": bson.M{op: pivot.Id}}
+		if sortField != "_id" {
+			value := taskSortValue(pivot, query.SortBy)
+			after = bson.M{"$or": bson.A{
+				bson.M{sortField: bson.M{op: value}},
+				bson.M{sortField: value, "_id": bson.M{op: pivot.Id}},
+			}}
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if sortField != "_id" {
+		sort = bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}
+	}
+	opts := options.Find().SetSort(sort)
+	if query.Limit > 0 {
+		// fetch one extra task to find out whether there is a next page
+		opts.SetLimit(int64(query.Limit) + 1)
+	}
+
+	cursor, err := collection.Find(c, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(c)
 
-	var tasks []*domain.Task
+	tasks := []*domain.Task{}
 	if err = cursor.All(c, &tasks); err != nil {
 		return nil, err
 	}
-	return &tasks, nil
+
+	page := &domain.TaskPage{Items: tasks, Total: total}
+	if query.Limit > 0 && len(tasks) > query.Limit {
+		page.Items = tasks[:query.Limit]
+		page.NextCursor = utils.EncodeTaskCursor(page.Items[query.Limit-1], query)
+	}
+	return page, nil
 }
 
 func (repo *TaskRepository) GetById(c context.Context, id string) (*domain.Task, error) {
 
 	var task domain.Task
-	if err := repo.database.Collection(repo.collection).FindOne(c, bson.M{"_id": id}).Decode(&task); err != nil {
-		return nil, err
+	if err := repo.database.Collection(repo.collection).FindOne(c, bson.M{"_id": id, "deletedAt": nil}).Decode(&task); err != nil {
+		return nil, taskError(err)
 	}
 	return &task, nil
 }
 
+func (repo *TaskRepository) GetTrashed(c context.Context, id string) (*domain.Task, error) {
+	var task domain.Task
+	if err := repo.database.Collection(repo.collection).FindOne(c, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}).Decode(&task); err != nil {
+		return nil, taskError(err)
+	}
+	return &task, nil
+}
+
+func (repo *TaskRepository) UpdateAssignees(c context.Context, id string, assigneeIDs []string, version int64) (*domain.Task, error) {
+	update := bson.M{"$set": bson.M{"assigneeIds": assigneeIDs}, "$inc": bson.M{"version": 1}}
+	result, err := repo.database.Collection(repo.collection).UpdateOne(c, versionFilter(id, version), update)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.MatchedCount == 0 {
+		if repo.versionChanged(c, id, version) {
+			return nil, domain.ErrVersionMismatch
+		}
+		return nil, domain.ErrTaskNotFound
+	}
+
+	return repo.GetById(c, id)
+}
+
+func (repo *TaskRepository) Restore(c context.Context, id string) (*domain.Task, error) {
+	update := bson.M{"$unset": bson.M{"deletedAt": ""}, "$inc": bson.M{"version": 1}}
+	result, err := repo.database.Collection(repo.collection).UpdateOne(c, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.MatchedCount == 0 {
+		return nil, domain.ErrTaskNotFound
+	}
+
+	return repo.GetById(c, id)
+}
+
+func (repo *TaskRepository) Purge(c context.Context, trashedBefore time.Time) ([]*domain.Task, error) {
+	collection := repo.database.Collection(repo.collection)
+	filter := bson.M{"deletedAt": bson.M{"$lt": trashedBefore}}
+
+	// Each task is removed by its own FindOneAndDelete, so a task restored in
+	// between stays and one purged by another instance is not returned twice
+	tasks := []*domain.Task{}
+	for {
+		var task domain.Task
+		err := collection.FindOneAndDelete(c, filter).Decode(&task)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return tasks, nil
+		}
+		if err != nil {
+			return tasks, err
+		}
+		tasks = append(tasks, &task)
+	}
+}
+
+// taskError reports a missing task as domain.ErrTaskNotFound and passes every
+// other driver error through.
+func taskError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.ErrTaskNotFound
+	}
+	return err
+}
+
+// versionFilter matches the live task with the given id, and only at the
+// given version unless that is 0.
+func versionFilter(id string, version int64) bson.M {
+	filter := bson.M{"_id": id, "deletedAt": nil}
+	if version != 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// versionChanged tells whether a conditional write matched nothing because
+// the live task is at another version rather than missing.
+func (repo *TaskRepository) versionChanged(c context.Context, id string, version int64) bool {
+	if version == 0 {
+		return false
+	}
+	err := repo.database.Collection(repo.collection).FindOne(c, bson.M{"_id": id, "deletedAt": nil, "version": bson.M{"$ne": version}}).Err()
+	return err == nil
+}
+
+func taskFilter(query *domain.TaskQuery) bson.M {
+	filter := bson.M{"deletedAt": nil}
+	if query.Trashed {
+		filter["deletedAt"] = bson.M{"$ne": nil}
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	if query.AssigneeId != "" {
+		filter["assigneeIds"] = query.AssigneeId
+	}
+	if query.VisibleTo != "" {
+		filter["$or"] = bson.A{
+			bson.M{"createdBy": query.VisibleTo},
+			bson.M{"assigneeIds": query.VisibleTo},
+		}
+	}
+	if query.Title != "" {
+		filter["title"] = bson.M{"$regex": regexp.QuoteMeta(query.Title), "$options": "i"}
+	}
+
+	dueDate := bson.M{}
+	if !query.DueBefore.IsZero() {
+		dueDate["$lt"] = query.DueBefore
+	}
+	if !query.DueAfter.IsZero() {
+		dueDate["$gt"] = query.DueAfter
+	}
+	if len(dueDate) > 0 {
+		filter["dueDate"] = dueDate
+	}
+	return filter
+}
+
+func taskSortField(sortBy string) string {
+	switch sortBy {
+	case domain.TaskSortTitle, domain.TaskSortStatus, domain.TaskSortDueDate:
+		return sortBy
+	}
+	return "_id"
+}
+
+func taskSortValue(task *domain.Task, sortBy string) interface{} {
+	switch sortBy {
+	case domain.TaskSortTitle:
+		return task.Title
+	case domain.TaskSortStatus:
+		return task.Status
+	case domain.TaskSortDueDate:
+		return task.DueDate
+	}
+	return task.Id
+}
@@ -3,6 +3,7 @@ package repository_test
 import (
     "context"
     "os"
+    "sync"
     "testing"
     "time"
 
@@ -75,7 +76,7 @@ func (suite *TaskRepositoryTestSuite) TestUpdate_Success() {
     suite.repo.Create(ctx, task)
 
     updateTask := &domain.Task{Title: "Updated Task Title", Description: "Updated Description", Status: "Completed", DueDate: time.Now()}
-    updatedTask, err := suite.repo.Update(ctx, task.Id, updateTask)
+    updatedTask, err := suite.repo.Update(ctx, task.Id, updateTask, []string{domain.TaskFieldTitle, domain.TaskFieldDescription, domain.TaskFieldStatus, domain.TaskFieldDueDate})
     suite.NoError(err)
     suite.Equal(updateTask.Title, updatedTask.Title)
 
@@ -85,12 +86,30 @@ func (suite *TaskRepositoryTestSuite) TestUpdate_Success() {
     suite.Equal(updateTask.Title, result.Title)
 }
 
+func (suite *TaskRepositoryTestSuite) TestUpdate_DueDateOnly() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Title: "Test Task", Status: "Pending", DueDate: time.Now().Truncate(time.Millisecond)}
+    suite.repo.Create(ctx, task)
+
+    dueDate := task.DueDate.Add(24 * time.Hour)
+    updatedTask, err := suite.repo.Update(ctx, task.Id, &domain.Task{DueDate: dueDate}, []string{domain.TaskFieldDueDate})
+    suite.NoError(err)
+    suite.True(dueDate.Equal(updatedTask.DueDate))
+
+    // the due date is stored where every read looks for it
+    count, err := suite.database.Collection(suite.collection).CountDocuments(ctx, bson.M{"due_date": bson.M{"$exists": true}})
+    suite.NoError(err)
+    suite.Zero(count)
+}
+
 func (suite *TaskRepositoryTestSuite) TestUpdate_Failure() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     // Attempting to update a non-existent task
-    _, err := suite.repo.Update(ctx, "nonExistentId", &domain.Task{Title: "New Title"})
+    _, err := suite.repo.Update(ctx, "nonExistentId", &domain.Task{Title: "New Title"}, []string{domain.TaskFieldTitle})
     suite.Error(err)
 }
 
@@ -102,11 +121,26 @@ func (suite *TaskRepositoryTestSuite) TestUpdate_NoFieldsUpdated() {
     suite.repo.Create(ctx, task)
 
     updateTask := &domain.Task{Title: "Test Task", Description: "Description of test task"}
-    _, err := suite.repo.Update(ctx, task.Id, updateTask)
+    _, err := suite.repo.Update(ctx, task.Id, updateTask, []string{domain.TaskFieldTitle, domain.TaskFieldDescription})
     suite.Error(err)
     suite.Equal("task not updated, no new information is provided", err.Error())
 }
 
+func (suite *TaskRepositoryTestSuite) TestUpdate_ClearsMaskedFields() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Title: "Test Task", Description: "Description of test task", Status: "Pending", DueDate: time.Now()}
+    suite.repo.Create(ctx, task)
+
+    updatedTask, err := suite.repo.Update(ctx, task.Id, &domain.Task{}, []string{domain.TaskFieldDescription, domain.TaskFieldDueDate})
+    suite.NoError(err)
+    suite.Empty(updatedTask.Description)
+    suite.True(updatedTask.DueDate.IsZero())
+    suite.Equal("Test Task", updatedTask.Title)
+    suite.Equal("Pending", updatedTask.Status)
+}
+
 func (suite *TaskRepositoryTestSuite) TestDelete_Success() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
@@ -114,12 +148,17 @@ func (suite *TaskRepositoryTestSuite) TestDelete_Success() {
     task := &domain.Task{Title: "Test Task", Description: "Description of test task", Status: "Pending", DueDate: time.Now()}
     suite.repo.Create(ctx, task)
 
-    err := suite.repo.Delete(ctx, task.Id)
+    err := suite.repo.Delete(ctx, task.Id, 0)
     suite.NoError(err)
 
+    // the task is only moved to the trash
+    _, err = suite.repo.GetById(ctx, task.Id)
+    suite.ErrorIs(err, domain.ErrTaskNotFound)
+
     var result domain.Task
     err = suite.database.Collection(suite.collection).FindOne(ctx, bson.M{"_id": task.Id}).Decode(&result)
-    suite.Error(err)
+    suite.NoError(err)
+    suite.NotNil(result.DeletedAt)
 }
 
 func (suite *TaskRepositoryTestSuite) TestDelete_Failure() {
@@ -127,7 +166,7 @@ func (suite *TaskRepositoryTestSuite) TestDelete_Failure() {
     defer cancel()
 
     // Attempting to delete a non-existent task
-    err := suite.repo.Delete(ctx, "nonExistentId")
+    err := suite.repo.Delete(ctx, "nonExistentId", 0)
     suite.Error(err)
 }
 
@@ -140,18 +179,46 @@ func (suite *TaskRepositoryTestSuite) TestGetAll_Success() {
     suite.repo.Create(ctx, task1)
     suite.repo.Create(ctx, task2)
 
-    tasks, err := suite.repo.GetAll(ctx)
+    page, err := suite.repo.GetAll(ctx, &domain.TaskQuery{})
+    suite.NoError(err)
+    suite.Len(page.Items, 2)
+    suite.Equal(int64(2), page.Total)
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetAll_FilterSortAndPaginate() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    now := time.Now().UTC().Truncate(time.Millisecond)
+    suite.repo.Create(ctx, &domain.Task{Title: "Write report", Status: "Pending", DueDate: now.Add(3 * time.Hour)})
+    suite.repo.Create(ctx, &domain.Task{Title: "Review report", Status: "Pending", DueDate: now.Add(1 * time.Hour)})
+    suite.repo.Create(ctx, &domain.Task{Title: "Report bug", Status: "Pending", DueDate: now.Add(2 * time.Hour)})
+    suite.repo.Create(ctx, &domain.Task{Title: "Ship release", Status: "Completed", DueDate: now.Add(4 * time.Hour)})
+
+    query := &domain.TaskQuery{Status: "Pending", Title: "REPORT", SortBy: domain.TaskSortDueDate, Limit: 2}
+    page, err := suite.repo.GetAll(ctx, query)
+    suite.NoError(err)
+    suite.Equal(int64(3), page.Total)
+    suite.Len(page.Items, 2)
+    suite.Equal("Review report", page.Items[0].Title)
+    suite.Equal("Report bug", page.Items[1].Title)
+    suite.NotEmpty(page.NextCursor)
+
+    query.Cursor = page.NextCursor
+    page, err = suite.repo.GetAll(ctx, query)
     suite.NoError(err)
-    suite.Len(*tasks, 2)
+    suite.Len(page.Items, 1)
+    suite.Equal("Write report", page.Items[0].Title)
+    suite.Empty(page.NextCursor)
 }
 
 func (suite *TaskRepositoryTestSuite) TestGetAll_NoTasks() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    tasks, err := suite.repo.GetAll(ctx)
+    page, err := suite.repo.GetAll(ctx, &domain.TaskQuery{})
     suite.NoError(err)
-    suite.Len(*tasks, 0)
+    suite.Len(page.Items, 0)
 }
 
 func (suite *TaskRepositoryTestSuite) TestGetById_Success() {
@@ -175,6 +242,79 @@ func (suite *TaskRepositoryTestSuite) TestGetById_Failure() {
     suite.Error(err)
 }
 
+func (suite *TaskRepositoryTestSuite) TestUpdateAssignees_Success() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Title: "Test Task", Status: "Pending", CreatedBy: "admin", AssigneeIDs: []string{}}
+    suite.repo.Create(ctx, task)
+
+    updated, err := suite.repo.UpdateAssignees(ctx, task.Id, []string{"u1"}, 0)
+    suite.NoError(err)
+    suite.Equal([]string{"u1"}, updated.AssigneeIDs)
+
+    page, err := suite.repo.GetAll(ctx, &domain.TaskQuery{VisibleTo: "u1"})
+    suite.NoError(err)
+    suite.Len(page.Items, 1)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdateAssignees_NotFound() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := suite.repo.UpdateAssignees(ctx, "nonExistentId", []string{"u1"}, 0)
+    suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositoryTestSuite) TestUpdateAssignees_VersionMismatch() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Title: "Test Task", Status: "Pending", CreatedBy: "admin", AssigneeIDs: []string{}}
+    suite.repo.Create(ctx, task)
+
+    _, err := suite.repo.UpdateAssignees(ctx, task.Id, []string{"u1"}, task.Version+1)
+    suite.ErrorIs(err, domain.ErrVersionMismatch)
+
+    updated, err := suite.repo.UpdateAssignees(ctx, task.Id, []string{"u1"}, task.Version)
+    suite.NoError(err)
+    suite.Equal(task.Version+1, updated.Version)
+}
+
+func (suite *TaskRepositoryTestSuite) TestPurge_Concurrent() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    for i := 0; i < 20; i++ {
+        task := &domain.Task{Title: "Test Task", Status: "Pending", CreatedBy: "admin"}
+        suite.repo.Create(ctx, task)
+        suite.NoError(suite.repo.Delete(ctx, task.Id, 0))
+    }
+
+    // two instances purging at once must remove every task exactly once
+    var wg sync.WaitGroup
+    results := make([][]*domain.Task, 2)
+    for i := range results {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            purged, err := suite.repo.Purge(ctx, time.Now().Add(time.Minute))
+            suite.NoError(err)
+            results[i] = purged
+        }(i)
+    }
+    wg.Wait()
+
+    seen := map[string]bool{}
+    for _, purged := range results {
+        for _, task := range purged {
+            suite.False(seen[task.Id], "task %s purged twice", task.Id)
+            seen[task.Id] = true
+        }
+    }
+    suite.Len(seen, 20)
+}
+
 func TestTaskRepositoryTestSuite(t *testing.T) {
     suite.Run(t, new(TaskRepositoryTestSuite))
 }
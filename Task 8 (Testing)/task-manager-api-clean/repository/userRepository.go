@@ -3,12 +3,16 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"task-manager-api-clean/utils"
 	"task-manager-api-clean/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 )
 
@@ -24,12 +28,95 @@ func NewUserRepository(db *mongo.Database, collection string) domain.UserReposit
 		}
 }
 
+// The unique indexes of the users collection. A duplicate key error names
+// the index it ran into, which tells a taken username from a taken address.
+const (
+	usernameIndex = "username_unique"
+	emailIndex    = "email_unique"
+
+	// maxDuplicates caps how many duplicates CreateUserIndexes reports.
+	maxDuplicates = 20
+)
+
+// CreateUserIndexes makes usernames and email addresses unique in the
+// collection, so two concurrent registrations cannot both take one. Accounts
+// without an address are left out of the email index. Users stored before
+// the indexes existed may share a username or address; rather than failing
+// on the first of them, it names them all so they can be sorted out by hand.
+func CreateUserIndexes(c context.Context, db *mongo.Database, collection string) error {
+	nonEmptyEmail := bson.M{"email": bson.M{"$gt": ""}}
+	var problems []string
+	for _, field := range []struct {
+		name   string
+		filter bson.M
+	}{{"username", bson.M{}}, {"email", nonEmptyEmail}} {
+		duplicates, err := findDuplicates(c, db.Collection(collection), field.name, field.filter)
+		if err != nil {
+			return err
+		}
+		if len(duplicates) > 0 {
+			problems = append(problems, fmt.Sprintf("%s used by more than one user: %s", field.name, strings.Join(duplicates, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s; rename the extra users before starting again", strings.Join(problems, "; "))
+	}
+
+	_, err := db.Collection(collection).Indexes().CreateMany(c, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetName(usernameIndex).SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName(emailIndex).SetUnique(true).SetPartialFilterExpression(nonEmptyEmail),
+		},
+	})
+	return err
+}
+
+// DropUserIndexes undoes CreateUserIndexes.
+func DropUserIndexes(c context.Context, db *mongo.Database, collection string) error {
+	for _, name := range []string{usernameIndex, emailIndex} {
+		if _, err := db.Collection(collection).Indexes().DropOne(c, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDuplicates returns up to maxDuplicates values of the field that more
+// than one document matching the filter has.
+func findDuplicates(c context.Context, collection *mongo.Collection, field string, filter bson.M) ([]string, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: maxDuplicates}},
+	}
+	cursor, err := collection.Aggregate(c, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(c)
+
+	var groups []struct {
+		Value interface{} `bson:"_id"`
+	}
+	if err := cursor.All(c, &groups); err != nil {
+		return nil, err
+	}
+	duplicates := make([]string, 0, len(groups))
+	for _, group := range groups {
+		duplicates = append(duplicates, fmt.Sprintf("%q", fmt.Sprint(group.Value)))
+	}
+	return duplicates, nil
+}
+
 func (ur *UserRepository) Create(c context.Context, user *domain.User) (*domain.User, error) {
-	// Check if this is the first user in the database
-	users, _ := ur.database.Collection(ur.collection).CountDocuments(context.Background(), bson.M{})
-	if users == 0 {
-		user.Role = "admin" // Automatically make the first user an admin
-	} else {
+	// the caller decides the role, a user without one is an ordinary user
+	if user.Role == "" {
 		user.Role = "user"
 	}
 
@@ -43,7 +130,7 @@ func (ur *UserRepository) Create(c context.Context, user *domain.User) (*domain.
 
 	_, err = ur.database.Collection(ur.collection).InsertOne(c, user)
 	if err != nil {
-		return nil, err
+		return nil, userError(err)
 	}
 	return user, nil
 }
@@ -51,42 +138,282 @@ func (ur *UserRepository) Create(c context.Context, user *domain.User) (*domain.
 func (ur *UserRepository) GetByUsername(c context.Context, username string) (*domain.User, error) {
 	var user domain.User
 	if err := ur.database.Collection(ur.collection).FindOne(c, bson.M{"username": username}).Decode(&user); err != nil {
-		return nil, errors.New("user with the given username not")
+		return nil, userError(err)
+	}
+	return &user, nil
+}
+
+func (ur *UserRepository) GetById(c context.Context, userID string) (*domain.User, error) {
+	var user domain.User
+	if err := ur.database.Collection(ur.collection).FindOne(c, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return nil, userError(err)
 	}
 	return &user, nil
 }
 
+func (ur *UserRepository) GetByEmail(c context.Context, email string) (*domain.User, error) {
+	var user domain.User
+	if err := ur.database.Collection(ur.collection).FindOne(c, bson.M{"email": email}).Decode(&user); err != nil {
+		return nil, userError(err)
+	}
+	return &user, nil
+}
 
-func (ur *UserRepository) UpdateRole(c context.Context, username string, role string) (*domain.User, error) {
-	filter := bson.M{"username": username}
+func (ur *UserRepository) IncrementTokenVersion(c context.Context, userID string) error {
+	update := bson.M{"$inc": bson.M{"tokenVersion": 1}}
+	result, err := ur.database.Collection(ur.collection).UpdateOne(c, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (ur *UserRepository) RecordFailedLogin(c context.Context, userID string) (int, error) {
+	// a single atomic update, so concurrent failures are all counted
+	update := bson.M{"$inc": bson.M{"failedLogins": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"failedLogins": 1})
 
 	var user domain.User
-	err := ur.database.Collection(ur.collection).FindOne(c, filter).Decode(&user)
+	err := ur.database.Collection(ur.collection).FindOneAndUpdate(c, bson.M{"_id": userID}, update, opts).Decode(&user)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return 0, userError(err)
 	}
+	return user.FailedLogins, nil
+}
 
-	
-    if user.Role == "admin" {
-        return nil, errors.New("user is already an admin")
-    }
+func (ur *UserRepository) LockUntil(c context.Context, userID string, until time.Time) error {
+	update := bson.M{"$set": bson.M{"lockedUntil": until}}
+	result, err := ur.database.Collection(ur.collection).UpdateOne(c, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
 
+func (ur *UserRepository) ResetFailedLogins(c context.Context, userID string) error {
+	update := bson.M{
+		"$set":   bson.M{"failedLogins": 0},
+		"$unset": bson.M{"lockedUntil": ""},
+	}
+	result, err := ur.database.Collection(ur.collection).UpdateOne(c, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
 
+func (ur *UserRepository) UpdateRole(c context.Context, username string, role string) (*domain.User, error) {
+	before, after, err := ur.changeAccess(c, bson.M{"username": username}, &domain.UserUpdate{Role: &role})
+	if err != nil {
+		return nil, err
+	}
+	if role == "admin" && before.Role == "admin" {
+		return nil, domain.ErrUserAlreadyAdmin
+	}
+	return after, nil
+}
+
+func (ur *UserRepository) UpdatePassword(c context.Context, userID string, password string) error {
+	hashedPassword, err := utils.EncryptPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// bumping the token version revokes every token issued with the old password
 	update := bson.M{
-		"$set": bson.M{
-			"role": role,
-		},
+		"$set": bson.M{"password": hashedPassword},
+		"$inc": bson.M{"tokenVersion": 1},
 	}
+	result, err := ur.database.Collection(ur.collection).UpdateOne(c, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
 
-	_, err = ur.database.Collection(ur.collection).UpdateOne(c, filter, update)
+func (ur *UserRepository) MarkEmailVerified(c context.Context, userID string, email string) (bool, error) {
+	// matching on the address too keeps a stale link from verifying a new one
+	filter := bson.M{"_id": userID, "email": email}
+	result, err := ur.database.Collection(ur.collection).UpdateOne(c, filter, bson.M{"$set": bson.M{"emailVerified": true}})
 	if err != nil {
-		return nil, errors.New("failed to update user role")
+		return false, err
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
 	}
 
-	updatedUser, err := ur.GetByUsername(c, username)
+	if _, err := ur.GetById(c, userID); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (ur *UserRepository) List(c context.Context, query *domain.UserQuery) ([]*domain.User, string, error) {
+	filter := bson.M{}
+	if query.Role != "" {
+		filter["role"] = query.Role
+	}
+	// user ids are object ids, so they sort in the order users registered
+	if query.Cursor != "" {
+		filter["_id"] = bson.M{"$gt": query.Cursor}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit) + 1)
+	}
+
+	cursor, err := ur.database.Collection(ur.collection).Find(c, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	defer cursor.Close(c)
+
+	users := []*domain.User{}
+	if err := cursor.All(c, &users); err != nil {
+		return nil, "", err
+	}
+
+	if query.Limit > 0 && len(users) > query.Limit {
+		users = users[:query.Limit]
+		return users, users[query.Limit-1].UserID, nil
 	}
+	return users, "", nil
+}
 
-	return updatedUser, nil
+func (ur *UserRepository) UpdateProfile(c context.Context, userID string, username string, email string) (*domain.User, error) {
+	// Compared with the stored values in the same step as the write, so a
+	// concurrent change cannot slip between reading the user and updating it
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"username": bson.M{"$literal": username},
+		"email":    bson.M{"$literal": email},
+		// access tokens carry the username, so the old ones have to go
+		"tokenVersion": bson.M{"$cond": bson.A{
+			bson.M{"$ne": bson.A{"$username", bson.M{"$literal": username}}},
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$tokenVersion", 0}}, 1}},
+			bson.M{"$ifNull": bson.A{"$tokenVersion", 0}},
+		}},
+		"emailVerified": bson.M{"$and": bson.A{
+			bson.M{"$eq": bson.A{"$email", bson.M{"$literal": email}}},
+			bson.M{"$ifNull": bson.A{"$emailVerified", false}},
+		}},
+	}}}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated domain.User
+	err := ur.database.Collection(ur.collection).FindOneAndUpdate(c, bson.M{"_id": userID}, update, opts).Decode(&updated)
+	if err != nil {
+		return nil, userError(err)
+	}
+	return &updated, nil
+}
+
+func (ur *UserRepository) UpdateAccess(c context.Context, userID string, change *domain.UserUpdate) (*domain.User, error) {
+	_, after, err := ur.changeAccess(c, bson.M{"_id": userID}, change)
+	return after, err
+}
+
+// changeAccess applies change to the user matched by filter and returns the
+// user as it was before and after. A user who stops being an active admin is
+// changed in a transaction with the check that another active admin is left,
+// so a refused change writes nothing, token version included.
+func (ur *UserRepository) changeAccess(c context.Context, filter bson.M, change *domain.UserUpdate) (*domain.User, *domain.User, error) {
+	session, err := ur.database.Client().StartSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer session.EndSession(c)
+
+	users := ur.database.Collection(ur.collection)
+	var before, after domain.User
+	_, err = session.WithTransaction(c, func(sc mongo.SessionContext) (interface{}, error) {
+		// a retried transaction starts over
+		before, after = domain.User{}, domain.User{}
+		if err := users.FindOneAndUpdate(sc, filter, accessUpdate(change)).Decode(&before); err != nil {
+			return nil, userError(err)
+		}
+		if err := users.FindOne(sc, bson.M{"_id": before.UserID}).Decode(&after); err != nil {
+			return nil, err
+		}
+		if isActiveAdmin(&before) && !isActiveAdmin(&after) {
+			return nil, ur.claimOtherAdmin(sc, before.UserID)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &before, &after, nil
+}
+
+// accessUpdate sets the role and deactivation of change, bumping the token
+// version only when the role changes or an active user is deactivated, so
+// setting what is already there revokes nothing.
+func accessUpdate(change *domain.UserUpdate) mongo.Pipeline {
+	set := bson.M{}
+	revoking := bson.A{}
+	if change.Role != nil {
+		set["role"] = bson.M{"$literal": *change.Role}
+		revoking = append(revoking, bson.M{"$ne": bson.A{"$role", bson.M{"$literal": *change.Role}}})
+	}
+	if change.Active != nil {
+		set["deactivated"] = !*change.Active
+		if !*change.Active {
+			revoking = append(revoking, bson.M{"$ne": bson.A{"$deactivated", true}})
+		}
+	}
+	if len(revoking) > 0 {
+		set["tokenVersion"] = bson.M{"$cond": bson.A{
+			bson.M{"$or": revoking},
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$tokenVersion", 0}}, 1}},
+			bson.M{"$ifNull": bson.A{"$tokenVersion", 0}},
+		}}
+	}
+	return mongo.Pipeline{{{Key: "$set", Value: set}}}
+}
+
+// claimOtherAdmin fails with ErrLastAdmin unless an active admin other than
+// the user is left. It writes to that admin, so a transaction demoting or
+// deactivating them at the same time conflicts with this one and is retried
+// after it, instead of the two together leaving no admin.
+func (ur *UserRepository) claimOtherAdmin(sc mongo.SessionContext, userID string) error {
+	filter := bson.M{"_id": bson.M{"$ne": userID}, "role": "admin", "deactivated": bson.M{"$ne": true}}
+	update := bson.M{"$currentDate": bson.M{"adminCheckedAt": true}}
+	err := ur.database.Collection(ur.collection).FindOneAndUpdate(sc, filter, update).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.ErrLastAdmin
+	}
+	return err
+}
+
+func isActiveAdmin(user *domain.User) bool {
+	return user.Role == "admin" && !user.Deactivated
+}
+
+// userError reports a missing user as domain.ErrUserNotFound, a username or
+// address taken by another user as the matching conflict, and passes every
+// other driver error through.
+func userError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.ErrUserNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		if strings.Contains(err.Error(), emailIndex) {
+			return domain.ErrEmailTaken
+		}
+		return domain.ErrUserExists
+	}
+	return err
 }
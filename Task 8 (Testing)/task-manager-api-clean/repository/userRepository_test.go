@@ -2,7 +2,9 @@ package repository_test
 
 import (
     "context"
+    "errors"
     "os"
+    "sync"
     "testing"
     "time"
 
@@ -49,6 +51,7 @@ func (suite *UserRepositoryTestSuite) SetupTest() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
     suite.database.Collection(suite.collection).Drop(ctx)
+    suite.NoError(repository.CreateUserIndexes(ctx, suite.database, suite.collection))
 }
 
 func (suite *UserRepositoryTestSuite) TestCreate_Success() {
@@ -68,6 +71,57 @@ func (suite *UserRepositoryTestSuite) TestCreate_Success() {
 }
 
 
+func (suite *UserRepositoryTestSuite) TestCreate_Duplicate() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    created, err := suite.repo.Create(ctx, &domain.User{Username: "taken", Password: "taken", Email: "taken@example.com"})
+    suite.NoError(err)
+    suite.Equal("user", created.Role)
+
+    _, err = suite.repo.Create(ctx, &domain.User{Username: "taken", Password: "other", Email: "other@example.com"})
+    suite.ErrorIs(err, domain.ErrUserExists)
+    _, err = suite.repo.Create(ctx, &domain.User{Username: "other", Password: "other", Email: "taken@example.com"})
+    suite.ErrorIs(err, domain.ErrEmailTaken)
+
+    // accounts without an address do not clash
+    _, err = suite.repo.Create(ctx, &domain.User{Username: "noemail1", Password: "noemail1"})
+    suite.NoError(err)
+    _, err = suite.repo.Create(ctx, &domain.User{Username: "noemail2", Password: "noemail2"})
+    suite.NoError(err)
+
+    other, err := suite.repo.Create(ctx, &domain.User{Username: "other", Password: "other", Email: "other@example.com"})
+    suite.NoError(err)
+    _, err = suite.repo.UpdateProfile(ctx, other.UserID, "taken", "other@example.com")
+    suite.ErrorIs(err, domain.ErrUserExists)
+    _, err = suite.repo.UpdateProfile(ctx, other.UserID, "other", "taken@example.com")
+    suite.ErrorIs(err, domain.ErrEmailTaken)
+}
+
+func (suite *UserRepositoryTestSuite) TestCreateUserIndexes_ReportsDuplicates() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // users stored before the indexes existed
+    collection := suite.database.Collection("users_duplicates")
+    defer collection.Drop(ctx)
+    collection.InsertMany(ctx, []interface{}{
+        bson.M{"_id": "1", "username": "twin", "email": "one@example.com"},
+        bson.M{"_id": "2", "username": "twin", "email": "shared@example.com"},
+        bson.M{"_id": "3", "username": "other", "email": "shared@example.com"},
+        bson.M{"_id": "4", "username": "noemail1", "email": ""},
+        bson.M{"_id": "5", "username": "noemail2", "email": ""},
+    })
+
+    err := repository.CreateUserIndexes(ctx, suite.database, "users_duplicates")
+    suite.ErrorContains(err, `username used by more than one user: "twin"`)
+    suite.ErrorContains(err, `email used by more than one user: "shared@example.com"`)
+    suite.NotContains(err.Error(), `""`)
+
+    collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": []string{"2", "3"}}})
+    suite.NoError(repository.CreateUserIndexes(ctx, suite.database, "users_duplicates"))
+}
+
 func (suite *UserRepositoryTestSuite) TestGetByUsername_Success() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
@@ -125,6 +179,20 @@ func (suite *UserRepositoryTestSuite) TestUpdateRole_negative() {
     suite.EqualError(err, "user is already an admin")
 }
 
+func (suite *UserRepositoryTestSuite) TestUpdateRole_SameRole() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    created, err := suite.repo.Create(ctx, &domain.User{Username: "test5b", Password: "test5b", Email: "test5b@example.com", Role: "manager"})
+    suite.Require().NoError(err)
+
+    // the user keeps their sessions
+    updatedUser, err := suite.repo.UpdateRole(ctx, "test5b", "manager")
+    suite.NoError(err)
+    suite.Equal("manager", updatedUser.Role)
+    suite.Equal(created.TokenVersion, updatedUser.TokenVersion)
+}
+
 func (suite *UserRepositoryTestSuite) TestUpdateRole_Failure() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
@@ -133,6 +201,174 @@ func (suite *UserRepositoryTestSuite) TestUpdateRole_Failure() {
     suite.Error(err)
 }
 
+func (suite *UserRepositoryTestSuite) TestFailedLogins() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    created, err := suite.repo.Create(ctx, &domain.User{Username: "test8", Password: "test8", Email: "test8@example.com"})
+    suite.NoError(err)
+
+    for want := 1; want <= 3; want++ {
+        failures, err := suite.repo.RecordFailedLogin(ctx, created.UserID)
+        suite.NoError(err)
+        suite.Equal(want, failures)
+    }
+    until := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+    suite.NoError(suite.repo.LockUntil(ctx, created.UserID, until))
+
+    locked, err := suite.repo.GetByUsername(ctx, "test8")
+    suite.NoError(err)
+    suite.Equal(3, locked.FailedLogins)
+    suite.True(until.Equal(*locked.LockedUntil))
+
+    suite.NoError(suite.repo.ResetFailedLogins(ctx, created.UserID))
+    unlocked, err := suite.repo.GetByUsername(ctx, "test8")
+    suite.NoError(err)
+    suite.Equal(0, unlocked.FailedLogins)
+    suite.Nil(unlocked.LockedUntil)
+
+    _, err = suite.repo.RecordFailedLogin(ctx, "nonExistentId")
+    suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestMarkEmailVerified() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    created, err := suite.repo.Create(ctx, &domain.User{Username: "test10", Password: "test10", Email: "test10@example.com"})
+    suite.NoError(err)
+    suite.False(created.EmailVerified)
+
+    marked, err := suite.repo.MarkEmailVerified(ctx, created.UserID, "old10@example.com")
+    suite.NoError(err)
+    suite.False(marked)
+
+    marked, err = suite.repo.MarkEmailVerified(ctx, created.UserID, "test10@example.com")
+    suite.NoError(err)
+    suite.True(marked)
+    verified, err := suite.repo.GetById(ctx, created.UserID)
+    suite.NoError(err)
+    suite.True(verified.EmailVerified)
+
+    _, err = suite.repo.MarkEmailVerified(ctx, "nonExistentId", "test10@example.com")
+    suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserRepositoryTestSuite) TestLastAdmin() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // other tests leave admins behind, so this one works on its own collection
+    repo := repository.NewUserRepository(suite.database, "users_last_admin")
+    defer suite.database.Collection("users_last_admin").Drop(ctx)
+
+    admin, err := repo.Create(ctx, &domain.User{Username: "admin13", Password: "admin13", Email: "admin13@example.com", Role: "admin"})
+    suite.NoError(err)
+    user, err := repo.Create(ctx, &domain.User{Username: "user13", Password: "user13", Email: "user13@example.com"})
+    suite.NoError(err)
+
+    _, err = repo.UpdateRole(ctx, "admin13", "user")
+    suite.ErrorIs(err, domain.ErrLastAdmin)
+    inactive, manager := false, "manager"
+    _, err = repo.UpdateAccess(ctx, admin.UserID, &domain.UserUpdate{Role: &manager, Active: &inactive})
+    suite.ErrorIs(err, domain.ErrLastAdmin)
+    kept, err := repo.GetById(ctx, admin.UserID)
+    suite.NoError(err)
+    suite.Equal("admin", kept.Role)
+    suite.False(kept.Deactivated)
+    // the refused changes leave the admin's tokens working
+    suite.Equal(admin.TokenVersion, kept.TokenVersion)
+
+    _, err = repo.UpdateRole(ctx, "admin13", "admin")
+    suite.ErrorIs(err, domain.ErrUserAlreadyAdmin)
+
+    _, err = repo.UpdateRole(ctx, "user13", "admin")
+    suite.NoError(err)
+    demoted, err := repo.UpdateRole(ctx, "admin13", "user")
+    suite.NoError(err)
+    suite.Equal("user", demoted.Role)
+
+    deactivated, err := repo.UpdateAccess(ctx, admin.UserID, &domain.UserUpdate{Active: &inactive})
+    suite.NoError(err)
+    suite.True(deactivated.Deactivated)
+    suite.Equal(kept.TokenVersion+2, deactivated.TokenVersion)
+
+    users, next, err := repo.List(ctx, &domain.UserQuery{Limit: 1})
+    suite.NoError(err)
+    suite.Equal("admin13", users[0].Username)
+    users, next, err = repo.List(ctx, &domain.UserQuery{Limit: 1, Cursor: next})
+    suite.NoError(err)
+    suite.Equal(user.UserID, users[0].UserID)
+    suite.Empty(next)
+}
+
+func (suite *UserRepositoryTestSuite) TestLastAdmin_Concurrent() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    repo := repository.NewUserRepository(suite.database, "users_last_admin_concurrent")
+    defer suite.database.Collection("users_last_admin_concurrent").Drop(ctx)
+
+    usernames := []string{"admin15", "admin16"}
+    for _, username := range usernames {
+        _, err := repo.Create(ctx, &domain.User{Username: username, Password: username, Email: username + "@example.com", Role: "admin"})
+        suite.Require().NoError(err)
+    }
+
+    // two admins demoting each other at the same time leave one of them
+    var wg sync.WaitGroup
+    errs := make([]error, len(usernames))
+    for i, username := range usernames {
+        wg.Add(1)
+        go func(i int, username string) {
+            defer wg.Done()
+            _, errs[i] = repo.UpdateRole(ctx, username, "user")
+        }(i, username)
+    }
+    wg.Wait()
+
+    refused := 0
+    for i, username := range usernames {
+        stored, err := repo.GetByUsername(ctx, username)
+        suite.Require().NoError(err)
+        if errors.Is(errs[i], domain.ErrLastAdmin) {
+            refused++
+            suite.Equal("admin", stored.Role)
+            suite.Equal(0, stored.TokenVersion)
+        } else {
+            suite.NoError(errs[i])
+            suite.Equal("user", stored.Role)
+        }
+    }
+    suite.Equal(1, refused)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdateProfile() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    created, err := suite.repo.Create(ctx, &domain.User{Username: "test14", Password: "test14", Email: "test14@example.com"})
+    suite.NoError(err)
+    suite.repo.MarkEmailVerified(ctx, created.UserID, "test14@example.com")
+
+    renamed, err := suite.repo.UpdateProfile(ctx, created.UserID, "renamed14", "new14@example.com")
+    suite.NoError(err)
+    suite.Equal("renamed14", renamed.Username)
+    suite.Equal("new14@example.com", renamed.Email)
+    suite.False(renamed.EmailVerified)
+    suite.Equal(created.TokenVersion+1, renamed.TokenVersion)
+
+    // keeping the username and address keeps the sessions and the verification
+    suite.repo.MarkEmailVerified(ctx, created.UserID, "new14@example.com")
+    kept, err := suite.repo.UpdateProfile(ctx, created.UserID, "renamed14", "new14@example.com")
+    suite.NoError(err)
+    suite.True(kept.EmailVerified)
+    suite.Equal(renamed.TokenVersion, kept.TokenVersion)
+
+    _, err = suite.repo.UpdateProfile(ctx, "nonExistentId", "x", "x@example.com")
+    suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
 func TestUserRepositoryTestSuite(t *testing.T) {
     suite.Run(t, new(UserRepositoryTestSuite))
 }
\ No newline at end of file
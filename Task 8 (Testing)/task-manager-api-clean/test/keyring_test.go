@@ -0,0 +1,150 @@
+package tests
+
+import (
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "task-manager-api-clean/domain"
+    "task-manager-api-clean/utils"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "github.com/stretchr/testify/suite"
+)
+
+type KeyRingTestSuite struct {
+    suite.Suite
+    dir string
+}
+
+func (suite *KeyRingTestSuite) SetupTest() {
+    suite.dir = suite.T().TempDir()
+}
+
+// writeKey stores a PKCS#8 private key as PEM and returns the file path.
+func (suite *KeyRingTestSuite) writeKey(name string, key interface{}) string {
+    der, err := x509.MarshalPKCS8PrivateKey(key)
+    require.NoError(suite.T(), err)
+    file := filepath.Join(suite.dir, name)
+    require.NoError(suite.T(), os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+    return file
+}
+
+func (suite *KeyRingTestSuite) rsaKey(name string) string {
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    require.NoError(suite.T(), err)
+    return suite.writeKey(name, key)
+}
+
+func (suite *KeyRingTestSuite) edKey(name string) string {
+    _, key, err := ed25519.GenerateKey(rand.Reader)
+    require.NoError(suite.T(), err)
+    return suite.writeKey(name, key)
+}
+
+func (suite *KeyRingTestSuite) issue(keys *utils.KeyRing) string {
+    token, err := utils.TokenGenerate(&domain.AuthenticatedUser{UserID: "123", Username: "testuser", Role: "user"}, keys, time.Hour)
+    require.NoError(suite.T(), err)
+    return token
+}
+
+func (suite *KeyRingTestSuite) verify(keys *utils.KeyRing, token string) error {
+    _, err := jwt.Parse(token, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+    return err
+}
+
+func (suite *KeyRingTestSuite) TestSignAndVerify() {
+    for _, file := range []string{suite.rsaKey("rsa.pem"), suite.edKey("ed.pem")} {
+        keys, err := utils.LoadKeyRing("", file, nil)
+        require.NoError(suite.T(), err)
+
+        token := suite.issue(keys)
+        parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+        require.NoError(suite.T(), err)
+        assert.Equal(suite.T(), keys.JWKS().Keys[0].Kid, parsed.Header["kid"])
+        assert.NoError(suite.T(), suite.verify(keys, token))
+    }
+}
+
+func (suite *KeyRingTestSuite) TestRotation() {
+    oldKey, newKey := suite.rsaKey("old.pem"), suite.edKey("new.pem")
+    oldRing, err := utils.LoadKeyRing("", oldKey, nil)
+    require.NoError(suite.T(), err)
+    token := suite.issue(oldRing)
+
+    rotated, err := utils.LoadKeyRing("", newKey, []string{oldKey})
+    require.NoError(suite.T(), err)
+    assert.NoError(suite.T(), suite.verify(rotated, token), "tokens of the previous key stay valid")
+
+    retired, err := utils.LoadKeyRing("", newKey, nil)
+    require.NoError(suite.T(), err)
+    assert.Error(suite.T(), suite.verify(retired, token), "tokens of a removed key are rejected")
+}
+
+func (suite *KeyRingTestSuite) TestSecretKeptForVerification() {
+    token := suite.issue(utils.NewHMACKeyRing("secret"))
+
+    keys, err := utils.LoadKeyRing("secret", suite.rsaKey("rsa.pem"), nil)
+    require.NoError(suite.T(), err)
+    assert.NoError(suite.T(), suite.verify(keys, token))
+
+    legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "123"})
+    legacyToken, err := legacy.SignedString([]byte("secret"))
+    require.NoError(suite.T(), err)
+    assert.NoError(suite.T(), suite.verify(keys, legacyToken), "tokens without a kid use the secret")
+}
+
+func (suite *KeyRingTestSuite) TestAlgorithmMismatchRejected() {
+    keys, err := utils.LoadKeyRing("", suite.rsaKey("rsa.pem"), nil)
+    require.NoError(suite.T(), err)
+    kid := keys.JWKS().Keys[0].Kid
+
+    forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "123"})
+    forged.Header["kid"] = kid
+    forgedToken, err := forged.SignedString([]byte("anything"))
+    require.NoError(suite.T(), err)
+    assert.Error(suite.T(), suite.verify(keys, forgedToken))
+}
+
+func (suite *KeyRingTestSuite) TestJWKS() {
+    rsaFile, edFile := suite.rsaKey("rsa.pem"), suite.edKey("ed.pem")
+    keys, err := utils.LoadKeyRing("secret", rsaFile, []string{edFile})
+    require.NoError(suite.T(), err)
+
+    set := keys.JWKS()
+    require.Len(suite.T(), set.Keys, 2, "the shared secret is never published")
+    assert.Equal(suite.T(), "RSA", set.Keys[0].Kty)
+    assert.Equal(suite.T(), "RS256", set.Keys[0].Alg)
+    assert.Equal(suite.T(), "AQAB", set.Keys[0].E)
+    assert.Equal(suite.T(), "OKP", set.Keys[1].Kty)
+    assert.Equal(suite.T(), "Ed25519", set.Keys[1].Crv)
+
+    again, err := utils.LoadKeyRing("", rsaFile, nil)
+    require.NoError(suite.T(), err)
+    assert.Equal(suite.T(), set.Keys[0].Kid, again.JWKS().Keys[0].Kid, "key ids are stable")
+}
+
+func (suite *KeyRingTestSuite) TestLoadKeyRing_Errors() {
+    _, err := utils.LoadKeyRing("", "", nil)
+    assert.Error(suite.T(), err)
+
+    _, err = utils.LoadKeyRing("", filepath.Join(suite.dir, "missing.pem"), nil)
+    assert.Error(suite.T(), err)
+
+    garbage := filepath.Join(suite.dir, "garbage.pem")
+    require.NoError(suite.T(), os.WriteFile(garbage, []byte("not a key"), 0600))
+    _, err = utils.LoadKeyRing("", garbage, nil)
+    assert.Error(suite.T(), err)
+}
+
+func TestKeyRingTestSuite(t *testing.T) {
+    suite.Run(t, new(KeyRingTestSuite))
+}
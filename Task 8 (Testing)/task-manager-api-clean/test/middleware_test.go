@@ -90,14 +90,28 @@
 package tests
 
 import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
     "net/http"
     "net/http/httptest"
+    "strings"
     "testing"
+    "time"
     "task-manager-api-clean/api/middleware"
+    "task-manager-api-clean/domain"
+    "task-manager-api-clean/domain/mocks"
+    "task-manager-api-clean/metrics"
+    "task-manager-api-clean/repository/memory"
+    "task-manager-api-clean/utils"
 
     "github.com/gin-gonic/gin"
     "github.com/golang-jwt/jwt/v5"
+    "github.com/prometheus/client_golang/prometheus/testutil"
     "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
     "github.com/stretchr/testify/suite"
 )
 
@@ -108,7 +122,7 @@ type MiddlewareTestSuite struct {
 
 func (suite *MiddlewareTestSuite) SetupTest() {
     suite.router = gin.New()
-    suite.router.Use(middleware.AuthMiddleware("secret"))
+    suite.router.Use(middleware.AuthMiddleware(utils.NewHMACKeyRing("secret"), nil))
     suite.router.GET("/test", func(c *gin.Context) {
         c.Status(http.StatusOK)
     })
@@ -228,6 +242,359 @@ func (suite *MiddlewareTestSuite) TestAuthMiddleware_ValidToken() {
     assert.Equal(suite.T(), http.StatusOK, w.Code)
 }
 
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_TokenVersionCurrent() {
+    users := new(mocks.UserRepository)
+    users.On("GetById", mock.Anything, "123").Return(&domain.User{UserID: "123", TokenVersion: 2}, nil)
+    router := gin.New()
+    router.Use(middleware.AuthMiddleware(utils.NewHMACKeyRing("secret"), users))
+    router.GET("/test", func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "user_id":  "123",
+        "username": "testuser",
+        "role":     "admin",
+        "ver":      2,
+    })
+    tokenString, err := token.SignedString([]byte("secret"))
+    suite.NoError(err)
+
+    req, _ := http.NewRequest("GET", "/test", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_TokenVersionRevoked() {
+    users := new(mocks.UserRepository)
+    users.On("GetById", mock.Anything, "123").Return(&domain.User{UserID: "123", TokenVersion: 3}, nil)
+    router := gin.New()
+    router.Use(middleware.AuthMiddleware(utils.NewHMACKeyRing("secret"), users))
+    router.GET("/test", func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "user_id":  "123",
+        "username": "testuser",
+        "role":     "user",
+        "ver":      2,
+    })
+    tokenString, err := token.SignedString([]byte("secret"))
+    suite.NoError(err)
+
+    req, _ := http.NewRequest("GET", "/test", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+    assert.Contains(suite.T(), w.Body.String(), "Token has been revoked")
+}
+
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_DeactivatedUser() {
+    users := new(mocks.UserRepository)
+    users.On("GetById", mock.Anything, "123").Return(&domain.User{UserID: "123", TokenVersion: 2, Deactivated: true}, nil)
+    router := gin.New()
+    router.Use(middleware.AuthMiddleware(utils.NewHMACKeyRing("secret"), users))
+    router.GET("/test", func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "user_id":  "123",
+        "username": "testuser",
+        "role":     "user",
+        "ver":      2,
+    })
+    tokenString, err := token.SignedString([]byte("secret"))
+    suite.NoError(err)
+
+    req, _ := http.NewRequest("GET", "/test", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+    assert.Contains(suite.T(), w.Body.String(), "Account has been deactivated")
+}
+
+func (suite *MiddlewareTestSuite) TestAuthMiddleware_UserLookupFails() {
+    users := new(mocks.UserRepository)
+    users.On("GetById", mock.Anything, "123").Return(nil, errors.New("connection refused"))
+    router := gin.New()
+    router.Use(middleware.ErrorHandler())
+    router.Use(middleware.AuthMiddleware(utils.NewHMACKeyRing("secret"), users))
+    router.GET("/test", func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "user_id":  "123",
+        "username": "testuser",
+        "role":     "user",
+        "ver":      2,
+    })
+    tokenString, err := token.SignedString([]byte("secret"))
+    suite.NoError(err)
+
+    req, _ := http.NewRequest("GET", "/test", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    // an unavailable store must not look like a revoked token
+    assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+    assert.NotContains(suite.T(), w.Body.String(), "connection refused")
+}
+
+func (suite *MiddlewareTestSuite) TestErrorHandler_MapsErrorKinds() {
+    cases := []struct {
+        err    error
+        status int
+        body   string
+    }{
+        {domain.ErrTaskNotFound, http.StatusNotFound, "task not found"},
+        {domain.ErrUserExists, http.StatusConflict, "user already exists"},
+        {domain.ErrTaskForbidden, http.StatusForbidden, domain.ErrTaskForbidden.Error()},
+        {domain.ErrInvalidRefreshToken, http.StatusUnauthorized, "invalid or expired refresh token"},
+        {fmt.Errorf("%w: cannot sort by \"x\"", domain.ErrInvalidTaskQuery), http.StatusBadRequest, "cannot sort by"},
+        {&domain.TransitionError{From: "Completed", To: "Pending"}, http.StatusConflict, "final status"},
+        {errors.New("connection reset by peer"), http.StatusInternalServerError, "internal server error"},
+    }
+
+    for _, tc := range cases {
+        router := gin.New()
+        router.Use(middleware.ErrorHandler())
+        router.GET("/test", func(c *gin.Context) {
+            c.Error(tc.err)
+        })
+
+        req, _ := http.NewRequest("GET", "/test", nil)
+        w := httptest.NewRecorder()
+        router.ServeHTTP(w, req)
+
+        assert.Equal(suite.T(), tc.status, w.Code, tc.err.Error())
+        assert.Contains(suite.T(), w.Body.String(), tc.body)
+        assert.NotContains(suite.T(), w.Body.String(), "connection reset")
+    }
+}
+
+func (suite *MiddlewareTestSuite) TestErrorHandler_LeavesWrittenResponses() {
+    router := gin.New()
+    router.Use(middleware.ErrorHandler())
+    router.GET("/test", func(c *gin.Context) {
+        c.Error(domain.ErrTaskNotFound)
+        c.JSON(http.StatusAccepted, gin.H{"message": "handled"})
+    })
+
+    req, _ := http.NewRequest("GET", "/test", nil)
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+    assert.JSONEq(suite.T(), `{"message":"handled"}`, w.Body.String())
+}
+
+func (suite *MiddlewareTestSuite) TestErrorHandler_ListsInvalidFields() {
+    router := gin.New()
+    router.Use(middleware.ErrorHandler())
+    router.POST("/test", func(c *gin.Context) {
+        invalid := &domain.ValidationError{}
+        invalid.Add("title", domain.CodeRequired, "title must not be blank")
+        c.Error(fmt.Errorf("creating task: %w", invalid))
+    })
+
+    req, _ := http.NewRequest("POST", "/test", nil)
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+    assert.JSONEq(suite.T(), `{"errors":[{"field":"title","code":"required","message":"title must not be blank"}]}`, w.Body.String())
+}
+
+// loggedRouter serves /tasks/:id behind the request logger and the auth
+// middleware, logging JSON into the returned buffer.
+func (suite *MiddlewareTestSuite) loggedRouter(handler gin.HandlerFunc) (*gin.Engine, *bytes.Buffer) {
+    logs := &bytes.Buffer{}
+    router := gin.New()
+    router.ContextWithFallback = true
+    router.Use(middleware.RequestLogger(slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+    router.GET("/tasks/:id", middleware.AuthMiddleware(utils.NewHMACKeyRing("secret"), nil), handler)
+    return router, logs
+}
+
+func (suite *MiddlewareTestSuite) TestRequestLogger_LogsRequest() {
+    router, logs := suite.loggedRouter(func(c *gin.Context) {
+        // Anything given the gin context logs with the request's attributes
+        utils.Logger(c).Info("loading task")
+        c.Status(http.StatusNoContent)
+    })
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "123", "username": "testuser", "role": "admin"})
+    tokenString, err := token.SignedString([]byte("secret"))
+    suite.NoError(err)
+
+    req, _ := http.NewRequest("GET", "/tasks/42", nil)
+    req.Header.Set("Authorization", "Bearer "+tokenString)
+    req.Header.Set(middleware.RequestIDHeader, "req-123")
+    w := httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+
+    assert.Equal(suite.T(), "req-123", w.Header().Get(middleware.RequestIDHeader))
+
+    lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+    suite.Require().Len(lines, 2)
+    var handlerLog, requestLog map[string]interface{}
+    suite.Require().NoError(json.Unmarshal([]byte(lines[0]), &handlerLog))
+    suite.Require().NoError(json.Unmarshal([]byte(lines[1]), &requestLog))
+
+    assert.Equal(suite.T(), "loading task", handlerLog["msg"])
+    assert.Equal(suite.T(), "req-123", handlerLog["request_id"])
+    assert.Equal(suite.T(), "testuser", handlerLog["username"])
+
+    assert.Equal(suite.T(), "request served", requestLog["msg"])
+    assert.Equal(suite.T(), "INFO", requestLog["level"])
+    assert.Equal(suite.T(), "req-123", requestLog["request_id"])
+    assert.Equal(suite.T(), "GET", requestLog["method"])
+    assert.Equal(suite.T(), "/tasks/:id", requestLog["route"])
+    assert.Equal(suite.T(), "/tasks/42", requestLog["path"])
+    assert.Equal(suite.T(), float64(http.StatusNoContent), requestLog["status"])
+    assert.Equal(suite.T(), "testuser", requestLog["username"])
+    assert.Contains(suite.T(), requestLog, "latency_ms")
+}
+
+func (suite *MiddlewareTestSuite) TestRequestLogger_GeneratesRequestID() {
+    router, logs := suite.loggedRouter(func(c *gin.Context) {})
+
+    for _, given := range []string{"", "bad id\nwith a newline"} {
+        logs.Reset()
+        req, _ := http.NewRequest("GET", "/tasks/42", nil)
+        if given != "" {
+            req.Header.Set(middleware.RequestIDHeader, given)
+        }
+        w := httptest.NewRecorder()
+        router.ServeHTTP(w, req)
+
+        requestID := w.Header().Get(middleware.RequestIDHeader)
+        assert.Regexp(suite.T(), "^[0-9a-f]{32}$", requestID)
+
+        var requestLog map[string]interface{}
+        suite.Require().NoError(json.Unmarshal(logs.Bytes(), &requestLog))
+        assert.Equal(suite.T(), requestID, requestLog["request_id"])
+        // Rejected by the auth middleware
+        assert.Equal(suite.T(), "WARN", requestLog["level"])
+        assert.NotContains(suite.T(), requestLog, "username")
+    }
+}
+
+func (suite *MiddlewareTestSuite) TestRequestMetrics_LabelsByRoute() {
+    m := metrics.New()
+    router := gin.New()
+    router.Use(middleware.RequestMetrics(m))
+    router.GET("/tasks/:id", func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    for _, path := range []string{"/tasks/1", "/tasks/2", "/wp-login.php"} {
+        req, _ := http.NewRequest("GET", path, nil)
+        router.ServeHTTP(httptest.NewRecorder(), req)
+    }
+    req, _ := http.NewRequest("BREW", "/tasks/1", nil)
+    router.ServeHTTP(httptest.NewRecorder(), req)
+
+    assert.Equal(suite.T(), 2.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("GET", "/tasks/:id", "200")))
+    assert.Equal(suite.T(), 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("GET", "unmatched", "404")))
+    assert.Equal(suite.T(), 1.0, testutil.ToFloat64(m.HTTPRequests.WithLabelValues("other", "unmatched", "404")))
+    assert.Equal(suite.T(), 3, testutil.CollectAndCount(m.HTTPRequestDuration))
+}
+
+func (suite *MiddlewareTestSuite) TestRateLimit_PerUsername() {
+    router := gin.New()
+    router.Use(middleware.ErrorHandler())
+    router.POST("/login", middleware.RateLimit(memory.NewRateLimiter(2, time.Minute), middleware.LoginUsername), func(c *gin.Context) {
+        var login domain.UserLogin
+        if err := c.ShouldBindJSON(&login); err != nil {
+            c.Status(http.StatusBadRequest)
+            return
+        }
+        c.String(http.StatusOK, login.Username)
+    })
+
+    login := func(username string) *httptest.ResponseRecorder {
+        req, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"username":"`+username+`","password":"guess"}`))
+        w := httptest.NewRecorder()
+        router.ServeHTTP(w, req)
+        return w
+    }
+
+    for i := 0; i < 2; i++ {
+        w := login("alice")
+        assert.Equal(suite.T(), http.StatusOK, w.Code)
+        // The handler still gets the body the limiter read
+        assert.Equal(suite.T(), "alice", w.Body.String())
+    }
+
+    w := login("alice")
+    assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+    assert.Equal(suite.T(), "30", w.Header().Get("Retry-After"))
+    assert.JSONEq(suite.T(), `{"error":"too many requests, try again later"}`, w.Body.String())
+
+    assert.Equal(suite.T(), http.StatusOK, login("bob").Code)
+
+    // A padded body is not read whole, and the handler cannot use it either
+    padded := `{"username":"alice","password":"guess","padding":"` + strings.Repeat("x", 64<<10) + `"}`
+    req, _ := http.NewRequest("POST", "/login", strings.NewReader(padded))
+    w = httptest.NewRecorder()
+    router.ServeHTTP(w, req)
+    assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *MiddlewareTestSuite) TestRateLimit_PerVerificationEmail() {
+    router := gin.New()
+    router.Use(middleware.ErrorHandler())
+    router.POST("/auth/verify/resend", middleware.RateLimit(memory.NewRateLimiter(1, time.Hour), middleware.VerificationEmail), func(c *gin.Context) {
+        c.Status(http.StatusAccepted)
+    })
+
+    resend := func(body string) int {
+        req, _ := http.NewRequest("POST", "/auth/verify/resend", strings.NewReader(body))
+        w := httptest.NewRecorder()
+        router.ServeHTTP(w, req)
+        return w.Code
+    }
+
+    assert.Equal(suite.T(), http.StatusAccepted, resend(`{"email":"alice@example.com"}`))
+    // Addresses differing only in case share a bucket
+    assert.Equal(suite.T(), http.StatusTooManyRequests, resend(`{"email":"Alice@Example.com"}`))
+    assert.Equal(suite.T(), http.StatusAccepted, resend(`{"email":"bob@example.com"}`))
+    // Bodies without an address are left for the handler to reject
+    assert.Equal(suite.T(), http.StatusAccepted, resend(`{"email":42}`))
+    assert.Equal(suite.T(), http.StatusAccepted, resend(`not json`))
+}
+
+func (suite *MiddlewareTestSuite) TestRateLimit_PerClientIP() {
+    router := gin.New()
+    router.Use(middleware.ErrorHandler())
+    router.POST("/login", middleware.RateLimit(memory.NewRateLimiter(1, time.Minute), middleware.ClientIP), func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    login := func(remoteAddr string) int {
+        req, _ := http.NewRequest("POST", "/login", nil)
+        req.RemoteAddr = remoteAddr
+        w := httptest.NewRecorder()
+        router.ServeHTTP(w, req)
+        return w.Code
+    }
+
+    assert.Equal(suite.T(), http.StatusOK, login("10.0.0.1:1234"))
+    assert.Equal(suite.T(), http.StatusTooManyRequests, login("10.0.0.1:5678"))
+    assert.Equal(suite.T(), http.StatusOK, login("10.0.0.2:1234"))
+}
+
 func TestMiddlewareTestSuite(t *testing.T) {
     suite.Run(t, new(MiddlewareTestSuite))
 }
\ No newline at end of file
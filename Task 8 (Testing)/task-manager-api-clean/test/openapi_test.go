@@ -0,0 +1,146 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "sort"
+    "strings"
+    "testing"
+    "time"
+
+    "task-manager-api-clean/api/openapi"
+    "task-manager-api-clean/api/router"
+    "task-manager-api-clean/config"
+    "task-manager-api-clean/domain"
+    "task-manager-api-clean/utils"
+
+    "github.com/gin-gonic/gin"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "github.com/stretchr/testify/suite"
+)
+
+type OpenAPITestSuite struct {
+    suite.Suite
+    engine *gin.Engine
+    cancel context.CancelFunc
+}
+
+type openAPIDocument struct {
+    OpenAPI string                                `json:"openapi"`
+    Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// pathParam matches gin path parameters such as :id.
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+func (suite *OpenAPITestSuite) SetupTest() {
+    gin.SetMode(gin.TestMode)
+    policy, err := domain.NewPolicy(nil)
+    require.NoError(suite.T(), err)
+    workflow, err := domain.NewWorkflow(nil, nil)
+    require.NoError(suite.T(), err)
+    env := &config.Environment{
+        StorageDriver:               config.StorageMemory,
+        HealthCheckTimeout:          time.Second,
+        TrashRetention:              time.Hour,
+        TrashPurgeInterval:          time.Hour,
+        LoginRateLimitIP:            config.RateLimit{Requests: 20, Per: time.Minute},
+        LoginRateLimitUsername:      config.RateLimit{Requests: 5, Per: time.Minute},
+        VerificationResendRateLimit: config.RateLimit{Requests: 3, Per: time.Hour},
+    }
+
+    var ctx context.Context
+    ctx, suite.cancel = context.WithCancel(context.Background())
+    suite.engine = gin.New()
+    require.NoError(suite.T(), router.Setup(ctx, env, utils.NewHMACKeyRing("secret"), policy, workflow, nil, suite.engine))
+}
+
+func (suite *OpenAPITestSuite) TearDownTest() {
+    suite.cancel()
+}
+
+func (suite *OpenAPITestSuite) document() *openAPIDocument {
+    spec, err := openapi.JSON()
+    require.NoError(suite.T(), err)
+
+    var document openAPIDocument
+    require.NoError(suite.T(), json.Unmarshal(spec, &document))
+    return &document
+}
+
+// operations lists "METHOD /path" for every route on the engine, written
+// the way OpenAPI writes paths.
+func (suite *OpenAPITestSuite) operations() []string {
+    var operations []string
+    for _, route := range suite.engine.Routes() {
+        operations = append(operations, route.Method+" "+pathParam.ReplaceAllString(route.Path, "{$1}"))
+    }
+    sort.Strings(operations)
+    return operations
+}
+
+func (suite *OpenAPITestSuite) TestSpec_DescribesEveryRoute() {
+    document := suite.document()
+
+    for _, operation := range suite.operations() {
+        method, path, _ := strings.Cut(operation, " ")
+        _, ok := document.Paths[path][strings.ToLower(method)]
+        assert.True(suite.T(), ok, "%s is registered in router.Setup but missing from api/openapi/openapi.yaml", operation)
+    }
+}
+
+func (suite *OpenAPITestSuite) TestSpec_DescribesNoOtherRoute() {
+    registered := make(map[string]bool)
+    for _, operation := range suite.operations() {
+        registered[operation] = true
+    }
+
+    for path, item := range suite.document().Paths {
+        for method := range item {
+            if method == "parameters" {
+                continue
+            }
+            operation := strings.ToUpper(method) + " " + path
+            assert.True(suite.T(), registered[operation], "%s is in api/openapi/openapi.yaml but not registered in router.Setup", operation)
+        }
+    }
+}
+
+func (suite *OpenAPITestSuite) TestServesSpecAndSwaggerUI() {
+    w := httptest.NewRecorder()
+    suite.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+    assert.Equal(suite.T(), "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+    var document openAPIDocument
+    require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &document))
+    assert.Equal(suite.T(), "3.0.3", document.OpenAPI)
+
+    w = httptest.NewRecorder()
+    suite.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+    assert.Equal(suite.T(), http.StatusOK, w.Code)
+    assert.Contains(suite.T(), w.Body.String(), `url: "/openapi.json"`)
+    // nothing is loaded from another origin
+    assert.NotRegexp(suite.T(), `(src|href)="(https?:)?//`, w.Body.String())
+
+    // the assets the page loads are served by the API itself
+    for _, asset := range regexp.MustCompile(`(?:src|href)="(/docs/[^"]+)"`).FindAllStringSubmatch(w.Body.String(), -1) {
+        w := httptest.NewRecorder()
+        suite.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, asset[1], nil))
+        assert.Equal(suite.T(), http.StatusOK, w.Code, asset[1])
+        assert.NotEmpty(suite.T(), w.Body.Bytes(), asset[1])
+    }
+
+    w = httptest.NewRecorder()
+    suite.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/missing.js", nil))
+    assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestOpenAPITestSuite(t *testing.T) {
+    suite.Run(t, new(OpenAPITestSuite))
+}
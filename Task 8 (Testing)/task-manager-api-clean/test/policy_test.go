@@ -0,0 +1,53 @@
+package tests
+
+import (
+    "testing"
+
+    "task-manager-api-clean/domain"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "github.com/stretchr/testify/suite"
+)
+
+type PolicyTestSuite struct {
+    suite.Suite
+}
+
+func (suite *PolicyTestSuite) TestDefaultRoles() {
+    policy, err := domain.NewPolicy(nil)
+    require.NoError(suite.T(), err)
+
+    for _, permission := range domain.AllPermissions {
+        assert.True(suite.T(), policy.Allows("admin", permission), permission)
+    }
+    assert.True(suite.T(), policy.Allows("user", domain.PermTaskUpdate))
+    assert.False(suite.T(), policy.Allows("user", domain.PermTaskCreate))
+    assert.True(suite.T(), policy.Allows("viewer", domain.PermTaskReadAny))
+    assert.False(suite.T(), policy.Allows("viewer", domain.PermTaskUpdate))
+    assert.False(suite.T(), policy.Allows("stranger", domain.PermTaskRead))
+}
+
+func (suite *PolicyTestSuite) TestConfiguredRoles() {
+    policy, err := domain.NewPolicy(map[string][]string{
+        "user":    {"task:read", "task:update", "task:create"},
+        "auditor": {"task:read:any"},
+        "root":    {"*"},
+    })
+    require.NoError(suite.T(), err)
+
+    assert.True(suite.T(), policy.Allows("user", domain.PermTaskCreate))
+    assert.True(suite.T(), policy.HasRole("auditor"))
+    assert.False(suite.T(), policy.Allows("auditor", domain.PermTaskDelete))
+    assert.True(suite.T(), policy.Allows("root", domain.PermUserPromote))
+    assert.True(suite.T(), policy.HasRole("manager"), "default roles are kept")
+}
+
+func (suite *PolicyTestSuite) TestUnknownPermission() {
+    _, err := domain.NewPolicy(map[string][]string{"user": {"task:fly"}})
+    assert.Error(suite.T(), err)
+}
+
+func TestPolicyTestSuite(t *testing.T) {
+    suite.Run(t, new(PolicyTestSuite))
+}
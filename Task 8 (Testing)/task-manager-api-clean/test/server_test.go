@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/api/server"
+	"task-manager-api-clean/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ServerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ServerTestSuite) TestNew_UsesConfiguredTimeouts() {
+	env := &config.Environment{BindAddress: "0.0.0.0", Port: "9000", ReadTimeout: 20 * time.Second, WriteTimeout: time.Minute, IdleTimeout: 2 * time.Minute}
+
+	srv := server.New(env, http.NotFoundHandler())
+
+	assert.Equal(suite.T(), "0.0.0.0:9000", srv.Addr)
+	assert.Equal(suite.T(), 20*time.Second, srv.ReadTimeout)
+	assert.Equal(suite.T(), 5*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(suite.T(), time.Minute, srv.WriteTimeout)
+	assert.Equal(suite.T(), 2*time.Minute, srv.IdleTimeout)
+}
+
+func (suite *ServerTestSuite) TestServe_DrainsRequestsInFlight() {
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(suite.T(), err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- server.Serve(ctx, srv, listener, server.Lifecycle{ShutdownTimeout: time.Second}) }()
+
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	assert.Equal(suite.T(), http.StatusNoContent, <-responses)
+	assert.NoError(suite.T(), <-stopped)
+
+	// New connections are refused once the server stopped
+	_, err = http.Get("http://" + listener.Addr().String())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ServerTestSuite) TestServe_ShutdownDeadline() {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(suite.T(), err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Serve(ctx, srv, listener, server.Lifecycle{ShutdownTimeout: 50 * time.Millisecond})
+	}()
+	go http.Get("http://" + listener.Addr().String())
+
+	<-started
+	cancel()
+
+	assert.ErrorIs(suite.T(), <-stopped, context.DeadlineExceeded)
+}
+
+func (suite *ServerTestSuite) TestServe_KeepsServingDuringShutdownDelay() {
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(suite.T(), err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Serve(ctx, srv, listener, server.Lifecycle{ShutdownDelay: 200 * time.Millisecond, ShutdownTimeout: time.Second})
+	}()
+	cancel()
+
+	resp, err := http.Get("http://" + listener.Addr().String())
+	require.NoError(suite.T(), err)
+	resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusNoContent, resp.StatusCode)
+	assert.NoError(suite.T(), <-stopped)
+}
+
+func (suite *ServerTestSuite) TestRun_FailsWhenAddressIsTaken() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(suite.T(), err)
+	defer listener.Close()
+
+	srv := &http.Server{Addr: listener.Addr().String(), Handler: http.NotFoundHandler()}
+
+	assert.Error(suite.T(), server.Run(context.Background(), srv, server.Lifecycle{ShutdownTimeout: time.Second}))
+}
+
+func TestServerTestSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
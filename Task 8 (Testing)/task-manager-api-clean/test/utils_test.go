@@ -4,6 +4,7 @@ import (
     "task-manager-api-clean/domain"
     "task-manager-api-clean/utils"
     "testing"
+    "time"
 
     "github.com/gin-gonic/gin"
     "github.com/stretchr/testify/assert"
@@ -77,11 +78,79 @@ func (suite *UtilsTestSuite) TestTokenGenerate() {
         Role:     "admin",
     }
 
-    token, err := utils.TokenGenerate(authUser, "secret")
+    token, err := utils.TokenGenerate(authUser, utils.NewHMACKeyRing("secret"), time.Hour)
     require.NoError(suite.T(), err, "Token generation should succeed")
     assert.NotEmpty(suite.T(), token, "Generated token should not be empty")
 }
 
+func (suite *UtilsTestSuite) TestGenerateToken_Unique() {
+    first, err := utils.GenerateToken()
+    require.NoError(suite.T(), err)
+    second, err := utils.GenerateToken()
+    require.NoError(suite.T(), err)
+    assert.NotEqual(suite.T(), first, second)
+}
+
+func (suite *UtilsTestSuite) TestHashToken() {
+    assert.Equal(suite.T(), utils.HashToken("token"), utils.HashToken("token"))
+    assert.NotEqual(suite.T(), utils.HashToken("token"), utils.HashToken("other"))
+    assert.NotContains(suite.T(), utils.HashToken("token"), "token")
+}
+
+func (suite *UtilsTestSuite) TestMergePatch() {
+    doc := []byte(`{"title": "Task", "description": "Details", "tags": {"a": 1, "b": 2}}`)
+
+    patched, err := utils.MergePatch(doc, []byte(`{"description": null, "tags": {"a": null, "c": 3}, "status": "Done"}`))
+    require.NoError(suite.T(), err)
+    assert.JSONEq(suite.T(), `{"title": "Task", "tags": {"b": 2, "c": 3}, "status": "Done"}`, string(patched))
+
+    // anything but an object replaces the whole document
+    patched, err = utils.MergePatch(doc, []byte(`["a"]`))
+    require.NoError(suite.T(), err)
+    assert.JSONEq(suite.T(), `["a"]`, string(patched))
+
+    _, err = utils.MergePatch(doc, []byte(`{"title": `))
+    assert.ErrorIs(suite.T(), err, domain.ErrInvalidPatch)
+}
+
+func (suite *UtilsTestSuite) TestJSONPatch() {
+    doc := []byte(`{"title": "Task", "ids": ["a", "b"], "a/b": {"~c": 1}}`)
+
+    patched, err := utils.JSONPatch(doc, []byte(`[
+        {"op": "test", "path": "/a~1b/~0c", "value": 1},
+        {"op": "add", "path": "/ids/1", "value": "x"},
+        {"op": "add", "path": "/ids/-", "value": "z"},
+        {"op": "remove", "path": "/ids/0"},
+        {"op": "replace", "path": "/title", "value": null},
+        {"op": "copy", "from": "/ids", "path": "/copied"},
+        {"op": "move", "from": "/a~1b", "path": "/moved"}
+    ]`))
+    require.NoError(suite.T(), err)
+    assert.JSONEq(suite.T(), `{"title": null, "ids": ["x", "b", "z"], "copied": ["x", "b", "z"], "moved": {"~c": 1}}`, string(patched))
+}
+
+func (suite *UtilsTestSuite) TestJSONPatch_Errors() {
+    doc := []byte(`{"title": "Task", "ids": ["a"]}`)
+
+    _, err := utils.JSONPatch(doc, []byte(`[{"op": "test", "path": "/title", "value": "Other"}]`))
+    assert.ErrorIs(suite.T(), err, domain.ErrPatchTestFailed)
+
+    for _, patch := range []string{
+        `{"op": "remove", "path": "/title"}`,
+        `[{"op": "remove", "path": "/missing"}]`,
+        `[{"op": "replace", "path": "/missing", "value": 1}]`,
+        `[{"op": "add", "path": "/ids/2", "value": 1}]`,
+        `[{"op": "add", "path": "/ids/01", "value": 1}]`,
+        `[{"op": "add", "path": "/title"}]`,
+        `[{"op": "move", "from": "/ids", "path": "/ids/0"}]`,
+        `[{"op": "add", "path": "title", "value": 1}]`,
+        `[{"op": "rename", "path": "/title"}]`,
+    } {
+        _, err := utils.JSONPatch(doc, []byte(patch))
+        assert.ErrorIs(suite.T(), err, domain.ErrInvalidPatch, patch)
+    }
+}
+
 func TestUtilsTestSuite(t *testing.T) {
     suite.Run(t, new(UtilsTestSuite))
 }
\ No newline at end of file
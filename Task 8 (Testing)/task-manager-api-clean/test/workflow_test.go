@@ -0,0 +1,62 @@
+package tests
+
+import (
+    "testing"
+
+    "task-manager-api-clean/domain"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "github.com/stretchr/testify/suite"
+)
+
+type WorkflowTestSuite struct {
+    suite.Suite
+}
+
+func (suite *WorkflowTestSuite) TestDefaultWorkflow() {
+    workflow, err := domain.NewWorkflow(nil, nil)
+    require.NoError(suite.T(), err)
+
+    assert.Equal(suite.T(), domain.StatusPending, workflow.Initial())
+    assert.NoError(suite.T(), workflow.CheckTransition(domain.StatusPending, domain.StatusInProgress))
+    assert.NoError(suite.T(), workflow.CheckTransition(domain.StatusInProgress, domain.StatusCompleted))
+    assert.NoError(suite.T(), workflow.CheckTransition(domain.StatusBlocked, domain.StatusCancelled))
+
+    var transitionErr *domain.TransitionError
+    require.ErrorAs(suite.T(), workflow.CheckTransition(domain.StatusPending, domain.StatusCompleted), &transitionErr)
+    assert.Equal(suite.T(), []string{domain.StatusInProgress, domain.StatusBlocked, domain.StatusCancelled}, transitionErr.Allowed)
+
+    require.ErrorAs(suite.T(), workflow.CheckTransition(domain.StatusCompleted, domain.StatusInProgress), &transitionErr)
+    assert.Contains(suite.T(), transitionErr.Error(), "final status")
+}
+
+func (suite *WorkflowTestSuite) TestUnknownStatus() {
+    workflow, err := domain.NewWorkflow(nil, nil)
+    require.NoError(suite.T(), err)
+
+    var invalid *domain.ValidationError
+    require.ErrorAs(suite.T(), workflow.CheckStatus("Someday"), &invalid)
+    assert.Equal(suite.T(), "status", invalid.Fields[0].Field)
+    assert.Equal(suite.T(), domain.CodeUnknownValue, invalid.Fields[0].Code)
+    assert.Contains(suite.T(), invalid.Error(), `unknown status "Someday"`)
+    require.ErrorAs(suite.T(), workflow.CheckTransition(domain.StatusPending, "Someday"), &invalid)
+
+    assert.NoError(suite.T(), workflow.CheckTransition("Done", domain.StatusPending), "statuses predating the workflow may move anywhere")
+}
+
+func (suite *WorkflowTestSuite) TestConfiguredWorkflow() {
+    workflow, err := domain.NewWorkflow([]string{"Todo", "Done"}, map[string][]string{"Todo": {"Done"}})
+    require.NoError(suite.T(), err)
+    assert.Equal(suite.T(), "Todo", workflow.Initial())
+    assert.NoError(suite.T(), workflow.CheckTransition("Todo", "Done"))
+    assert.Error(suite.T(), workflow.CheckTransition("Done", "Todo"))
+    assert.Error(suite.T(), workflow.CheckStatus(domain.StatusPending))
+
+    _, err = domain.NewWorkflow([]string{"Todo"}, map[string][]string{"Todo": {"Done"}})
+    assert.Error(suite.T(), err)
+}
+
+func TestWorkflowTestSuite(t *testing.T) {
+    suite.Run(t, new(WorkflowTestSuite))
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+)
+
+type HealthUseCase struct {
+	mu      sync.RWMutex
+	checks  []domain.HealthCheck
+	Timeout time.Duration
+}
+
+// NewHealthUseCase returns a HealthUseCase giving each check up to timeout
+// to answer.
+func NewHealthUseCase(timeout time.Duration, checks ...domain.HealthCheck) domain.HealthUseCase {
+	return &HealthUseCase{
+		checks:  checks,
+		Timeout: timeout,
+	}
+}
+
+// Register adds a check, for backends wired up after the use case.
+func (hu *HealthUseCase) Register(check domain.HealthCheck) {
+	hu.mu.Lock()
+	defer hu.mu.Unlock()
+	hu.checks = append(hu.checks, check)
+}
+
+// Check runs every check concurrently, so one slow dependency costs no more
+// than the timeout.
+func (hu *HealthUseCase) Check(c context.Context) *domain.HealthReport {
+	hu.mu.RLock()
+	checks := append([]domain.HealthCheck(nil), hu.checks...)
+	hu.mu.RUnlock()
+
+	results := make([]domain.DependencyHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check domain.HealthCheck) {
+			defer wg.Done()
+			results[i] = hu.run(c, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := &domain.HealthReport{Status: domain.HealthOK, Checks: make(map[string]domain.DependencyHealth, len(checks))}
+	for i, check := range checks {
+		report.Checks[check.Name()] = results[i]
+		if results[i].Status != domain.HealthOK {
+			report.Status = domain.HealthUnavailable
+		}
+	}
+	return report
+}
+
+func (hu *HealthUseCase) run(c context.Context, check domain.HealthCheck) domain.DependencyHealth {
+	ctx, cancel := context.WithTimeout(c, hu.Timeout)
+	defer cancel()
+
+	// A check that ignores its context still cannot hold up the report
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check.Check(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		utils.Logger(c).Warn("health check failed", "check", check.Name(), "latency", time.Since(start), "error", err)
+		return domain.DependencyHealth{Status: domain.HealthUnavailable}
+	}
+	return domain.DependencyHealth{Status: domain.HealthOK}
+}
@@ -0,0 +1,77 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/domain/mocks"
+	"task-manager-api-clean/usecase"
+	"task-manager-api-clean/utils"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthUseCaseTestSuite struct {
+	suite.Suite
+	database *mocks.HealthCheck
+	useCase  domain.HealthUseCase
+}
+
+func (suite *HealthUseCaseTestSuite) SetupTest() {
+	suite.database = new(mocks.HealthCheck)
+	suite.database.On("Name").Return("mongo")
+	suite.useCase = usecase.NewHealthUseCase(50*time.Millisecond, suite.database)
+}
+
+func (suite *HealthUseCaseTestSuite) TestCheck_AllHealthy() {
+	suite.database.On("Check", mock.Anything).Return(nil)
+
+	report := suite.useCase.Check(context.Background())
+
+	suite.Equal(domain.HealthOK, report.Status)
+	suite.Equal(domain.DependencyHealth{Status: domain.HealthOK}, report.Checks["mongo"])
+}
+
+func (suite *HealthUseCaseTestSuite) TestCheck_FailingDependency() {
+	suite.database.On("Check", mock.Anything).Return(errors.New("connection refused"))
+	suite.useCase.Register(domain.HealthCheckFunc("cache", func(c context.Context) error { return nil }))
+
+	var logged strings.Builder
+	ctx := utils.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logged, nil)))
+	report := suite.useCase.Check(ctx)
+
+	suite.Equal(domain.HealthUnavailable, report.Status)
+	suite.Equal(domain.DependencyHealth{Status: domain.HealthUnavailable}, report.Checks["mongo"])
+	suite.Equal(domain.HealthOK, report.Checks["cache"].Status)
+	// the reason stays on the server
+	suite.Contains(logged.String(), "check=mongo")
+	suite.Contains(logged.String(), `error="connection refused"`)
+}
+
+func (suite *HealthUseCaseTestSuite) TestCheck_TimesOutHangingCheck() {
+	release := make(chan struct{})
+	defer close(release)
+	suite.useCase.Register(domain.HealthCheckFunc("hanging", func(c context.Context) error {
+		<-release
+		return nil
+	}))
+	suite.database.On("Check", mock.Anything).Return(nil)
+
+	start := time.Now()
+	report := suite.useCase.Check(context.Background())
+
+	suite.Less(time.Since(start), time.Second)
+	suite.Equal(domain.HealthUnavailable, report.Status)
+	suite.Equal(domain.HealthUnavailable, report.Checks["hanging"].Status)
+	suite.Equal(domain.HealthOK, report.Checks["mongo"].Status)
+}
+
+func TestHealthUseCaseTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthUseCaseTestSuite))
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"task-manager-api-clean/domain"
+)
+
+// recordHistory stores what an action did to a task. The history is an audit
+// trail, so a change it misses must not look like a success: the error is
+// returned, and the caller learns that the change was made without a record
+// of it.
+func (tu *TaskUseCase) recordHistory(c context.Context, actor *domain.AuthenticatedUser, action string, before, after *domain.Task) error {
+	return recordTaskHistory(c, tu.HistoryRepository, actor, action, before, after)
+}
+
+func recordTaskHistory(c context.Context, history domain.HistoryRepository, actor *domain.AuthenticatedUser, action string, before, after *domain.Task) error {
+	taskId := ""
+	if after != nil {
+		taskId = after.Id
+	} else if before != nil {
+		taskId = before.Id
+	}
+
+	entry := &domain.TaskHistoryEntry{
+		TaskId:        taskId,
+		Action:        action,
+		ActorId:       actor.UserID,
+		ActorUsername: actor.Username,
+		Changes:       diffTasks(before, after),
+		At:            time.Now().UTC(),
+	}
+	if err := history.Record(c, entry); err != nil {
+		return fmt.Errorf("failed to record the %s of task %s in its history: %w", action, taskId, err)
+	}
+	return nil
+}
+
+// cloneTask copies a task deeply enough that changing the copy's fields,
+// assignees included, leaves the original alone.
+func cloneTask(task *domain.Task) *domain.Task {
+	cloned := *task
+	cloned.AssigneeIDs = slices.Clone(task.AssigneeIDs)
+	return &cloned
+}
+
+// diffTasks lists the fields that differ between two versions of a task.
+// A nil before describes a creation, a nil after a deletion.
+func diffTasks(before, after *domain.Task) []domain.FieldChange {
+	if before == nil {
+		before = &domain.Task{}
+	}
+	if after == nil {
+		after = &domain.Task{}
+	}
+
+	changes := []domain.FieldChange{}
+	addChange := func(field string, from, to interface{}, fromEmpty, toEmpty bool) {
+		if fromEmpty {
+			from = nil
+		}
+		if toEmpty {
+			to = nil
+		}
+		changes = append(changes, domain.FieldChange{Field: field, From: from, To: to})
+	}
+
+	if before.Title != after.Title {
+		addChange("title", before.Title, after.Title, before.Title == "", after.Title == "")
+	}
+	if before.Description != after.Description {
+		addChange("description", before.Description, after.Description, before.Description == "", after.Description == "")
+	}
+	if before.Status != after.Status {
+		addChange("status", before.Status, after.Status, before.Status == "", after.Status == "")
+	}
+	if !before.DueDate.Equal(after.DueDate) {
+		addChange("dueDate", before.DueDate, after.DueDate, before.DueDate.IsZero(), after.DueDate.IsZero())
+	}
+	if !slices.Equal(before.AssigneeIDs, after.AssigneeIDs) {
+		addChange("assigneeIds", before.AssigneeIDs, after.AssigneeIDs, len(before.AssigneeIDs) == 0, len(after.AssigneeIDs) == 0)
+	}
+	return changes
+}
+
+// normalizeHistoryQuery fills in the default page size and caps it, leaving
+// the caller's query untouched.
+func normalizeHistoryQuery(query *domain.HistoryQuery) *domain.HistoryQuery {
+	normalized := domain.HistoryQuery{}
+	if query != nil {
+		normalized = *query
+	}
+
+	if normalized.Limit <= 0 {
+		normalized.Limit = defaultTaskPageSize
+	} else if normalized.Limit > maxTaskPageSize {
+		normalized.Limit = maxTaskPageSize
+	}
+	return &normalized
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
+)
+
+// purgeActor is who the history shows as having purged a task.
+var purgeActor = &domain.AuthenticatedUser{UserID: "system", Username: "system"}
+
+// TaskPurger permanently removes tasks that have been in the trash for longer
+// than the retention period.
+type TaskPurger struct {
+	TaskRepository    domain.TaskRepository
+	HistoryRepository domain.HistoryRepository
+	Retention         time.Duration
+	Interval          time.Duration
+}
+
+func NewTaskPurger(tr domain.TaskRepository, hr domain.HistoryRepository, retention, interval time.Duration) *TaskPurger {
+	return &TaskPurger{
+		TaskRepository:    tr,
+		HistoryRepository: hr,
+		Retention:         retention,
+		Interval:          interval,
+	}
+}
+
+// Run purges the trash every Interval until the context is cancelled.
+func (tp *TaskPurger) Run(c context.Context) {
+	ticker := time.NewTicker(tp.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := tp.Purge(c); err != nil {
+			utils.Logger(c).Error("failed to purge the task trash", "error", err)
+		}
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the tasks trashed more than Retention ago and returns how
+// many it removed. Only the tasks this call removed are recorded in the
+// history, so instances purging side by side never record a task twice.
+// It fails if the removal stopped partway or any purge could not be
+// recorded.
+func (tp *TaskPurger) Purge(c context.Context) (int, error) {
+	purged, err := tp.TaskRepository.Purge(c, time.Now().UTC().Add(-tp.Retention))
+	if len(purged) > 0 {
+		utils.Logger(c).Info("purged the task trash", "tasks", len(purged))
+	}
+
+	// The tasks are gone either way, so every purge is recorded that can be
+	errs := []error{err}
+	for _, task := range purged {
+		errs = append(errs, recordTaskHistory(c, tp.HistoryRepository, purgeActor, domain.HistoryPurge, task, nil))
+	}
+	return len(purged), errors.Join(errs...)
+}
@@ -2,62 +2,521 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 	"task-manager-api-clean/domain"
+	"task-manager-api-clean/utils"
 	
 )
 
+const (
+	defaultTaskPageSize = 20
+	maxTaskPageSize     = 100
+)
+
+// taskJSONFields names every field of a task as the client reads it.
+var taskJSONFields = []string{
+	"id", "title", "description", "status", "dueDate", "createdBy", "assigneeIds",
+	"statusChangedBy", "statusChangedAt", "deletedAt", "version",
+}
+
 
 type TaskUseCase struct {
 	TaskRepository domain.TaskRepository
-
+	HistoryRepository domain.HistoryRepository
+	UserRepository domain.UserRepository
+	Policy *domain.Policy
+	Workflow *domain.Workflow
 }
 
-func NewTaskUseCase(tr domain.TaskRepository) domain.TaskUseCase {
+func NewTaskUseCase(tr domain.TaskRepository, hr domain.HistoryRepository, ur domain.UserRepository, policy *domain.Policy, workflow *domain.Workflow) domain.TaskUseCase {
 	return &TaskUseCase{
 		TaskRepository: tr,
+		HistoryRepository: hr,
+		UserRepository: ur,
+		Policy: policy,
+		Workflow: workflow,
 	}
 }
-func (tu *TaskUseCase) Create(c context.Context, payload *domain.TaskInput) (*domain.Task, error) {
+func (tu *TaskUseCase) Create(c context.Context, actor *domain.AuthenticatedUser, payload *domain.TaskInput) (*domain.Task, error) {
+	if !tu.Policy.Allows(actor.Role, domain.PermTaskCreate) {
+		return nil, domain.ErrTaskForbidden
+	}
+	if err := validateTaskInput(payload, true, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// New tasks start in the workflow's initial status unless told otherwise
+	status := payload.Status
+	if status == "" {
+		status = tu.Workflow.Initial()
+	} else if err := tu.Workflow.CheckStatus(status); err != nil {
+		return nil, err
+	}
+	if err := tu.checkAssignees(c, payload.AssigneeIDs); err != nil {
+		return nil, err
+	}
+
 	task := &domain.Task{
-		Title:       payload.Title,
-		Description: payload.Description,
-		Status:      payload.Status,
-		DueDate:     payload.DueDate,
+		Title:           strings.TrimSpace(payload.Title),
+		Description:     payload.Description,
+		Status:          status,
+		DueDate:         payload.DueDate,
+		CreatedBy:       actor.UserID,
+		AssigneeIDs:     uniqueIDs(payload.AssigneeIDs),
+		StatusChangedBy: actor.UserID,
+		StatusChangedAt: time.Now().UTC(),
 	}
-	
-	return tu.TaskRepository.Create(c, task)
+
+	created, err := tu.TaskRepository.Create(c, task)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.recordHistory(c, actor, domain.HistoryCreate, nil, created); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-func (tu *TaskUseCase) Update(c context.Context, taskId string, payload *domain.TaskInput) (*domain.Task, error) {
-	task, err := tu.TaskRepository.GetById(c, taskId)
+func (tu *TaskUseCase) Update(c context.Context, actor *domain.AuthenticatedUser, taskId string, payload *domain.TaskInput, version int64) (*domain.Task, error) {
+	if err := validateTaskInput(payload, false, time.Now()); err != nil {
+		return nil, err
+	}
+	task, err := tu.taskToChange(c, actor, taskId, version)
 	if err != nil {
 		return nil, err
 	}
 
+	// Empty fields of the payload leave the task's alone
+	next := cloneTask(task)
 	if payload.Title != "" {
-		task.Title = payload.Title
+		next.Title = strings.TrimSpace(payload.Title)
 	}
 	if payload.Description != "" {
-		task.Description = payload.Description
+		next.Description = payload.Description
 	}
 	if !payload.DueDate.IsZero() {
-		task.DueDate = payload.DueDate
+		next.DueDate = payload.DueDate
 	}
-	if task.Status != payload.Status {
-		task.Status = payload.Status
+	if payload.Status != "" {
+		next.Status = payload.Status
+	}
+	return tu.change(c, actor, task, next)
+}
+
+func (tu *TaskUseCase) Replace(c context.Context, actor *domain.AuthenticatedUser, taskId string, payload *domain.TaskInput, version int64) (*domain.Task, error) {
+	if err := validateTaskReplacement(payload, time.Now()); err != nil {
+		return nil, err
+	}
+	task, err := tu.taskToChange(c, actor, taskId, version)
+	if err != nil {
+		return nil, err
 	}
 
-	return tu.TaskRepository.Update(c, taskId, task)
+	// Assignees are not part of the replacement, they change through Assign
+	next := cloneTask(task)
+	next.Title = strings.TrimSpace(payload.Title)
+	next.Description = payload.Description
+	next.DueDate = payload.DueDate
+	next.Status = payload.Status
+	return tu.change(c, actor, task, next)
 }
 
-func (tu *TaskUseCase) Delete(c context.Context, taskId string) error {
-	return tu.TaskRepository.Delete(c, taskId)
+func (tu *TaskUseCase) Patch(c context.Context, actor *domain.AuthenticatedUser, taskId string, patch *domain.TaskPatch, version int64) (*domain.Task, error) {
+	task, err := tu.taskToChange(c, actor, taskId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// The patch applies to the task as the client reads it
+	doc, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var patched []byte
+	switch patch.MediaType {
+	case domain.MediaTypeMergePatch:
+		patched, err = utils.MergePatch(doc, patch.Document)
+	case domain.MediaTypeJSONPatch:
+		patched, err = utils.JSONPatch(doc, patch.Document)
+	default:
+		return nil, domain.ErrUnsupportedPatch
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := decodePatchedTask(task, patched)
+	if err != nil {
+		return nil, err
+	}
+	input := &domain.TaskInput{Title: next.Title, Description: next.Description, Status: next.Status, DueDate: next.DueDate}
+	if err := validateTaskReplacement(input, time.Now()); err != nil {
+		return nil, err
+	}
+	next.Title = strings.TrimSpace(next.Title)
+	return tu.change(c, actor, task, next)
+}
+
+func (tu *TaskUseCase) Delete(c context.Context, actor *domain.AuthenticatedUser, taskId string, version int64) error {
+	if !tu.Policy.Allows(actor.Role, domain.PermTaskDelete) {
+		return domain.ErrTaskForbidden
+	}
+	task, err := tu.taskToChange(c, actor, taskId, version)
+	if err != nil {
+		return err
+	}
+
+	if err := tu.TaskRepository.Delete(c, taskId, task.Version); err != nil {
+		return err
+	}
+	return tu.recordHistory(c, actor, domain.HistoryDelete, task, nil)
+}
+
+func (tu *TaskUseCase) Trash(c context.Context, actor *domain.AuthenticatedUser, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	normalized, err := normalizeTaskQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized.Trashed = true
+	if !tu.Policy.Allows(actor.Role, domain.PermTaskReadAny) {
+		normalized.VisibleTo = actor.UserID
+	}
+	return tu.TaskRepository.GetAll(c, normalized)
+}
+
+func (tu *TaskUseCase) Restore(c context.Context, actor *domain.AuthenticatedUser, taskId string) (*domain.Task, error) {
+	if !tu.Policy.Allows(actor.Role, domain.PermTaskDelete) {
+		return nil, domain.ErrTaskForbidden
+	}
+
+	// Trashed tasks stay hidden from those who could not see them before
+	task, err := tu.TaskRepository.GetTrashed(c, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if !tu.canSeeTask(actor, task) {
+		return nil, domain.ErrTaskNotFound
+	}
+
+	restored, err := tu.TaskRepository.Restore(c, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.recordHistory(c, actor, domain.HistoryRestore, nil, restored); err != nil {
+		return nil, err
+	}
+	return restored, nil
 }
 
-func (tu *TaskUseCase) GetAll(c context.Context) (*[]*domain.Task, error) {
-	return tu.TaskRepository.GetAll(c)
+func (tu *TaskUseCase) GetAll(c context.Context, actor *domain.AuthenticatedUser, query *domain.TaskQuery) (*domain.TaskPage, error) {
+	normalized, err := normalizeTaskQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tu.Policy.Allows(actor.Role, domain.PermTaskReadAny) {
+		normalized.VisibleTo = actor.UserID
+	}
+	return tu.TaskRepository.GetAll(c, normalized)
 }
 
-func (tu *TaskUseCase) GetById(c context.Context, taskId string) (*domain.Task, error) {
-	return tu.TaskRepository.GetById(c, taskId)
+func (tu *TaskUseCase) GetById(c context.Context, actor *domain.AuthenticatedUser, taskId string) (*domain.Task, error) {
+	task, err := tu.TaskRepository.GetById(c, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tasks a user has nothing to do with are reported as missing rather than forbidden
+	if !tu.canSeeTask(actor, task) {
+		return nil, domain.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+func (tu *TaskUseCase) Assign(c context.Context, actor *domain.AuthenticatedUser, taskId string, assigneeIDs []string, version int64) (*domain.Task, error) {
+	if !tu.Policy.Allows(actor.Role, domain.PermTaskAssign) {
+		return nil, domain.ErrTaskForbidden
+	}
+	before, err := tu.taskToChange(c, actor, taskId, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.checkAssignees(c, assigneeIDs); err != nil {
+		return nil, err
+	}
+
+	updated, err := tu.TaskRepository.UpdateAssignees(c, taskId, uniqueIDs(assigneeIDs), before.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.recordHistory(c, actor, domain.HistoryAssign, before, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (tu *TaskUseCase) Transition(c context.Context, actor *domain.AuthenticatedUser, taskId string, status string) (*domain.Task, error) {
+	task, err := tu.GetById(c, actor, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tu.canChangeStatus(actor, task) {
+		return nil, domain.ErrTaskForbidden
+	}
+	if err := tu.Workflow.CheckTransition(task.Status, status); err != nil {
+		return nil, err
+	}
+
+	updated, err := tu.TaskRepository.Update(c, taskId, &domain.Task{
+		Status:          status,
+		StatusChangedBy: actor.UserID,
+		StatusChangedAt: time.Now().UTC(),
+		Version:         task.Version,
+	}, []string{domain.TaskFieldStatus})
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.recordHistory(c, actor, domain.HistoryTransition, task, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (tu *TaskUseCase) History(c context.Context, actor *domain.AuthenticatedUser, taskId string, query *domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if _, err := tu.GetById(c, actor, taskId); err != nil {
+		return nil, err
+	}
+
+	normalized := normalizeHistoryQuery(query)
+	normalized.TaskId = taskId
+	return tu.HistoryRepository.List(c, normalized)
+}
+
+func (tu *TaskUseCase) Audit(c context.Context, actor *domain.AuthenticatedUser, query *domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if !tu.Policy.Allows(actor.Role, domain.PermAuditRead) {
+		return nil, domain.ErrTaskForbidden
+	}
+	return tu.HistoryRepository.List(c, normalizeHistoryQuery(query))
+}
+
+// taskToChange reads the task the actor is about to change, failing with
+// ErrVersionMismatch when it is no longer at the version they last saw.
+func (tu *TaskUseCase) taskToChange(c context.Context, actor *domain.AuthenticatedUser, taskId string, version int64) (*domain.Task, error) {
+	task, err := tu.GetById(c, actor, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != task.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+	return task, nil
+}
+
+// checkAssignees fails with a ValidationError naming every assignee id that
+// belongs to no user. Blank ids are skipped, as uniqueIDs drops them.
+func (tu *TaskUseCase) checkAssignees(c context.Context, assigneeIDs []string) error {
+	invalid := &domain.ValidationError{}
+	checked := map[string]bool{}
+	for i, id := range assigneeIDs {
+		if id == "" || checked[id] {
+			continue
+		}
+		checked[id] = true
+
+		_, err := tu.UserRepository.GetById(c, id)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			invalid.Add(fmt.Sprintf("assigneeIds[%d]", i), domain.CodeUnknownValue, fmt.Sprintf("no user has the id %q", id))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return invalid.Err()
+}
+
+// change writes the fields in which next differs from task, the version of
+// it the actor read, and records the change in the history.
+func (tu *TaskUseCase) change(c context.Context, actor *domain.AuthenticatedUser, task *domain.Task, next *domain.Task) (*domain.Task, error) {
+	fields := changedTaskFields(task, next)
+
+	// Without task:update:any a user may only move the status of a task assigned to them
+	if !tu.Policy.Allows(actor.Role, domain.PermTaskUpdateAny) {
+		if !tu.canChangeStatus(actor, task) {
+			return nil, domain.ErrTaskForbidden
+		}
+		if slices.ContainsFunc(fields, func(field string) bool { return field != domain.TaskFieldStatus }) {
+			return nil, domain.ErrTaskForbidden
+		}
+	}
+	if len(fields) == 0 {
+		return nil, domain.ErrTaskUnchanged
+	}
+
+	if slices.Contains(fields, domain.TaskFieldStatus) {
+		if err := tu.Workflow.CheckTransition(task.Status, next.Status); err != nil {
+			return nil, err
+		}
+		next.StatusChangedBy = actor.UserID
+		next.StatusChangedAt = time.Now().UTC()
+	}
+
+	// The repository only writes over the version read, so a concurrent change is not lost
+	next.Version = task.Version
+	updated, err := tu.TaskRepository.Update(c, task.Id, next, fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.recordHistory(c, actor, domain.HistoryUpdate, task, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// changedTaskFields lists the fields a change can write in which next differs from task.
+func changedTaskFields(task *domain.Task, next *domain.Task) []string {
+	fields := []string{}
+	if next.Title != task.Title {
+		fields = append(fields, domain.TaskFieldTitle)
+	}
+	if next.Description != task.Description {
+		fields = append(fields, domain.TaskFieldDescription)
+	}
+	if !next.DueDate.Equal(task.DueDate) {
+		fields = append(fields, domain.TaskFieldDueDate)
+	}
+	if next.Status != task.Status {
+		fields = append(fields, domain.TaskFieldStatus)
+	}
+	return fields
+}
+
+// decodePatchedTask reads back the task a patch left. Only the fields a
+// change can write may differ from task: the others, and fields a task does
+// not have, are rejected.
+func decodePatchedTask(task *domain.Task, patched []byte) (*domain.Task, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patched, &members); err != nil || members == nil {
+		return nil, fmt.Errorf("%w: the patched task is not an object", domain.ErrInvalidPatch)
+	}
+
+	invalid := &domain.ValidationError{}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		switch name {
+		case "dueDate", "statusChangedAt", "deletedAt":
+			var at *time.Time
+			if json.Unmarshal(members[name], &at) != nil {
+				invalid.Add(name, domain.CodeInvalidFormat, fmt.Sprintf("%s must be an RFC 3339 timestamp", name))
+			}
+		default:
+			if !slices.Contains(taskJSONFields, name) {
+				invalid.Add(name, domain.CodeUnknownField, fmt.Sprintf("a task has no %s", name))
+			}
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		return nil, err
+	}
+
+	next := &domain.Task{}
+	if err := json.Unmarshal(patched, next); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			invalid.Add(typeErr.Field, domain.CodeInvalidType, fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type))
+			return nil, invalid
+		}
+		return nil, fmt.Errorf("%w: the patched task is not a task", domain.ErrInvalidPatch)
+	}
+
+	readOnly := func(field string, same bool) {
+		if !same {
+			invalid.Add(field, domain.CodeReadOnly, fmt.Sprintf("%s cannot be changed", field))
+		}
+	}
+	readOnly("id", next.Id == task.Id)
+	readOnly("createdBy", next.CreatedBy == task.CreatedBy)
+	readOnly("assigneeIds", slices.Equal(next.AssigneeIDs, task.AssigneeIDs))
+	readOnly("statusChangedBy", next.StatusChangedBy == task.StatusChangedBy)
+	readOnly("statusChangedAt", next.StatusChangedAt.Equal(task.StatusChangedAt))
+	readOnly("deletedAt", next.DeletedAt == nil)
+	readOnly("version", next.Version == task.Version)
+	return next, invalid.Err()
+}
+
+// canChangeStatus tells whether the actor may move the task along the workflow:
+// anyone with task:update:any, or an assignee with task:update.
+func (tu *TaskUseCase) canChangeStatus(actor *domain.AuthenticatedUser, task *domain.Task) bool {
+	if tu.Policy.Allows(actor.Role, domain.PermTaskUpdateAny) {
+		return true
+	}
+	return tu.Policy.Allows(actor.Role, domain.PermTaskUpdate) && slices.Contains(task.AssigneeIDs, actor.UserID)
+}
+
+func (tu *TaskUseCase) canSeeTask(actor *domain.AuthenticatedUser, task *domain.Task) bool {
+	return tu.Policy.Allows(actor.Role, domain.PermTaskReadAny) || task.CreatedBy == actor.UserID || slices.Contains(task.AssigneeIDs, actor.UserID)
+}
+
+// uniqueIDs drops blank and repeated ids, always returning a non-nil slice.
+func uniqueIDs(ids []string) []string {
+	unique := []string{}
+	for _, id := range ids {
+		if id != "" && !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// normalizeTaskQuery validates a task query and fills in the default
+// ordering and page size, leaving the caller's query untouched.
+func normalizeTaskQuery(query *domain.TaskQuery) (*domain.TaskQuery, error) {
+	normalized := domain.TaskQuery{}
+	if query != nil {
+		normalized = *query
+	}
+
+	switch normalized.SortBy {
+	case "":
+		normalized.SortBy = domain.TaskSortById
+	case domain.TaskSortById, domain.TaskSortTitle, domain.TaskSortStatus, domain.TaskSortDueDate:
+	default:
+		return nil, fmt.Errorf("%w: cannot sort by %q", domain.ErrInvalidTaskQuery, normalized.SortBy)
+	}
+
+	switch {
+	case normalized.Limit < 0:
+		return nil, fmt.Errorf("%w: limit must be positive", domain.ErrInvalidTaskQuery)
+	case normalized.Limit == 0:
+		normalized.Limit = defaultTaskPageSize
+	case normalized.Limit > maxTaskPageSize:
+		normalized.Limit = maxTaskPageSize
+	}
+
+	if !normalized.DueBefore.IsZero() && !normalized.DueAfter.IsZero() && !normalized.DueAfter.Before(normalized.DueBefore) {
+		return nil, fmt.Errorf("%w: due_after must be earlier than due_before", domain.ErrInvalidTaskQuery)
+	}
+
+	if normalized.Cursor != "" {
+		cursor, err := utils.DecodeTaskCursor(normalized.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != normalized.SortBy || cursor.SortDesc != normalized.SortDesc {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort order", domain.ErrInvalidTaskQuery)
+		}
+	}
+
+	return &normalized, nil
 }
@@ -3,12 +3,14 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"task-manager-api-clean/domain"
 	"task-manager-api-clean/domain/mocks"
     "task-manager-api-clean/usecase"
+    "task-manager-api-clean/utils"
 
 
 	"github.com/stretchr/testify/mock"
@@ -20,16 +22,32 @@ const (
     nonExistentId  = "nonExistentId"
 )
 
+var (
+    admin  = &domain.AuthenticatedUser{UserID: "adminId", Username: "admin", Role: "admin"}
+    member = &domain.AuthenticatedUser{UserID: "memberId", Username: "member", Role: "user"}
+    manager = &domain.AuthenticatedUser{UserID: "managerId", Username: "manager", Role: "manager"}
+    viewer = &domain.AuthenticatedUser{UserID: "viewerId", Username: "viewer", Role: "viewer"}
+)
+
 type TaskUseCaseTestSuite struct {
     suite.Suite
     repo    *mocks.TaskRepository
+    history *mocks.HistoryRepository
+    users   *mocks.UserRepository
     useCase domain.TaskUseCase
 
 }
 
 func (suite *TaskUseCaseTestSuite) SetupTest() {
 	suite.repo = new(mocks.TaskRepository)
-    suite.useCase = usecase.NewTaskUseCase(suite.repo)
+    suite.history = new(mocks.HistoryRepository)
+    suite.history.On("Record", mock.Anything, mock.Anything).Return(nil).Maybe()
+    suite.users = new(mocks.UserRepository)
+    policy, err := domain.NewPolicy(nil)
+    suite.Require().NoError(err)
+    workflow, err := domain.NewWorkflow(nil, nil)
+    suite.Require().NoError(err)
+    suite.useCase = usecase.NewTaskUseCase(suite.repo, suite.history, suite.users, policy, workflow)
 
 }
 
@@ -42,7 +60,7 @@ func (suite *TaskUseCaseTestSuite) TestCreate_Success() {
 
     suite.repo.On("Create", ctx, mock.Anything).Return(task, nil)
 
-    createdTask, err := suite.useCase.Create(ctx, taskInput)
+    createdTask, err := suite.useCase.Create(ctx, admin, taskInput)
     suite.NoError(err)
     suite.Equal(taskInput.Title, createdTask.Title)
 }
@@ -55,7 +73,7 @@ func (suite *TaskUseCaseTestSuite) TestCreate_Failure() {
 
     suite.repo.On("Create", ctx, mock.Anything).Return(nil, errors.New("create error"))
 
-    _, err := suite.useCase.Create(ctx, taskInput)
+    _, err := suite.useCase.Create(ctx, admin, taskInput)
     suite.Error(err)
 }
 
@@ -63,14 +81,14 @@ func (suite *TaskUseCaseTestSuite) TestUpdate_Success() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    taskInput := &domain.TaskInput{Title: "Updated Task", Description: "Updated Description", Status: "Completed", DueDate: time.Now()}
+    taskInput := &domain.TaskInput{Title: "Updated Task", Description: "Updated Description", Status: "In Progress", DueDate: time.Now()}
     task := &domain.Task{Id: testId, Title: "Test Task", Description: "Test Description", Status: "Pending", DueDate: time.Now()}
     updatedTask := &domain.Task{Id: task.Id, Title: taskInput.Title, Description: taskInput.Description, Status: taskInput.Status, DueDate: taskInput.DueDate}
 
     suite.repo.On("GetById", ctx, testId).Return(task, nil)
-    suite.repo.On("Update", ctx, testId, mock.Anything).Return(updatedTask, nil)
+    suite.repo.On("Update", ctx, testId, mock.Anything, mock.Anything).Return(updatedTask, nil)
 
-    result, err := suite.useCase.Update(ctx, testId, taskInput)
+    result, err := suite.useCase.Update(ctx, admin, testId, taskInput, 0)
     suite.NoError(err)
     suite.Equal(taskInput.Title, result.Title)
 }
@@ -83,28 +101,195 @@ func (suite *TaskUseCaseTestSuite) TestUpdate_Failure() {
 
     suite.repo.On("GetById", ctx, nonExistentId).Return(nil, errors.New("not found"))
 
-    _, err := suite.useCase.Update(ctx, nonExistentId, taskInput)
+    _, err := suite.useCase.Update(ctx, admin, nonExistentId, taskInput, 0)
     suite.Error(err)
 }
 
+func (suite *TaskUseCaseTestSuite) TestUpdate_StaleVersion() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending", Version: 3}, nil)
+
+    _, err := suite.useCase.Update(ctx, admin, testId, &domain.TaskInput{Title: "Renamed"}, 2)
+    suite.ErrorIs(err, domain.ErrVersionMismatch)
+    suite.repo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_WritesOverVersionRead() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending", Version: 3}, nil)
+    suite.repo.On("Update", ctx, testId, mock.MatchedBy(func(task *domain.Task) bool {
+        return task.Version == 3
+    }), mock.Anything).Return(nil, domain.ErrVersionMismatch)
+
+    _, err := suite.useCase.Update(ctx, admin, testId, &domain.TaskInput{Title: "Renamed"}, 0)
+    suite.ErrorIs(err, domain.ErrVersionMismatch)
+    suite.history.AssertNotCalled(suite.T(), "Record", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestDelete_StaleVersion() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending", Version: 3}, nil)
+
+    err := suite.useCase.Delete(ctx, admin, testId, 2)
+    suite.ErrorIs(err, domain.ErrVersionMismatch)
+    suite.repo.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (suite *TaskUseCaseTestSuite) TestDelete_Success() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    suite.repo.On("Delete", ctx, testId).Return(nil)
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+    suite.repo.On("Delete", ctx, testId, int64(0)).Return(nil)
 
-    err := suite.useCase.Delete(ctx, testId)
+    err := suite.useCase.Delete(ctx, admin, testId, 0)
     suite.NoError(err)
+    suite.history.AssertCalled(suite.T(), "Record", ctx, mock.MatchedBy(func(entry *domain.TaskHistoryEntry) bool {
+        return entry.Action == domain.HistoryDelete && entry.TaskId == testId && entry.ActorId == admin.UserID && len(entry.Changes) == 2
+    }))
+}
+
+func (suite *TaskUseCaseTestSuite) TestDelete_HistoryFails() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.history.ExpectedCalls = nil
+    suite.history.On("Record", ctx, mock.Anything).Return(errors.New("history error"))
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+    suite.repo.On("Delete", ctx, testId, int64(0)).Return(nil)
+
+    // the task is gone, but the caller learns that the trail has a gap
+    err := suite.useCase.Delete(ctx, admin, testId, 0)
+    suite.ErrorContains(err, "history error")
+    suite.repo.AssertCalled(suite.T(), "Delete", ctx, testId, int64(0))
 }
 
 func (suite *TaskUseCaseTestSuite) TestDelete_Failure() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    suite.repo.On("Delete", ctx, nonExistentId).Return(errors.New("delete error"))
+    suite.repo.On("GetById", ctx, nonExistentId).Return(&domain.Task{Id: nonExistentId}, nil)
+    suite.repo.On("Delete", ctx, nonExistentId, int64(0)).Return(errors.New("delete error"))
 
-    err := suite.useCase.Delete(ctx, nonExistentId)
+    err := suite.useCase.Delete(ctx, admin, nonExistentId, 0)
     suite.Error(err)
+    suite.history.AssertNotCalled(suite.T(), "Record", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestTrash_OnlyTrashedTasks() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetAll", ctx, mock.MatchedBy(func(query *domain.TaskQuery) bool {
+        return query.Trashed && query.VisibleTo == ""
+    })).Return(&domain.TaskPage{Items: []*domain.Task{{Id: testId}}}, nil)
+
+    page, err := suite.useCase.Trash(ctx, admin, &domain.TaskQuery{})
+    suite.NoError(err)
+    suite.Len(page.Items, 1)
+}
+
+func (suite *TaskUseCaseTestSuite) TestRestore_Success() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetTrashed", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+    suite.repo.On("Restore", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+
+    task, err := suite.useCase.Restore(ctx, admin, testId)
+    suite.NoError(err)
+    suite.Equal(testId, task.Id)
+    suite.history.AssertCalled(suite.T(), "Record", ctx, mock.MatchedBy(func(entry *domain.TaskHistoryEntry) bool {
+        return entry.Action == domain.HistoryRestore && entry.TaskId == testId && entry.ActorId == admin.UserID
+    }))
+}
+
+func (suite *TaskUseCaseTestSuite) TestRestore_Forbidden() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := suite.useCase.Restore(ctx, manager, testId)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+    suite.repo.AssertNotCalled(suite.T(), "Restore", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestRestore_NotVisible() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // a policy letting members delete, but not see other people's tasks
+    policy, err := domain.NewPolicy(map[string][]string{"user": {string(domain.PermTaskRead), string(domain.PermTaskDelete)}})
+    suite.Require().NoError(err)
+    workflow, err := domain.NewWorkflow(nil, nil)
+    suite.Require().NoError(err)
+    useCase := usecase.NewTaskUseCase(suite.repo, suite.history, suite.users, policy, workflow)
+    suite.repo.On("GetTrashed", ctx, testId).Return(&domain.Task{Id: testId, CreatedBy: "someoneElse"}, nil)
+
+    _, err = useCase.Restore(ctx, member, testId)
+    suite.ErrorIs(err, domain.ErrTaskNotFound)
+    suite.repo.AssertNotCalled(suite.T(), "Restore", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestDelete_Forbidden() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    err := suite.useCase.Delete(ctx, manager, testId, 0)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+    suite.repo.AssertNotCalled(suite.T(), "GetById", mock.Anything, mock.Anything)
+    suite.repo.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestDelete_NotVisible() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    policy, err := domain.NewPolicy(map[string][]string{"user": {string(domain.PermTaskRead), string(domain.PermTaskDelete)}})
+    suite.Require().NoError(err)
+    workflow, err := domain.NewWorkflow(nil, nil)
+    suite.Require().NoError(err)
+    useCase := usecase.NewTaskUseCase(suite.repo, suite.history, suite.users, policy, workflow)
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, CreatedBy: "someoneElse"}, nil)
+
+    err = useCase.Delete(ctx, member, testId, 0)
+    suite.ErrorIs(err, domain.ErrTaskNotFound)
+    suite.repo.AssertNotCalled(suite.T(), "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestPurger_PurgesPastRetention() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    purger := usecase.NewTaskPurger(suite.repo, suite.history, 24*time.Hour, time.Hour)
+    suite.repo.On("Purge", ctx, mock.MatchedBy(func(before time.Time) bool {
+        return time.Until(before) < -23*time.Hour
+    })).Return([]*domain.Task{{Id: testId, Title: "Test Task"}}, nil)
+
+    purged, err := purger.Purge(ctx)
+    suite.NoError(err)
+    suite.Equal(1, purged)
+    suite.history.AssertCalled(suite.T(), "Record", ctx, mock.MatchedBy(func(entry *domain.TaskHistoryEntry) bool {
+        return entry.Action == domain.HistoryPurge && entry.TaskId == testId && entry.ActorId == "system"
+    }))
+}
+
+func (suite *TaskUseCaseTestSuite) TestPurger_RecordsWhatWasRemovedBeforeFailing() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    purger := usecase.NewTaskPurger(suite.repo, suite.history, 24*time.Hour, time.Hour)
+    suite.repo.On("Purge", ctx, mock.Anything).Return([]*domain.Task{{Id: testId, Title: "Test Task"}}, errors.New("connection lost"))
+
+    purged, err := purger.Purge(ctx)
+    suite.Error(err)
+    suite.Equal(1, purged)
+    suite.history.AssertNumberOfCalls(suite.T(), "Record", 1)
 }
 
 func (suite *TaskUseCaseTestSuite) TestGetAll_Success() {
@@ -115,25 +300,65 @@ func (suite *TaskUseCaseTestSuite) TestGetAll_Success() {
         {Title: "Test Task 1", Description: "Test Description 1", Status: "Pending", DueDate: time.Now()},
         {Title: "Test Task 2", Description: "Test Description 2", Status: "Completed", DueDate: time.Now()},
     }
+    expectedQuery := &domain.TaskQuery{Status: "Pending", SortBy: domain.TaskSortById, Limit: 20}
 
-    suite.repo.On("GetAll", ctx).Return(&tasks, nil)
+    suite.repo.On("GetAll", ctx, expectedQuery).Return(&domain.TaskPage{Items: tasks, Total: 2}, nil)
 
-    result, err := suite.useCase.GetAll(ctx)
+    result, err := suite.useCase.GetAll(ctx, admin, &domain.TaskQuery{Status: "Pending"})
     suite.NoError(err)
-    suite.Len(*result, 2)
+    suite.Len(result.Items, 2)
+    suite.repo.AssertCalled(suite.T(), "GetAll", ctx, expectedQuery)
 }
 
 func (suite *TaskUseCaseTestSuite) TestGetAll_Empty() {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    var tasks []*domain.Task
+    suite.repo.On("GetAll", ctx, mock.Anything).Return(&domain.TaskPage{Items: []*domain.Task{}}, nil)
+
+    result, err := suite.useCase.GetAll(ctx, admin, nil)
+    suite.NoError(err)
+    suite.Len(result.Items, 0)
+}
+
+func (suite *TaskUseCaseTestSuite) TestGetAll_LimitIsCapped() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
 
-    suite.repo.On("GetAll", ctx).Return(&tasks, nil)
+    suite.repo.On("GetAll", ctx, mock.MatchedBy(func(query *domain.TaskQuery) bool {
+        return query.Limit == 100
+    })).Return(&domain.TaskPage{Items: []*domain.Task{}}, nil)
 
-    result, err := suite.useCase.GetAll(ctx)
+    _, err := suite.useCase.GetAll(ctx, admin, &domain.TaskQuery{Limit: 5000})
     suite.NoError(err)
-    suite.Len(*result, 0)
+}
+
+func (suite *TaskUseCaseTestSuite) TestGetAll_InvalidQuery() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    now := time.Now()
+    invalid := []*domain.TaskQuery{
+        {SortBy: "description"},
+        {Limit: -1},
+        {DueAfter: now, DueBefore: now.Add(-time.Hour)},
+        {Cursor: "%%%"},
+    }
+    for _, query := range invalid {
+        _, err := suite.useCase.GetAll(ctx, admin, query)
+        suite.ErrorIs(err, domain.ErrInvalidTaskQuery)
+    }
+    suite.repo.AssertNotCalled(suite.T(), "GetAll", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestGetAll_CursorFromDifferentSortOrder() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor := utils.EncodeTaskCursor(&domain.Task{Id: testId, Title: "Test Task"}, &domain.TaskQuery{SortBy: domain.TaskSortTitle})
+
+    _, err := suite.useCase.GetAll(ctx, admin, &domain.TaskQuery{SortBy: domain.TaskSortDueDate, Cursor: cursor})
+    suite.ErrorIs(err, domain.ErrInvalidTaskQuery)
 }
 
 func (suite *TaskUseCaseTestSuite) TestGetById_Success() {
@@ -144,7 +369,7 @@ func (suite *TaskUseCaseTestSuite) TestGetById_Success() {
 
     suite.repo.On("GetById", ctx, testId).Return(task, nil)
 
-    result, err := suite.useCase.GetById(ctx, testId)
+    result, err := suite.useCase.GetById(ctx, admin, testId)
     suite.NoError(err)
     suite.Equal(task.Title, result.Title)
 }
@@ -155,10 +380,545 @@ func (suite *TaskUseCaseTestSuite) TestGetById_Failure() {
 
     suite.repo.On("GetById", ctx, nonExistentId).Return(nil, errors.New("not found"))
 
-    _, err := suite.useCase.GetById(ctx, nonExistentId)
+    _, err := suite.useCase.GetById(ctx, admin, nonExistentId)
     suite.Error(err)
 }
 
+func (suite *TaskUseCaseTestSuite) TestCreate_RecordsCreatorAndAssignees() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    taskInput := &domain.TaskInput{Title: "Test Task", Status: "Pending", AssigneeIDs: []string{"u1", "", "u2", "u1"}}
+    suite.users.On("GetById", ctx, "u1").Return(&domain.User{UserID: "u1"}, nil).Once()
+    suite.users.On("GetById", ctx, "u2").Return(&domain.User{UserID: "u2"}, nil).Once()
+    suite.repo.On("Create", ctx, mock.MatchedBy(func(task *domain.Task) bool {
+        return task.CreatedBy == admin.UserID && len(task.AssigneeIDs) == 2
+    })).Return(&domain.Task{Id: testId}, nil)
+
+    _, err := suite.useCase.Create(ctx, admin, taskInput)
+    suite.NoError(err)
+}
+
+func (suite *TaskUseCaseTestSuite) TestGetAll_ScopedForNonAdmin() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetAll", ctx, mock.MatchedBy(func(query *domain.TaskQuery) bool {
+        return query.VisibleTo == member.UserID
+    })).Return(&domain.TaskPage{Items: []*domain.Task{}}, nil)
+
+    _, err := suite.useCase.GetAll(ctx, member, &domain.TaskQuery{})
+    suite.NoError(err)
+}
+
+func (suite *TaskUseCaseTestSuite) TestGetById_HiddenFromUnrelatedUser() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", CreatedBy: admin.UserID, AssigneeIDs: []string{"someoneElse"}}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    _, err := suite.useCase.GetById(ctx, member, testId)
+    suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskUseCaseTestSuite) TestGetById_VisibleToAssignee() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", CreatedBy: admin.UserID, AssigneeIDs: []string{member.UserID}}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    result, err := suite.useCase.GetById(ctx, member, testId)
+    suite.NoError(err)
+    suite.Equal(testId, result.Id)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_AssigneeCanChangeStatus() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Pending", AssigneeIDs: []string{member.UserID}}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.MatchedBy(func(task *domain.Task) bool {
+        return task.Status == "In Progress" && task.StatusChangedBy == member.UserID && !task.StatusChangedAt.IsZero()
+    }), mock.Anything).Return(&domain.Task{Id: testId, Status: "In Progress"}, nil)
+
+    result, err := suite.useCase.Update(ctx, member, testId, &domain.TaskInput{Status: "In Progress"}, 0)
+    suite.NoError(err)
+    suite.Equal("In Progress", result.Status)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_AssigneeCannotChangeOtherFields() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Pending", AssigneeIDs: []string{member.UserID}}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    _, err := suite.useCase.Update(ctx, member, testId, &domain.TaskInput{Title: "Renamed", Status: "Completed"}, 0)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+    suite.repo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_CreatorWhoIsNotAssigneeIsForbidden() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Pending", CreatedBy: member.UserID}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    _, err := suite.useCase.Update(ctx, member, testId, &domain.TaskInput{Status: "Completed"}, 0)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+}
+
+func (suite *TaskUseCaseTestSuite) TestAssign_Success() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Version: 3}, nil)
+    suite.users.On("GetById", ctx, "u1").Return(&domain.User{UserID: "u1"}, nil).Once()
+    suite.users.On("GetById", ctx, "u2").Return(&domain.User{UserID: "u2"}, nil).Once()
+    suite.repo.On("UpdateAssignees", ctx, testId, []string{"u1", "u2"}, int64(3)).Return(&domain.Task{Id: testId, AssigneeIDs: []string{"u1", "u2"}}, nil)
+
+    result, err := suite.useCase.Assign(ctx, admin, testId, []string{"u1", "u2", "u1"}, 3)
+    suite.NoError(err)
+    suite.Equal([]string{"u1", "u2"}, result.AssigneeIDs)
+    suite.users.AssertExpectations(suite.T())
+}
+
+func (suite *TaskUseCaseTestSuite) TestAssign_UnknownUser() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Version: 1}, nil)
+    suite.users.On("GetById", ctx, "u1").Return(&domain.User{UserID: "u1"}, nil)
+    suite.users.On("GetById", ctx, "ghost").Return(nil, domain.ErrUserNotFound)
+
+    _, err := suite.useCase.Assign(ctx, admin, testId, []string{"u1", "ghost"}, 0)
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Len(invalid.Fields, 1)
+    suite.Equal("assigneeIds[1]", invalid.Fields[0].Field)
+    suite.Equal(domain.CodeUnknownValue, invalid.Fields[0].Code)
+    suite.repo.AssertNotCalled(suite.T(), "UpdateAssignees", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestAssign_VersionMismatch() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Version: 2}, nil)
+
+    _, err := suite.useCase.Assign(ctx, admin, testId, []string{"u1"}, 1)
+    suite.ErrorIs(err, domain.ErrVersionMismatch)
+    suite.repo.AssertNotCalled(suite.T(), "UpdateAssignees", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestAssign_NonAdminForbidden() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := suite.useCase.Assign(ctx, member, testId, []string{member.UserID}, 0)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+}
+
+func (suite *TaskUseCaseTestSuite) TestCreate_RequiresPermission() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    for _, actor := range []*domain.AuthenticatedUser{member, viewer} {
+        _, err := suite.useCase.Create(ctx, actor, &domain.TaskInput{Title: "Test Task"})
+        suite.ErrorIs(err, domain.ErrTaskForbidden, actor.Role)
+    }
+    suite.repo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestCreate_InvalidInput() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    taskInput := &domain.TaskInput{Title: "   ", Description: strings.Repeat("x", 5001), DueDate: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+    _, err := suite.useCase.Create(ctx, admin, taskInput)
+    suite.ErrorIs(err, domain.ErrValidation)
+
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Equal([]domain.FieldError{
+        {Field: "title", Code: domain.CodeRequired, Message: "title must not be blank"},
+        {Field: "description", Code: domain.CodeTooLong, Message: "description must be at most 5000 characters"},
+        {Field: "dueDate", Code: domain.CodeInPast, Message: "dueDate must not be in the past"},
+    }, invalid.Fields)
+    suite.repo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestCreate_TitleTooLong() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := suite.useCase.Create(ctx, admin, &domain.TaskInput{Title: strings.Repeat("x", 201)})
+
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Equal("title", invalid.Fields[0].Field)
+    suite.Equal(domain.CodeTooLong, invalid.Fields[0].Code)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_KeepsPastDueDateButRejectsBlankTitle() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // A past due date is fine on update, an overdue task can still be edited
+    _, err := suite.useCase.Update(ctx, admin, testId, &domain.TaskInput{Title: " ", DueDate: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}, 0)
+
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Len(invalid.Fields, 1)
+    suite.Equal("title", invalid.Fields[0].Field)
+    suite.repo.AssertNotCalled(suite.T(), "GetById", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestGetAll_ReadAnyIsNotScoped() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetAll", ctx, mock.MatchedBy(func(query *domain.TaskQuery) bool {
+        return query.VisibleTo == ""
+    })).Return(&domain.TaskPage{Items: []*domain.Task{}}, nil)
+
+    _, err := suite.useCase.GetAll(ctx, viewer, &domain.TaskQuery{})
+    suite.NoError(err)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_ManagerUpdatesAnyTask() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Pending", CreatedBy: admin.UserID}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.Anything, mock.Anything).Return(&domain.Task{Id: testId, Title: "Renamed"}, nil)
+
+    _, err := suite.useCase.Update(ctx, manager, testId, &domain.TaskInput{Title: "Renamed"}, 0)
+    suite.NoError(err)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_ViewerIsForbidden() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Pending", AssigneeIDs: []string{viewer.UserID}}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    _, err := suite.useCase.Update(ctx, viewer, testId, &domain.TaskInput{Status: "Completed"}, 0)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+}
+
+func (suite *TaskUseCaseTestSuite) TestCreate_DefaultsToInitialStatus() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("Create", ctx, mock.MatchedBy(func(task *domain.Task) bool {
+        return task.Status == domain.StatusPending && task.StatusChangedBy == admin.UserID
+    })).Return(&domain.Task{Id: testId, Status: domain.StatusPending}, nil)
+
+    _, err := suite.useCase.Create(ctx, admin, &domain.TaskInput{Title: "Test Task"})
+    suite.NoError(err)
+}
+
+func (suite *TaskUseCaseTestSuite) TestCreate_UnknownStatus() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := suite.useCase.Create(ctx, admin, &domain.TaskInput{Title: "Test Task", Status: "Someday"})
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Equal("status", invalid.Fields[0].Field)
+    suite.Equal(domain.CodeUnknownValue, invalid.Fields[0].Code)
+    suite.repo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_OmittedStatusIsKept() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "In Progress"}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.MatchedBy(func(task *domain.Task) bool {
+        return task.Status == "In Progress" && task.Title == "Renamed"
+    }), []string{domain.TaskFieldTitle}).Return(&domain.Task{Id: testId, Title: "Renamed", Status: "In Progress"}, nil)
+
+    result, err := suite.useCase.Update(ctx, admin, testId, &domain.TaskInput{Title: "Renamed"}, 0)
+    suite.NoError(err)
+    suite.Equal("In Progress", result.Status)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_IllegalTransition() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    _, err := suite.useCase.Update(ctx, admin, testId, &domain.TaskInput{Status: "Completed"}, 0)
+    var transitionErr *domain.TransitionError
+    suite.ErrorAs(err, &transitionErr)
+    suite.Equal("Pending", transitionErr.From)
+    suite.repo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestTransition_Success() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "In Progress", AssigneeIDs: []string{member.UserID}}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.MatchedBy(func(task *domain.Task) bool {
+        return task.Status == "Completed" && task.StatusChangedBy == member.UserID && !task.StatusChangedAt.IsZero() && task.Title == ""
+    }), []string{domain.TaskFieldStatus}).Return(&domain.Task{Id: testId, Status: "Completed"}, nil)
+
+    result, err := suite.useCase.Transition(ctx, member, testId, "Completed")
+    suite.NoError(err)
+    suite.Equal("Completed", result.Status)
+}
+
+func (suite *TaskUseCaseTestSuite) TestTransition_FromFinalStatus() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Cancelled"}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    _, err := suite.useCase.Transition(ctx, admin, testId, "Pending")
+    var transitionErr *domain.TransitionError
+    suite.ErrorAs(err, &transitionErr)
+    suite.Empty(transitionErr.Allowed)
+}
+
+func (suite *TaskUseCaseTestSuite) TestTransition_NotAssigneeForbidden() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Status: "Pending", CreatedBy: member.UserID}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+
+    _, err := suite.useCase.Transition(ctx, member, testId, "In Progress")
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_RecordsFieldDiff() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Description: "Same", Status: "Pending"}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.Anything, mock.Anything).Return(&domain.Task{Id: testId, Title: "Renamed", Description: "Same", Status: "In Progress"}, nil)
+
+    _, err := suite.useCase.Update(ctx, admin, testId, &domain.TaskInput{Title: "Renamed", Status: "In Progress"}, 0)
+    suite.NoError(err)
+    suite.history.AssertCalled(suite.T(), "Record", ctx, &domain.TaskHistoryEntry{
+        TaskId:        testId,
+        Action:        domain.HistoryUpdate,
+        ActorId:       admin.UserID,
+        ActorUsername: admin.Username,
+        Changes: []domain.FieldChange{
+            {Field: "title", From: "Test Task", To: "Renamed"},
+            {Field: "status", From: "Pending", To: "In Progress"},
+        },
+        At: suite.recordedAt(),
+    })
+}
+
+func (suite *TaskUseCaseTestSuite) TestCreate_RecordsHistory() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("Create", ctx, mock.Anything).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+
+    _, err := suite.useCase.Create(ctx, admin, &domain.TaskInput{Title: "Test Task"})
+    suite.NoError(err)
+    suite.history.AssertCalled(suite.T(), "Record", ctx, mock.MatchedBy(func(entry *domain.TaskHistoryEntry) bool {
+        return entry.Action == domain.HistoryCreate && len(entry.Changes) == 2 && entry.Changes[0].From == nil && entry.Changes[0].To == "Test Task"
+    }))
+}
+
+func (suite *TaskUseCaseTestSuite) TestUpdate_FailureRecordsNothing() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+    suite.repo.On("Update", ctx, testId, mock.Anything, mock.Anything).Return(nil, errors.New("update error"))
+
+    _, err := suite.useCase.Update(ctx, admin, testId, &domain.TaskInput{Title: "Renamed"}, 0)
+    suite.Error(err)
+    suite.history.AssertNotCalled(suite.T(), "Record", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestReplace_ClearsOmittedFields() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Description: "Details", Status: "Pending", DueDate: time.Now(), Version: 3}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.MatchedBy(func(next *domain.Task) bool {
+        return next.Description == "" && next.DueDate.IsZero() && next.Version == 3
+    }), []string{domain.TaskFieldDescription, domain.TaskFieldDueDate}).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+
+    result, err := suite.useCase.Replace(ctx, admin, testId, &domain.TaskInput{Title: "Test Task", Status: "Pending"}, 3)
+    suite.NoError(err)
+    suite.Empty(result.Description)
+}
+
+func (suite *TaskUseCaseTestSuite) TestReplace_RequiresTitleAndStatus() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := suite.useCase.Replace(ctx, admin, testId, &domain.TaskInput{Description: "Details"}, 0)
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Equal([]domain.FieldError{
+        {Field: "title", Code: domain.CodeRequired, Message: "title must not be blank"},
+        {Field: "status", Code: domain.CodeRequired, Message: "status must not be blank"},
+    }, invalid.Fields)
+    suite.repo.AssertNotCalled(suite.T(), "GetById", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestPatch_MergePatchNullClears() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Description: "Details", Status: "Pending", DueDate: time.Now(), Version: 3}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.MatchedBy(func(next *domain.Task) bool {
+        return next.Title == "Renamed" && next.Description == "" && next.DueDate.IsZero()
+    }), []string{domain.TaskFieldTitle, domain.TaskFieldDescription, domain.TaskFieldDueDate}).Return(&domain.Task{Id: testId, Title: "Renamed", Status: "Pending"}, nil)
+
+    patch := &domain.TaskPatch{MediaType: domain.MediaTypeMergePatch, Document: []byte(`{"title": "Renamed", "description": null, "dueDate": null}`)}
+    result, err := suite.useCase.Patch(ctx, admin, testId, patch, 0)
+    suite.NoError(err)
+    suite.Equal("Renamed", result.Title)
+}
+
+func (suite *TaskUseCaseTestSuite) TestPatch_JSONPatch() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task := &domain.Task{Id: testId, Title: "Test Task", Description: "Details", Status: "Pending", Version: 3}
+    suite.repo.On("GetById", ctx, testId).Return(task, nil)
+    suite.repo.On("Update", ctx, testId, mock.MatchedBy(func(next *domain.Task) bool {
+        return next.Status == "In Progress" && next.StatusChangedBy == admin.UserID && next.Description == "Details"
+    }), []string{domain.TaskFieldStatus}).Return(&domain.Task{Id: testId, Status: "In Progress"}, nil)
+
+    patch := &domain.TaskPatch{MediaType: domain.MediaTypeJSONPatch, Document: []byte(`[
+        {"op": "test", "path": "/version", "value": 3},
+        {"op": "replace", "path": "/status", "value": "In Progress"}
+    ]`)}
+    _, err := suite.useCase.Patch(ctx, admin, testId, patch, 0)
+    suite.NoError(err)
+}
+
+func (suite *TaskUseCaseTestSuite) TestPatch_FailedTestChangesNothing() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending", Version: 3}, nil)
+
+    patch := &domain.TaskPatch{MediaType: domain.MediaTypeJSONPatch, Document: []byte(`[
+        {"op": "replace", "path": "/title", "value": "Renamed"},
+        {"op": "test", "path": "/title", "value": "Test Task"}
+    ]`)}
+    _, err := suite.useCase.Patch(ctx, admin, testId, patch, 0)
+    suite.ErrorIs(err, domain.ErrPatchTestFailed)
+    suite.ErrorIs(err, domain.ErrConflict)
+    suite.repo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestPatch_RejectsReadOnlyAndUnknownFields() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending", CreatedBy: member.UserID, Version: 3}, nil)
+
+    patch := &domain.TaskPatch{MediaType: domain.MediaTypeMergePatch, Document: []byte(`{"createdBy": "someoneElse", "version": 9}`)}
+    _, err := suite.useCase.Patch(ctx, admin, testId, patch, 0)
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Equal([]string{"createdBy", "version"}, []string{invalid.Fields[0].Field, invalid.Fields[1].Field})
+    suite.Equal(domain.CodeReadOnly, invalid.Fields[0].Code)
+
+    patch = &domain.TaskPatch{MediaType: domain.MediaTypeMergePatch, Document: []byte(`{"priority": "high"}`)}
+    _, err = suite.useCase.Patch(ctx, admin, testId, patch, 0)
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Equal(domain.CodeUnknownField, invalid.Fields[0].Code)
+    suite.repo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestPatch_AssigneeCannotChangeOtherFields() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Description: "Details", Status: "Pending", AssigneeIDs: []string{member.UserID}}, nil)
+
+    patch := &domain.TaskPatch{MediaType: domain.MediaTypeMergePatch, Document: []byte(`{"description": null}`)}
+    _, err := suite.useCase.Patch(ctx, member, testId, patch, 0)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+}
+
+func (suite *TaskUseCaseTestSuite) TestPatch_UnsupportedMediaType() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, Title: "Test Task", Status: "Pending"}, nil)
+
+    _, err := suite.useCase.Patch(ctx, admin, testId, &domain.TaskPatch{MediaType: "text/plain", Document: []byte("title=Renamed")}, 0)
+    suite.ErrorIs(err, domain.ErrUnsupportedMediaType)
+}
+
+func (suite *TaskUseCaseTestSuite) TestHistory_ScopedToVisibleTask() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, CreatedBy: "someoneElse"}, nil)
+
+    _, err := suite.useCase.History(ctx, member, testId, nil)
+    suite.ErrorIs(err, domain.ErrTaskNotFound)
+    suite.history.AssertNotCalled(suite.T(), "List", mock.Anything, mock.Anything)
+}
+
+func (suite *TaskUseCaseTestSuite) TestHistory_Success() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    suite.repo.On("GetById", ctx, testId).Return(&domain.Task{Id: testId, AssigneeIDs: []string{member.UserID}}, nil)
+    suite.history.On("List", ctx, &domain.HistoryQuery{TaskId: testId, Limit: 20}).Return(&domain.HistoryPage{Items: []*domain.TaskHistoryEntry{}}, nil)
+
+    _, err := suite.useCase.History(ctx, member, testId, &domain.HistoryQuery{TaskId: "ignored"})
+    suite.NoError(err)
+}
+
+func (suite *TaskUseCaseTestSuite) TestAudit_RequiresPermission() {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := suite.useCase.Audit(ctx, manager, nil)
+    suite.ErrorIs(err, domain.ErrTaskForbidden)
+
+    suite.history.On("List", ctx, &domain.HistoryQuery{Limit: 100}).Return(&domain.HistoryPage{Items: []*domain.TaskHistoryEntry{}}, nil)
+    _, err = suite.useCase.Audit(ctx, admin, &domain.HistoryQuery{Limit: 1000})
+    suite.NoError(err)
+}
+
+// recordedAt is the timestamp of the last history entry recorded, for
+// comparing whole entries.
+func (suite *TaskUseCaseTestSuite) recordedAt() time.Time {
+    for i := len(suite.history.Calls) - 1; i >= 0; i-- {
+        if call := suite.history.Calls[i]; call.Method == "Record" {
+            return call.Arguments.Get(1).(*domain.TaskHistoryEntry).At
+        }
+    }
+    return time.Time{}
+}
+
 func TestTaskUseCaseTestSuite(t *testing.T) {
     suite.Run(t, new(TaskUseCaseTestSuite))
 }
\ No newline at end of file
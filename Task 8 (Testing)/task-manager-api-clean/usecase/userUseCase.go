@@ -2,7 +2,12 @@ package usecase
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
 
 	"task-manager-api-clean/utils"
 	"task-manager-api-clean/domain"
@@ -11,83 +16,664 @@ import (
 )
 
 
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
 type UserUseCase struct {
 	Environment *config.Environment
+	KeyRing *utils.KeyRing
+	Policy *domain.Policy
 	UserRepository domain.UserRepository
+	RefreshTokenRepository domain.RefreshTokenRepository
+	PasswordResetRepository domain.PasswordResetRepository
+	Mailer domain.Mailer
+
+	// the hash of the one-time setup token, while there is one
+	setupMu sync.Mutex
+	setupTokenHash string
 }
 
-func NewUserUseCase(userRepo domain.UserRepository, env *config.Environment) domain.UserUseCase {
+func NewUserUseCase(userRepo domain.UserRepository, refreshRepo domain.RefreshTokenRepository, resetRepo domain.PasswordResetRepository, mailer domain.Mailer, keys *utils.KeyRing, policy *domain.Policy, env *config.Environment) domain.UserUseCase {
 	return &UserUseCase{
 		UserRepository: userRepo,
+		RefreshTokenRepository: refreshRepo,
+		PasswordResetRepository: resetRepo,
+		Mailer: mailer,
+		KeyRing: keys,
+		Policy: policy,
 		Environment: env,
 	}
 }
 
 func (uc *UserUseCase) RegisterUser(c context.Context, payload *domain.UserCreate) (*domain.UserInfo, error) {
-	if payload.Username == "" || payload.Password == "" {
-		return nil, errors.New("invalid Payload")
+	if err := validateUserCreate(payload); err != nil {
+		return nil, err
 	}
 	
 	user := &domain.User{
 		Username: payload.Username,
 		Password: payload.Password,
 		Email:    payload.Email,
+		Role:     "user",
 	}
 
-	//check if user exists
-	_, err := uc.UserRepository.GetByUsername(c, user.Username)
-	if err == nil {
-		return nil, errors.New("user already exists")	
+	// The repository refuses a taken username or address, atomically, so two
+	// registrations racing for one cannot both get it
+	createdUser, err := uc.UserRepository.Create(c, user)
+	if err != nil {
+		return nil, err
 	}
 
-	createdUser, err := uc.UserRepository.Create(c, user)
+	// The account exists either way, and the user can ask for another link
+	if err := uc.sendVerification(c, createdUser); err != nil {
+		utils.Logger(c).Error("failed to send verification email", "username", createdUser.Username, "error", err)
+	}
+
+	return createdUser.Info(), nil
+}
+
+func (uc *UserUseCase) BootstrapAdmin(c context.Context) error {
+	hasAdmin, err := uc.hasActiveAdmin(c)
+	if err != nil || hasAdmin {
+		return err
+	}
+
+	token, err := utils.GenerateToken()
 	if err != nil {
+		return err
+	}
+	uc.setupMu.Lock()
+	uc.setupTokenHash = utils.HashToken(token)
+	uc.setupMu.Unlock()
+
+	// Reading the log takes access to the server, which is what makes the
+	// token proof of running the API
+	utils.Logger(c).Warn("there is no active admin, create one with POST /setup and this one-time token", "setup_token", token)
+	return nil
+}
+
+func (uc *UserUseCase) Setup(c context.Context, payload *domain.AdminSetup) (*domain.UserInfo, error) {
+	if err := validateUserCreate(&payload.UserCreate); err != nil {
 		return nil, err
 	}
 
-	return &domain.UserInfo{
-		UserId:   createdUser.UserID,
-		Username: createdUser.Username,
-		Email:    createdUser.Email,
-	}, nil
+	// Holding the lock until the admin is created makes the token single use,
+	// however many requests race for it
+	uc.setupMu.Lock()
+	defer uc.setupMu.Unlock()
+
+	if uc.setupTokenHash == "" || subtle.ConstantTimeCompare([]byte(uc.setupTokenHash), []byte(utils.HashToken(payload.SetupToken))) != 1 {
+		return nil, domain.ErrInvalidSetupToken
+	}
+	// An admin made since startup, by another instance say, retires the token
+	hasAdmin, err := uc.hasActiveAdmin(c)
+	if err != nil {
+		return nil, err
+	}
+	if hasAdmin {
+		uc.setupTokenHash = ""
+		return nil, domain.ErrInvalidSetupToken
+	}
+
+	// A taken username or address leaves the token usable for another try
+	admin, err := uc.UserRepository.Create(c, &domain.User{
+		Username: payload.Username,
+		Password: payload.Password,
+		Email:    payload.Email,
+		Role:     "admin",
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.setupTokenHash = ""
+	utils.Logger(c).Info("first admin created", "username", admin.Username)
+
+	if err := uc.sendVerification(c, admin); err != nil {
+		utils.Logger(c).Error("failed to send verification email", "username", admin.Username, "error", err)
+	}
+	return admin.Info(), nil
 }
 
-func (uc *UserUseCase) Login(c context.Context, payload *domain.UserLogin) (string, error) {
+func (uc *UserUseCase) Login(c context.Context, payload *domain.UserLogin) (*domain.TokenPair, error) {
+	// An unknown username fails the same way as a wrong password, so logins
+	// cannot be used to find out which usernames exist
 	user, err := uc.UserRepository.GetByUsername(c, payload.Username)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		utils.Logger(c).Info("login failed", "username", payload.Username, "reason", "unknown username")
+		return nil, domain.ErrInvalidCredentials
+	}
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	// A locked account fails like a wrong password too, without the password
+	// being checked, so guessing on gets nowhere until the lock runs out
+	now := time.Now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		utils.Logger(c).Info("login failed", "username", payload.Username, "reason", "account locked", "locked_until", *user.LockedUntil)
+		return nil, domain.ErrInvalidCredentials
 	}
 
 	// Compare passwords
 	err = utils.ComparePasswords(user.Password, payload.Password)
 	if err != nil {
-		return "", errors.New("invalid username or password")
+		utils.Logger(c).Info("login failed", "username", payload.Username, "reason", "wrong password")
+		if err := uc.recordFailedLogin(c, user, now); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := uc.UserRepository.ResetFailedLogins(c, user.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only someone with the right password learns that the account is
+	// deactivated or its address unverified
+	if user.Deactivated {
+		utils.Logger(c).Info("login failed", "username", payload.Username, "reason", "account deactivated")
+		return nil, domain.ErrUserDeactivated
+	}
+	if uc.Environment.RequireEmailVerification && !user.EmailVerified {
+		utils.Logger(c).Info("login failed", "username", payload.Username, "reason", "email not verified")
+		return nil, domain.ErrEmailNotVerified
+	}
+
+	// A new login starts a new refresh token family
+	familyId, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	return uc.issueTokens(c, user, familyId)
+}
+
+func (uc *UserUseCase) Refresh(c context.Context, payload *domain.RefreshRequest) (*domain.TokenPair, error) {
+	stored, err := uc.RefreshTokenRepository.GetByHash(c, utils.HashToken(payload.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// Every refresh token is single use. Seeing one again means it leaked,
+	// so the whole family descending from the same login is revoked.
+	rotated, err := uc.RefreshTokenRepository.Revoke(c, stored.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		utils.Logger(c).Warn("refresh token reused, revoking its session", "user_id", stored.UserID, "family_id", stored.FamilyId)
+		if err := uc.RefreshTokenRepository.RevokeFamily(c, stored.FamilyId); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := uc.UserRepository.GetById(c, stored.UserID)
+	if err != nil || user.Deactivated {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	return uc.issueTokens(c, user, stored.FamilyId)
+}
+
+func (uc *UserUseCase) Logout(c context.Context, payload *domain.LogoutRequest) error {
+	stored, err := uc.RefreshTokenRepository.GetByHash(c, utils.HashToken(payload.RefreshToken))
+	if err != nil {
+		return err
+	}
+
+	if !payload.AllSessions {
+		return uc.RefreshTokenRepository.RevokeFamily(c, stored.FamilyId)
+	}
+
+	// Logging out everywhere also kills access tokens that are still valid
+	if err := uc.RefreshTokenRepository.RevokeAllForUser(c, stored.UserID); err != nil {
+		return err
+	}
+	return uc.UserRepository.IncrementTokenVersion(c, stored.UserID)
+}
+
+func (uc *UserUseCase) Promote(c context.Context, username string, role string) (*domain.UserInfo, error) {
+	if !uc.Policy.HasRole(role) {
+		return nil, fmt.Errorf("%w %q", domain.ErrUnknownRole, role)
+	}
+
+	user, err := uc.UserRepository.UpdateRole(c, username, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.Info(), nil
+}
+
+func (uc *UserUseCase) List(c context.Context, query *domain.UserQuery) (*domain.UserPage, error) {
+	normalized := *query
+	if normalized.Role != "" && !uc.Policy.HasRole(normalized.Role) {
+		return nil, fmt.Errorf("%w %q", domain.ErrUnknownRole, normalized.Role)
+	}
+	switch {
+	case normalized.Limit < 0:
+		return nil, domain.NewError(domain.ErrValidation, "limit must be a positive integer")
+	case normalized.Limit == 0:
+		normalized.Limit = defaultUserPageSize
+	case normalized.Limit > maxUserPageSize:
+		normalized.Limit = maxUserPageSize
+	}
+
+	users, next, err := uc.UserRepository.List(c, &normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.UserPage{Items: make([]*domain.UserInfo, 0, len(users)), NextCursor: next}
+	for _, user := range users {
+		page.Items = append(page.Items, user.Info())
+	}
+	return page, nil
+}
+
+func (uc *UserUseCase) Profile(c context.Context, user *domain.AuthenticatedUser) (*domain.UserInfo, error) {
+	stored, err := uc.UserRepository.GetById(c, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return stored.Info(), nil
+}
+
+func (uc *UserUseCase) UpdateProfile(c context.Context, user *domain.AuthenticatedUser, payload *domain.UserProfileUpdate) (*domain.UserInfo, *domain.TokenPair, error) {
+	if err := validateUserProfileUpdate(payload); err != nil {
+		return nil, nil, err
+	}
+
+	stored, err := uc.UserRepository.GetById(c, user.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	username, email := stored.Username, stored.Email
+	if payload.Username != nil {
+		username = *payload.Username
+	}
+	if payload.Email != nil {
+		email = *payload.Email
+	}
+	if username == stored.Username && email == stored.Email {
+		return nil, nil, domain.ErrUserUnchanged
+	}
+
+	// a username or address another user has is refused by the repository
+	updated, err := uc.UserRepository.UpdateProfile(c, stored.UserID, username, email)
+	if err != nil {
+		return nil, nil, err
+	}
+	utils.Logger(c).Info("profile updated", "user_id", updated.UserID, "old_username", stored.Username, "new_username", updated.Username)
+
+	if email != stored.Email {
+		if err := uc.sendVerification(c, updated); err != nil {
+			utils.Logger(c).Error("failed to send verification email", "username", updated.Username, "error", err)
+		}
+	}
+
+	if updated.Username == stored.Username {
+		return updated.Info(), nil, nil
+	}
+	// the caller's access token carried the old username and no longer works
+	familyId, err := utils.GenerateToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	tokens, err := uc.issueTokens(c, updated, familyId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated.Info(), tokens, nil
+}
+
+func (uc *UserUseCase) Update(c context.Context, actor *domain.AuthenticatedUser, userID string, payload *domain.UserUpdate) (*domain.UserInfo, error) {
+	if payload.Role == nil && payload.Active == nil {
+		return nil, domain.ErrUserUnchanged
+	}
+	if payload.Role != nil && !uc.Policy.Allows(actor.Role, domain.PermUserPromote) {
+		return nil, domain.ErrUserForbidden
+	}
+	if payload.Active != nil && !uc.Policy.Allows(actor.Role, domain.PermUserDeactivate) {
+		return nil, domain.ErrUserForbidden
+	}
+	if payload.Role != nil && !uc.Policy.HasRole(*payload.Role) {
+		return nil, fmt.Errorf("%w %q", domain.ErrUnknownRole, *payload.Role)
+	}
+
+	user, err := uc.UserRepository.GetById(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only what differs is changed, and all of it at once, so a refusal leaves the user as they were
+	change := &domain.UserUpdate{}
+	if payload.Role != nil && *payload.Role != user.Role {
+		change.Role = payload.Role
+	}
+	if payload.Active != nil && *payload.Active == user.Deactivated {
+		change.Active = payload.Active
+	}
+	if change.Role == nil && change.Active == nil {
+		return user.Info(), nil
 	}
 
-	// Generate JWT token
-	jwtToken, err := utils.TokenGenerate(&domain.AuthenticatedUser{
-		UserID:   user.UserID,
-		Username: user.Username,
-		Role:     user.Role,
-	}, uc.Environment.JwtSecret)
+	updated, err := uc.UserRepository.UpdateAccess(c, user.UserID, change)
+	if err != nil {
+		return nil, err
+	}
+	if change.Role != nil {
+		utils.Logger(c).Info("role changed", "username", updated.Username, "old_role", user.Role, "new_role", updated.Role)
+	}
+	if change.Active != nil {
+		if updated.Deactivated {
+			// The token version bump already locks out access tokens
+			if err := uc.RefreshTokenRepository.RevokeAllForUser(c, updated.UserID); err != nil {
+				return nil, err
+			}
+			utils.Logger(c).Warn("user deactivated", "username", updated.Username)
+		} else {
+			utils.Logger(c).Info("user reactivated", "username", updated.Username)
+		}
+	}
+	return updated.Info(), nil
+}
 
-	return jwtToken, err
+func (uc *UserUseCase) Unlock(c context.Context, username string) error {
+	user, err := uc.UserRepository.GetByUsername(c, username)
+	if err != nil {
+		return err
+	}
+	return uc.UserRepository.ResetFailedLogins(c, user.UserID)
 }
 
-func (uc *UserUseCase) Promote(c context.Context, username string) (*domain.UserInfo, error) {
-	_, err := uc.UserRepository.GetByUsername(c, username)
-    if err != nil {
-        return nil, err
-    }
+func (uc *UserUseCase) ChangePassword(c context.Context, user *domain.AuthenticatedUser, payload *domain.PasswordChange) (*domain.TokenPair, error) {
+	if err := validatePasswordChange(payload); err != nil {
+		return nil, err
+	}
 
-	user, err := uc.UserRepository.UpdateRole(c, username, "admin")
+	stored, err := uc.UserRepository.GetById(c, user.UserID)
 	if err != nil {
 		return nil, err
 	}
+	if err := utils.ComparePasswords(stored.Password, payload.CurrentPassword); err != nil {
+		return nil, domain.ErrWrongPassword
+	}
+
+	// Whoever knew the old password is logged out everywhere, the caller
+	// included, and the caller gets a fresh session in return
+	if err := uc.endSessions(c, stored.UserID, payload.NewPassword); err != nil {
+		return nil, err
+	}
+	utils.Logger(c).Info("password changed", "username", stored.Username)
+
+	stored, err = uc.UserRepository.GetById(c, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	familyId, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	return uc.issueTokens(c, stored, familyId)
+}
+
+func (uc *UserUseCase) RequestPasswordReset(c context.Context, payload *domain.PasswordResetRequest) error {
+	if err := validatePasswordResetRequest(payload); err != nil {
+		return err
+	}
+
+	user, err := uc.UserRepository.GetByEmail(c, payload.Email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		utils.Logger(c).Info("password reset requested for unknown address")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = uc.PasswordResetRepository.Create(c, &domain.PasswordResetToken{
+		UserID:    user.UserID,
+		TokenHash: utils.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(uc.Environment.PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.Logger(c).Info("password reset requested", "username", user.Username)
+	// a failure only known addresses can run into would tell which ones have an account
+	if err := uc.Mailer.Send(c, uc.resetMessage(user, token)); err != nil {
+		utils.Logger(c).Error("failed to send password reset email", "username", user.Username, "error", err)
+	}
+	return nil
+}
+
+func (uc *UserUseCase) ResetPassword(c context.Context, payload *domain.PasswordReset) error {
+	if err := validatePasswordReset(payload); err != nil {
+		return err
+	}
+
+	stored, err := uc.PasswordResetRepository.GetByHash(c, utils.HashToken(payload.Token))
+	if err != nil {
+		return err
+	}
+	if stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return domain.ErrInvalidResetToken
+	}
+
+	// Every reset token is single use, even when two resets race
+	used, err := uc.PasswordResetRepository.MarkUsed(c, stored.Id)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidResetToken
+	}
+
+	if err := uc.endSessions(c, stored.UserID, payload.NewPassword); err != nil {
+		return err
+	}
+	// Proving control of the mailbox is as good as an admin unlocking the account
+	if err := uc.UserRepository.ResetFailedLogins(c, stored.UserID); err != nil {
+		return err
+	}
+	utils.Logger(c).Info("password reset", "user_id", stored.UserID)
+	return nil
+}
+
+func (uc *UserUseCase) VerifyEmail(c context.Context, token string) error {
+	if token == "" {
+		invalid := &domain.ValidationError{}
+		invalid.Add("token", domain.CodeRequired, "token is required")
+		return invalid.Err()
+	}
+
+	userID, email, err := utils.ParseVerificationToken(token, uc.KeyRing)
+	if err != nil {
+		return domain.ErrInvalidVerificationToken
+	}
 
-	return &domain.UserInfo{
-		UserId:   user.UserID,
-		Username: user.Username,
-		Email:    user.Email,
+	user, err := uc.UserRepository.GetById(c, userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return domain.ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+	// Following the same link twice is fine
+	if user.EmailVerified && user.Email == email {
+		return nil
+	}
+
+	marked, err := uc.UserRepository.MarkEmailVerified(c, userID, email)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return domain.ErrInvalidVerificationToken
+	}
+	utils.Logger(c).Info("email verified", "username", user.Username)
+	return nil
+}
+
+func (uc *UserUseCase) ResendVerification(c context.Context, payload *domain.VerificationResendRequest) error {
+	if err := validateVerificationResendRequest(payload); err != nil {
+		return err
+	}
+
+	user, err := uc.UserRepository.GetByEmail(c, payload.Email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		utils.Logger(c).Info("verification requested for unknown address")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	return uc.sendVerification(c, user)
+}
+
+// sendVerification emails the user a link that verifies their address.
+func (uc *UserUseCase) sendVerification(c context.Context, user *domain.User) error {
+	token, err := utils.VerificationTokenGenerate(user.UserID, user.Email, uc.KeyRing, uc.Environment.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return uc.Mailer.Send(c, uc.verificationMessage(user, token))
+}
+
+// verificationMessage is the email carrying an email verification token, as
+// a link to EMAIL_VERIFICATION_URL when one is configured.
+func (uc *UserUseCase) verificationMessage(user *domain.User, token string) *domain.Message {
+	instructions := "To verify it, send this token to GET /auth/verify?token=\n\n" + token
+	if uc.Environment.EmailVerificationURL != "" {
+		instructions = "To verify it, open this link:\n\n" + withToken(uc.Environment.EmailVerificationURL, token)
+	}
+
+	return &domain.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm that this is your email address.\n\n%s\n\nThis expires in %s. If you did not create an account, ignore this email.\n",
+			user.Username, instructions, uc.Environment.EmailVerificationTTL),
+	}
+}
+
+// endSessions sets a new password and revokes every token issued before,
+// access tokens through the token version UpdatePassword bumps.
+func (uc *UserUseCase) endSessions(c context.Context, userID string, password string) error {
+	if err := uc.UserRepository.UpdatePassword(c, userID, password); err != nil {
+		return err
+	}
+	return uc.RefreshTokenRepository.RevokeAllForUser(c, userID)
+}
+
+// resetMessage is the email carrying a password reset token, as a link to
+// PASSWORD_RESET_URL when one is configured.
+func (uc *UserUseCase) resetMessage(user *domain.User, token string) *domain.Message {
+	instructions := "To choose a new password, send this token with your new password to POST /auth/reset:\n\n" + token
+	if uc.Environment.PasswordResetURL != "" {
+		instructions = "To choose a new password, open this link:\n\n" + withToken(uc.Environment.PasswordResetURL, token)
+	}
+
+	return &domain.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nSomeone, hopefully you, asked to reset the password of your account.\n\n%s\n\nThis expires in %s and works only once. If you did not ask for it, ignore this email and your password stays as it is.\n",
+			user.Username, instructions, uc.Environment.PasswordResetTTL),
+	}
+}
+
+// withToken adds the token to the query of a configured link.
+func withToken(rawURL string, token string) string {
+	link, _ := url.Parse(rawURL)
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// recordFailedLogin counts a wrong password against the user, locking the
+// account once the lockout policy says so.
+func (uc *UserUseCase) recordFailedLogin(c context.Context, user *domain.User, now time.Time) error {
+	failures, err := uc.UserRepository.RecordFailedLogin(c, user.UserID)
+	if err != nil {
+		return err
+	}
+
+	lockout := uc.Environment.LockoutAfter(failures)
+	if lockout == 0 {
+		return nil
+	}
+	until := now.Add(lockout)
+	utils.Logger(c).Warn("account locked", "username", user.Username, "failed_logins", failures, "locked_until", until)
+	return uc.UserRepository.LockUntil(c, user.UserID, until)
+}
+
+// issueTokens signs a short-lived access token and stores a new refresh token in the given family.
+func (uc *UserUseCase) issueTokens(c context.Context, user *domain.User, familyId string) (*domain.TokenPair, error) {
+	accessTTL := uc.Environment.AccessTokenTTL()
+	accessToken, err := utils.TokenGenerate(&domain.AuthenticatedUser{
+		UserID:       user.UserID,
+		Username:     user.Username,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+	}, uc.KeyRing, accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = uc.RefreshTokenRepository.Create(c, &domain.RefreshToken{
+		UserID:    user.UserID,
+		FamilyId:  familyId,
+		TokenHash: utils.HashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(uc.Environment.RefreshTokenTTL()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTTL.Seconds()),
 	}, nil
 }
+
+// hasActiveAdmin reports whether any admin has not been deactivated.
+func (uc *UserUseCase) hasActiveAdmin(c context.Context) (bool, error) {
+	admins, _, err := uc.UserRepository.List(c, &domain.UserQuery{Role: "admin"})
+	if err != nil {
+		return false, err
+	}
+	for _, admin := range admins {
+		if !admin.Deactivated {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -3,7 +3,11 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"task-manager-api-clean/config"
 	"task-manager-api-clean/domain"
@@ -11,28 +15,39 @@ import (
     "task-manager-api-clean/usecase"
 	"task-manager-api-clean/utils"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type UserUseCaseTestSuite struct {
     suite.Suite
-    repo    *mocks.UserRepository
-    useCase domain.UserUseCase
-    env     *config.Environment
+    repo        *mocks.UserRepository
+    refreshRepo *mocks.RefreshTokenRepository
+    resetRepo   *mocks.PasswordResetRepository
+    mailer      *mocks.Mailer
+    useCase     domain.UserUseCase
+    env         *config.Environment
+    keys        *utils.KeyRing
 }
 
 func (suite *UserUseCaseTestSuite) SetupTest() {
     suite.repo = new(mocks.UserRepository)
-	suite.env = &config.Environment{JwtSecret: "secret"}
-    suite.useCase = usecase.NewUserUseCase(suite.repo, suite.env)
+    suite.refreshRepo = new(mocks.RefreshTokenRepository)
+    suite.resetRepo = new(mocks.PasswordResetRepository)
+    suite.mailer = new(mocks.Mailer)
+    policy, err := domain.NewPolicy(map[string][]string{"auditor": {"task:read:any"}})
+    suite.Require().NoError(err)
+	suite.env = &config.Environment{JwtSecret: "secret", JwtExpiration: 600, PasswordResetTTL: time.Hour, EmailVerificationTTL: time.Hour}
+    suite.keys = utils.NewHMACKeyRing("secret")
+    suite.useCase = usecase.NewUserUseCase(suite.repo, suite.refreshRepo, suite.resetRepo, suite.mailer, suite.keys, policy, suite.env)
 
 }
 
 func (suite *UserUseCaseTestSuite) TestRegisterUser_Success() {
     payload := &domain.UserCreate{Username: "test", Password: "test", Email: "test@example.com"}
-    suite.repo.On("GetByUsername", mock.Anything, "test").Return(nil, errors.New("user not found"))
-    suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    suite.repo.On("Create", mock.Anything, mock.MatchedBy(func(user *domain.User) bool { return user.Role == "user" })).Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Return(nil)
 
     userInfo, err := suite.useCase.RegisterUser(context.Background(), payload)
     suite.NoError(err)
@@ -45,7 +60,6 @@ func (suite *UserUseCaseTestSuite) TestRegisterUser_Success() {
 	
 	suite.Equal(expectedUserInfo, userInfo)
     suite.Equal(expectedUserInfo, userInfo)
-    suite.repo.AssertCalled(suite.T(), "GetByUsername", mock.Anything, "test")
     suite.repo.AssertCalled(suite.T(), "Create", mock.Anything, mock.AnythingOfType("*domain.User"))
 }
 
@@ -53,26 +67,190 @@ func (suite *UserUseCaseTestSuite) TestRegisterUser_EmptyUsername() {
     payload := &domain.UserCreate{Username: "", Password: "test", Email: "test@example.com"}
 
     _, err := suite.useCase.RegisterUser(context.Background(), payload)
-    suite.EqualError(err, "invalid Payload")
+    suite.ErrorIs(err, domain.ErrValidation)
+}
+
+func (suite *UserUseCaseTestSuite) TestRegisterUser_InvalidFields() {
+    cases := []struct {
+        payload domain.UserCreate
+        field   string
+        code    string
+    }{
+        {domain.UserCreate{Username: "ab", Password: "test", Email: "test@example.com"}, "username", domain.CodeTooShort},
+        {domain.UserCreate{Username: "test user", Password: "test", Email: "test@example.com"}, "username", domain.CodeInvalidFormat},
+        {domain.UserCreate{Username: "test", Password: "", Email: "test@example.com"}, "password", domain.CodeRequired},
+        {domain.UserCreate{Username: "test", Password: "test", Email: "not-an-email"}, "email", domain.CodeInvalidFormat},
+        {domain.UserCreate{Username: "test", Password: "test", Email: "Test <test@example.com>"}, "email", domain.CodeInvalidFormat},
+    }
+
+    for _, tc := range cases {
+        _, err := suite.useCase.RegisterUser(context.Background(), &tc.payload)
+
+        var invalid *domain.ValidationError
+        suite.Require().ErrorAs(err, &invalid, tc.payload)
+        suite.Equal([]string{tc.field, tc.code}, []string{invalid.Fields[0].Field, invalid.Fields[0].Code}, tc.payload)
+    }
+    suite.repo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
 }
 
 func (suite *UserUseCaseTestSuite) TestRegisterUser_UserExists() {
     payload := &domain.UserCreate{Username: "test", Password: "test", Email: "test@example.com"}
-    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, domain.ErrUserExists)
+
+    _, err := suite.useCase.RegisterUser(context.Background(), payload)
+    suite.ErrorIs(err, domain.ErrUserExists)
+    suite.mailer.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestRegisterUser_EmailTaken() {
+    payload := &domain.UserCreate{Username: "test", Password: "test", Email: "test@example.com"}
+    suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, domain.ErrEmailTaken)
+
+    _, err := suite.useCase.RegisterUser(context.Background(), payload)
+    suite.ErrorIs(err, domain.ErrEmailTaken)
+    suite.ErrorIs(err, domain.ErrConflict)
+}
+
+func (suite *UserUseCaseTestSuite) TestBootstrapAdmin_SetupToken() {
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin"}).Return([]*domain.User{{UserID: "1", Username: "old", Role: "admin", Deactivated: true}}, "", nil)
+
+    // a deactivated admin administers nothing, so a token is logged
+    token := suite.bootstrap()
+    suite.NotEmpty(token)
+
+    suite.repo.On("Create", mock.Anything, mock.MatchedBy(func(user *domain.User) bool { return user.Role == "admin" })).Return(func(c context.Context, user *domain.User) (*domain.User, error) {
+        created := *user
+        created.UserID = "2"
+        return &created, nil
+    })
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+    setup := &domain.AdminSetup{UserCreate: domain.UserCreate{Username: "root", Password: "root", Email: "root@example.com"}}
+
+    setup.SetupToken = "wrong"
+    _, err := suite.useCase.Setup(context.Background(), setup)
+    suite.ErrorIs(err, domain.ErrInvalidSetupToken)
+
+    setup.SetupToken = token
+    admin, err := suite.useCase.Setup(context.Background(), setup)
+    suite.NoError(err)
+    suite.Equal("admin", admin.Role)
+
+    // the token works once
+    _, err = suite.useCase.Setup(context.Background(), setup)
+    suite.ErrorIs(err, domain.ErrInvalidSetupToken)
+    suite.repo.AssertNumberOfCalls(suite.T(), "Create", 1)
+}
+
+func (suite *UserUseCaseTestSuite) TestBootstrapAdmin_AdminExists() {
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin"}).Return([]*domain.User{{UserID: "1", Username: "boss", Role: "admin"}}, "", nil)
+
+    suite.Empty(suite.bootstrap())
+
+    // without a token logged there is nothing to set up with
+    _, err := suite.useCase.Setup(context.Background(), &domain.AdminSetup{UserCreate: domain.UserCreate{Username: "root", Password: "root", Email: "root@example.com"}})
+    suite.ErrorIs(err, domain.ErrInvalidSetupToken)
+    suite.repo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestSetup_Concurrent() {
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin"}).Return([]*domain.User{}, "", nil)
+    token := suite.bootstrap()
+    suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&domain.User{UserID: "2", Username: "root", Role: "admin"}, nil)
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+    // of many requests racing with the token, exactly one creates an admin
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            suite.useCase.Setup(context.Background(), &domain.AdminSetup{UserCreate: domain.UserCreate{Username: "root", Password: "root", Email: "root@example.com"}, SetupToken: token})
+        }()
+    }
+    wg.Wait()
+    suite.repo.AssertNumberOfCalls(suite.T(), "Create", 1)
+}
+
+func (suite *UserUseCaseTestSuite) TestSetup_Rejected() {
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin"}).Return([]*domain.User{}, "", nil).Once()
+    token := suite.bootstrap()
+
+    // invalid fields are refused before the token is looked at
+    _, err := suite.useCase.Setup(context.Background(), &domain.AdminSetup{UserCreate: domain.UserCreate{Username: "x"}, SetupToken: token})
+    suite.ErrorIs(err, domain.ErrValidation)
+
+    // a taken username leaves the token for another try
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin"}).Return([]*domain.User{}, "", nil).Once()
+    suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, domain.ErrUserExists).Once()
+    setup := &domain.AdminSetup{UserCreate: domain.UserCreate{Username: "root", Password: "root", Email: "root@example.com"}, SetupToken: token}
+    _, err = suite.useCase.Setup(context.Background(), setup)
+    suite.ErrorIs(err, domain.ErrUserExists)
+
+    // an admin made in the meantime, by another instance say, retires it
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin"}).Return([]*domain.User{{UserID: "1", Username: "boss", Role: "admin"}}, "", nil)
+    _, err = suite.useCase.Setup(context.Background(), setup)
+    suite.ErrorIs(err, domain.ErrInvalidSetupToken)
+    suite.repo.AssertNumberOfCalls(suite.T(), "Create", 1)
+}
+
+func (suite *UserUseCaseTestSuite) TestBootstrapAdmin_RepoError() {
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin"}).Return(nil, "", errors.New("database down"))
+
+    suite.EqualError(suite.useCase.BootstrapAdmin(context.Background()), "database down")
+}
+
+// bootstrap runs BootstrapAdmin and returns the setup token it logged, if any.
+func (suite *UserUseCaseTestSuite) bootstrap() string {
+    var logged strings.Builder
+    ctx := utils.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logged, nil)))
+    suite.Require().NoError(suite.useCase.BootstrapAdmin(ctx))
+
+    _, token, found := strings.Cut(logged.String(), "setup_token=")
+    if !found {
+        return ""
+    }
+    return strings.TrimSpace(token)
+}
+
+func (suite *UserUseCaseTestSuite) TestRegisterUser_SendsVerificationLink() {
+    suite.env.EmailVerificationURL = "https://api.example.com/auth/verify"
+    payload := &domain.UserCreate{Username: "test", Password: "test", Email: "test@example.com"}
+    suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    var sent *domain.Message
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+        sent = args.Get(1).(*domain.Message)
+    }).Return(nil)
 
     _, err := suite.useCase.RegisterUser(context.Background(), payload)
-    suite.EqualError(err, "user already exists")
+    suite.Require().NoError(err)
+
+    suite.Equal("test@example.com", sent.To)
+    suite.Contains(sent.Body, "https://api.example.com/auth/verify?token=")
+    token := sent.Body[strings.Index(sent.Body, "token=")+len("token="):]
+    token = token[:strings.IndexAny(token, "\n")]
+    userID, email, err := utils.ParseVerificationToken(token, suite.keys)
+    suite.NoError(err)
+    suite.Equal("1", userID)
+    suite.Equal("test@example.com", email)
+}
+
+func (suite *UserUseCaseTestSuite) TestRegisterUser_MailerErrorStillRegisters() {
+    payload := &domain.UserCreate{Username: "test", Password: "test", Email: "test@example.com"}
+    suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Return(errors.New("mail server down"))
+
+    userInfo, err := suite.useCase.RegisterUser(context.Background(), payload)
+    suite.NoError(err)
+    suite.Equal("1", userInfo.UserId)
 }
 
 func (suite *UserUseCaseTestSuite) TestRegisterUser_CreateRepoError() {
     payload := &domain.UserCreate{Username: "test", Password: "test", Email: "test@example.com"}
-    suite.repo.On("GetByUsername", mock.Anything, "test").Return(nil, errors.New("user not found"))
     suite.repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, errors.New("failed to create user"))
 
     _, err := suite.useCase.RegisterUser(context.Background(), payload)
     suite.EqualError(err, "failed to create user")
 
-    suite.repo.AssertCalled(suite.T(), "GetByUsername", mock.Anything, "test")
     suite.repo.AssertCalled(suite.T(), "Create", mock.Anything, mock.AnythingOfType("*domain.User"))
 }
 
@@ -83,10 +261,15 @@ func (suite *UserUseCaseTestSuite) TestLogin_Success() {
     
     suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword}, nil)
     suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{Username: "test", Password: hashedPassword}, nil)
+    suite.refreshRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *domain.RefreshToken) bool {
+        return token.UserID == "1" && token.FamilyId != "" && token.TokenHash != ""
+    })).Return(nil)
 
-    jwtToken, err := suite.useCase.Login(context.Background(), payload)
+    tokens, err := suite.useCase.Login(context.Background(), payload)
     suite.NoError(err)
-    suite.NotEmpty(jwtToken)
+    suite.NotEmpty(tokens.AccessToken)
+    suite.NotEmpty(tokens.RefreshToken)
+    suite.Equal(int64(600), tokens.ExpiresIn)
     suite.repo.AssertCalled(suite.T(), "GetByUsername", mock.Anything, "test")
 }
 
@@ -96,10 +279,87 @@ func (suite *UserUseCaseTestSuite) TestLogin_InvalidCredentials() {
     suite.NoError(err)
     
     suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword}, nil)
+    suite.repo.On("RecordFailedLogin", mock.Anything, "1").Return(1, nil)
 
     _, err = suite.useCase.Login(context.Background(), payload)
-    suite.EqualError(err, "invalid username or password")
+    suite.ErrorIs(err, domain.ErrInvalidCredentials)
+    suite.repo.AssertNotCalled(suite.T(), "LockUntil", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestLogin_LocksAccountAfterThreshold() {
+    suite.env.LockoutThreshold = 3
+    suite.env.LockoutDuration = time.Minute
+    suite.env.LockoutMaxDuration = time.Hour
+    hashedPassword, err := utils.EncryptPassword("hashedPassword")
+    suite.NoError(err)
+
+    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword, FailedLogins: 2}, nil)
+    suite.repo.On("RecordFailedLogin", mock.Anything, "1").Return(3, nil)
+    suite.repo.On("LockUntil", mock.Anything, "1", mock.MatchedBy(func(until time.Time) bool {
+        return until.After(time.Now().Add(59*time.Second)) && until.Before(time.Now().Add(61*time.Second))
+    })).Return(nil)
+
+    _, err = suite.useCase.Login(context.Background(), &domain.UserLogin{Username: "test", Password: "wrongpassword"})
+    suite.ErrorIs(err, domain.ErrInvalidCredentials)
+    suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUseCaseTestSuite) TestLogin_LockedAccountFailsWithoutCheckingPassword() {
+    hashedPassword, err := utils.EncryptPassword("hashedPassword")
+    suite.NoError(err)
+    lockedUntil := time.Now().Add(time.Minute)
+
+    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword, FailedLogins: 5, LockedUntil: &lockedUntil}, nil)
+
+    _, err = suite.useCase.Login(context.Background(), &domain.UserLogin{Username: "test", Password: "hashedPassword"})
+    suite.ErrorIs(err, domain.ErrInvalidCredentials)
+    suite.repo.AssertNotCalled(suite.T(), "RecordFailedLogin", mock.Anything, mock.Anything)
+    suite.refreshRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestLogin_SuccessResetsFailedLogins() {
+    hashedPassword, err := utils.EncryptPassword("hashedPassword")
+    suite.NoError(err)
+    lockedUntil := time.Now().Add(-time.Minute)
+
+    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword, FailedLogins: 5, LockedUntil: &lockedUntil}, nil)
+    suite.repo.On("ResetFailedLogins", mock.Anything, "1").Return(nil)
+    suite.refreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+    _, err = suite.useCase.Login(context.Background(), &domain.UserLogin{Username: "test", Password: "hashedPassword"})
+    suite.NoError(err)
+    suite.repo.AssertCalled(suite.T(), "ResetFailedLogins", mock.Anything, "1")
+}
+
+func (suite *UserUseCaseTestSuite) TestLogin_UnverifiedEmail() {
+    suite.env.RequireEmailVerification = true
+    hashedPassword, err := utils.EncryptPassword("hashedPassword")
+    suite.NoError(err)
+
+    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword}, nil)
+
+    _, err = suite.useCase.Login(context.Background(), &domain.UserLogin{Username: "test", Password: "hashedPassword"})
+    suite.ErrorIs(err, domain.ErrEmailNotVerified)
+    suite.refreshRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+
+    // A wrong password still fails as such
+    suite.repo.On("RecordFailedLogin", mock.Anything, "1").Return(1, nil)
+    _, err = suite.useCase.Login(context.Background(), &domain.UserLogin{Username: "test", Password: "wrongpassword"})
+    suite.ErrorIs(err, domain.ErrInvalidCredentials)
 }
+
+func (suite *UserUseCaseTestSuite) TestLogin_VerifiedEmail() {
+    suite.env.RequireEmailVerification = true
+    hashedPassword, err := utils.EncryptPassword("hashedPassword")
+    suite.NoError(err)
+
+    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword, EmailVerified: true}, nil)
+    suite.refreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+    _, err = suite.useCase.Login(context.Background(), &domain.UserLogin{Username: "test", Password: "hashedPassword"})
+    suite.NoError(err)
+}
+
 func (suite *UserUseCaseTestSuite) TestLogin_GetByUsernameRepoError() {
     payload := &domain.UserLogin{Username: "test", Password: "hashedPassword"}
     
@@ -113,36 +373,510 @@ func (suite *UserUseCaseTestSuite) TestLogin_GetByUsernameRepoError() {
 
 func (suite *UserUseCaseTestSuite) TestPromote_Success() {
     username := "testUser"
-    suite.repo.On("GetByUsername", mock.Anything, username).Return(&domain.User{UserID: "1", Username: username, Role: "user"}, nil)
     suite.repo.On("UpdateRole", mock.Anything, username, "admin").Return(&domain.User{UserID: "1", Username: username, Role: "admin"}, nil)
 
-    _, err := suite.useCase.Promote(context.Background(), username)
+    _, err := suite.useCase.Promote(context.Background(), username, "admin")
     suite.NoError(err)
-    
-    suite.repo.AssertCalled(suite.T(), "GetByUsername", mock.Anything, username)
+
     suite.repo.AssertCalled(suite.T(), "UpdateRole", mock.Anything, username, "admin")
+    suite.repo.AssertNotCalled(suite.T(), "GetByUsername", mock.Anything, mock.Anything)
 }
 
 func (suite *UserUseCaseTestSuite) TestPromote_UserNotFound() {
     username := "nonExistentUser"
-    suite.repo.On("GetByUsername", mock.Anything, username).Return(nil, errors.New("user not found"))
+    suite.repo.On("UpdateRole", mock.Anything, username, "admin").Return(nil, domain.ErrUserNotFound)
 
-    _, err := suite.useCase.Promote(context.Background(), username)
-    suite.EqualError(err, "user not found")
+    _, err := suite.useCase.Promote(context.Background(), username, "admin")
+    suite.ErrorIs(err, domain.ErrUserNotFound)
 }
 
 func (suite *UserUseCaseTestSuite) TestPromote_UpdateRoleRepoError() {
     username := "testUser"
-    suite.repo.On("GetByUsername", mock.Anything, username).Return(&domain.User{UserID: "1", Username: username, Role: "user"}, nil)
     suite.repo.On("UpdateRole", mock.Anything, username, "admin").Return(nil, errors.New("repository error"))
 
-    _, err := suite.useCase.Promote(context.Background(), username)
+    _, err := suite.useCase.Promote(context.Background(), username, "admin")
     suite.EqualError(err, "repository error")
-    
-    suite.repo.AssertCalled(suite.T(), "GetByUsername", mock.Anything, username)
+
     suite.repo.AssertCalled(suite.T(), "UpdateRole", mock.Anything, username, "admin")
 }
 
+func (suite *UserUseCaseTestSuite) TestPromote_CustomRole() {
+    username := "testuser"
+    suite.repo.On("UpdateRole", mock.Anything, username, "auditor").Return(&domain.User{UserID: "1", Username: username, Role: "auditor"}, nil)
+
+    _, err := suite.useCase.Promote(context.Background(), username, "auditor")
+    suite.NoError(err)
+}
+
+func (suite *UserUseCaseTestSuite) TestPromote_UnknownRole() {
+    _, err := suite.useCase.Promote(context.Background(), "testuser", "wizard")
+    suite.ErrorIs(err, domain.ErrUnknownRole)
+    suite.repo.AssertNotCalled(suite.T(), "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestRefresh_RotatesToken() {
+    stored := &domain.RefreshToken{Id: "rt1", UserID: "1", FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour)}
+    suite.refreshRepo.On("GetByHash", mock.Anything, utils.HashToken("refresh")).Return(stored, nil)
+    suite.refreshRepo.On("Revoke", mock.Anything, "rt1").Return(true, nil)
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Role: "user"}, nil)
+    suite.refreshRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *domain.RefreshToken) bool {
+        return token.FamilyId == "family" && token.TokenHash != utils.HashToken("refresh")
+    })).Return(nil)
+
+    tokens, err := suite.useCase.Refresh(context.Background(), &domain.RefreshRequest{RefreshToken: "refresh"})
+    suite.NoError(err)
+    suite.NotEqual("refresh", tokens.RefreshToken)
+    suite.refreshRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUseCaseTestSuite) TestRefresh_ReuseRevokesFamily() {
+    stored := &domain.RefreshToken{Id: "rt1", UserID: "1", FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour)}
+    suite.refreshRepo.On("GetByHash", mock.Anything, utils.HashToken("refresh")).Return(stored, nil)
+    suite.refreshRepo.On("Revoke", mock.Anything, "rt1").Return(false, nil)
+    suite.refreshRepo.On("RevokeFamily", mock.Anything, "family").Return(nil)
+
+    _, err := suite.useCase.Refresh(context.Background(), &domain.RefreshRequest{RefreshToken: "refresh"})
+    suite.ErrorIs(err, domain.ErrInvalidRefreshToken)
+    suite.refreshRepo.AssertCalled(suite.T(), "RevokeFamily", mock.Anything, "family")
+    suite.refreshRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestRefresh_Expired() {
+    stored := &domain.RefreshToken{Id: "rt1", UserID: "1", FamilyId: "family", ExpiresAt: time.Now().Add(-time.Minute)}
+    suite.refreshRepo.On("GetByHash", mock.Anything, utils.HashToken("refresh")).Return(stored, nil)
+
+    _, err := suite.useCase.Refresh(context.Background(), &domain.RefreshRequest{RefreshToken: "refresh"})
+    suite.ErrorIs(err, domain.ErrInvalidRefreshToken)
+    suite.refreshRepo.AssertNotCalled(suite.T(), "Revoke", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestLogout_RevokesFamily() {
+    stored := &domain.RefreshToken{Id: "rt1", UserID: "1", FamilyId: "family"}
+    suite.refreshRepo.On("GetByHash", mock.Anything, utils.HashToken("refresh")).Return(stored, nil)
+    suite.refreshRepo.On("RevokeFamily", mock.Anything, "family").Return(nil)
+
+    err := suite.useCase.Logout(context.Background(), &domain.LogoutRequest{RefreshToken: "refresh"})
+    suite.NoError(err)
+    suite.repo.AssertNotCalled(suite.T(), "IncrementTokenVersion", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestLogout_AllSessions() {
+    stored := &domain.RefreshToken{Id: "rt1", UserID: "1", FamilyId: "family"}
+    suite.refreshRepo.On("GetByHash", mock.Anything, utils.HashToken("refresh")).Return(stored, nil)
+    suite.refreshRepo.On("RevokeAllForUser", mock.Anything, "1").Return(nil)
+    suite.repo.On("IncrementTokenVersion", mock.Anything, "1").Return(nil)
+
+    err := suite.useCase.Logout(context.Background(), &domain.LogoutRequest{RefreshToken: "refresh", AllSessions: true})
+    suite.NoError(err)
+    suite.repo.AssertCalled(suite.T(), "IncrementTokenVersion", mock.Anything, "1")
+}
+
+func (suite *UserUseCaseTestSuite) TestUnlock_Success() {
+    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test"}, nil)
+    suite.repo.On("ResetFailedLogins", mock.Anything, "1").Return(nil)
+
+    err := suite.useCase.Unlock(context.Background(), "test")
+    suite.NoError(err)
+    suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUseCaseTestSuite) TestUnlock_UserNotFound() {
+    suite.repo.On("GetByUsername", mock.Anything, "ghost").Return(nil, domain.ErrUserNotFound)
+
+    err := suite.useCase.Unlock(context.Background(), "ghost")
+    suite.ErrorIs(err, domain.ErrUserNotFound)
+}
+
+func (suite *UserUseCaseTestSuite) TestChangePassword_Success() {
+    hashedPassword, err := utils.EncryptPassword("oldPassword")
+    suite.NoError(err)
+    user := &domain.AuthenticatedUser{UserID: "1", Username: "test", Role: "user"}
+
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword, TokenVersion: 1}, nil).Once()
+    suite.repo.On("UpdatePassword", mock.Anything, "1", "newPassword").Return(nil)
+    suite.refreshRepo.On("RevokeAllForUser", mock.Anything, "1").Return(nil)
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", TokenVersion: 2}, nil).Once()
+    suite.refreshRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+    tokens, err := suite.useCase.ChangePassword(context.Background(), user, &domain.PasswordChange{CurrentPassword: "oldPassword", NewPassword: "newPassword"})
+    suite.NoError(err)
+    suite.NotEmpty(tokens.AccessToken)
+
+    // The new access token carries the token version bumped by the change
+    claims := jwt.MapClaims{}
+    _, err = jwt.ParseWithClaims(tokens.AccessToken, claims, utils.NewHMACKeyRing("secret").Keyfunc)
+    suite.NoError(err)
+    suite.Equal(2.0, claims["ver"])
+    suite.repo.AssertExpectations(suite.T())
+    suite.refreshRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUseCaseTestSuite) TestChangePassword_WrongCurrentPassword() {
+    hashedPassword, err := utils.EncryptPassword("oldPassword")
+    suite.NoError(err)
+    user := &domain.AuthenticatedUser{UserID: "1", Username: "test", Role: "user"}
+
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword}, nil)
+
+    _, err = suite.useCase.ChangePassword(context.Background(), user, &domain.PasswordChange{CurrentPassword: "guess", NewPassword: "newPassword"})
+    suite.ErrorIs(err, domain.ErrWrongPassword)
+    suite.repo.AssertNotCalled(suite.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestChangePassword_MissingFields() {
+    _, err := suite.useCase.ChangePassword(context.Background(), &domain.AuthenticatedUser{UserID: "1"}, &domain.PasswordChange{})
+
+    var invalid *domain.ValidationError
+    suite.Require().ErrorAs(err, &invalid)
+    suite.Len(invalid.Fields, 2)
+}
+
+func (suite *UserUseCaseTestSuite) TestRequestPasswordReset_SendsToken() {
+    suite.env.PasswordResetURL = "https://app.example.com/reset?lang=en"
+    suite.repo.On("GetByEmail", mock.Anything, "test@example.com").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+
+    var stored *domain.PasswordResetToken
+    suite.resetRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+        stored = args.Get(1).(*domain.PasswordResetToken)
+    }).Return(nil)
+    var sent *domain.Message
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+        sent = args.Get(1).(*domain.Message)
+    }).Return(nil)
+
+    err := suite.useCase.RequestPasswordReset(context.Background(), &domain.PasswordResetRequest{Email: "test@example.com"})
+    suite.NoError(err)
+
+    suite.Equal("1", stored.UserID)
+    suite.WithinDuration(time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+    suite.Equal("test@example.com", sent.To)
+    suite.Contains(sent.Body, "https://app.example.com/reset?lang=en&token=")
+
+    // Only the hash of the token that was mailed is stored
+    token := sent.Body[strings.Index(sent.Body, "token=")+len("token="):]
+    token = token[:strings.IndexAny(token, "\n")]
+    suite.Equal(utils.HashToken(token), stored.TokenHash)
+}
+
+func (suite *UserUseCaseTestSuite) TestRequestPasswordReset_MailFails() {
+    suite.repo.On("GetByEmail", mock.Anything, "test@example.com").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    suite.resetRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Return(errors.New("smtp: connection refused"))
+
+    // answered like an unknown address, so the failure does not give the account away
+    err := suite.useCase.RequestPasswordReset(context.Background(), &domain.PasswordResetRequest{Email: "test@example.com"})
+    suite.NoError(err)
+}
+
+func (suite *UserUseCaseTestSuite) TestRequestPasswordReset_UnknownAddress() {
+    suite.repo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+    err := suite.useCase.RequestPasswordReset(context.Background(), &domain.PasswordResetRequest{Email: "nobody@example.com"})
+    suite.NoError(err)
+    suite.resetRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+    suite.mailer.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestResetPassword_Success() {
+    suite.resetRepo.On("GetByHash", mock.Anything, utils.HashToken("resetToken")).Return(&domain.PasswordResetToken{Id: "r1", UserID: "1", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+    suite.resetRepo.On("MarkUsed", mock.Anything, "r1").Return(true, nil)
+    suite.repo.On("UpdatePassword", mock.Anything, "1", "newPassword").Return(nil)
+    suite.refreshRepo.On("RevokeAllForUser", mock.Anything, "1").Return(nil)
+    suite.repo.On("ResetFailedLogins", mock.Anything, "1").Return(nil)
+
+    err := suite.useCase.ResetPassword(context.Background(), &domain.PasswordReset{Token: "resetToken", NewPassword: "newPassword"})
+    suite.NoError(err)
+    suite.repo.AssertExpectations(suite.T())
+    suite.refreshRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUseCaseTestSuite) TestResetPassword_Expired() {
+    suite.resetRepo.On("GetByHash", mock.Anything, utils.HashToken("resetToken")).Return(&domain.PasswordResetToken{Id: "r1", UserID: "1", ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+
+    err := suite.useCase.ResetPassword(context.Background(), &domain.PasswordReset{Token: "resetToken", NewPassword: "newPassword"})
+    suite.ErrorIs(err, domain.ErrInvalidResetToken)
+    suite.repo.AssertNotCalled(suite.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestResetPassword_AlreadyUsed() {
+    suite.resetRepo.On("GetByHash", mock.Anything, utils.HashToken("resetToken")).Return(&domain.PasswordResetToken{Id: "r1", UserID: "1", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+    suite.resetRepo.On("MarkUsed", mock.Anything, "r1").Return(false, nil)
+
+    err := suite.useCase.ResetPassword(context.Background(), &domain.PasswordReset{Token: "resetToken", NewPassword: "newPassword"})
+    suite.ErrorIs(err, domain.ErrInvalidResetToken)
+    suite.repo.AssertNotCalled(suite.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestVerifyEmail_Success() {
+    token, err := utils.VerificationTokenGenerate("1", "test@example.com", suite.keys, time.Hour)
+    suite.Require().NoError(err)
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    suite.repo.On("MarkEmailVerified", mock.Anything, "1", "test@example.com").Return(true, nil)
+
+    err = suite.useCase.VerifyEmail(context.Background(), token)
+    suite.NoError(err)
+    suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUseCaseTestSuite) TestVerifyEmail_AlreadyVerified() {
+    token, err := utils.VerificationTokenGenerate("1", "test@example.com", suite.keys, time.Hour)
+    suite.Require().NoError(err)
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com", EmailVerified: true}, nil)
+
+    err = suite.useCase.VerifyEmail(context.Background(), token)
+    suite.NoError(err)
+    suite.repo.AssertNotCalled(suite.T(), "MarkEmailVerified", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestVerifyEmail_AddressChanged() {
+    token, err := utils.VerificationTokenGenerate("1", "old@example.com", suite.keys, time.Hour)
+    suite.Require().NoError(err)
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Email: "new@example.com"}, nil)
+    suite.repo.On("MarkEmailVerified", mock.Anything, "1", "old@example.com").Return(false, nil)
+
+    err = suite.useCase.VerifyEmail(context.Background(), token)
+    suite.ErrorIs(err, domain.ErrInvalidVerificationToken)
+}
+
+func (suite *UserUseCaseTestSuite) TestVerifyEmail_InvalidToken() {
+    expired, err := utils.VerificationTokenGenerate("1", "test@example.com", suite.keys, -time.Minute)
+    suite.Require().NoError(err)
+    forged, err := utils.VerificationTokenGenerate("1", "test@example.com", utils.NewHMACKeyRing("other secret"), time.Hour)
+    suite.Require().NoError(err)
+    accessToken, err := utils.TokenGenerate(&domain.AuthenticatedUser{UserID: "1", Username: "test", Role: "user"}, suite.keys, time.Hour)
+    suite.Require().NoError(err)
+
+    for _, token := range []string{"garbage", expired, forged, accessToken} {
+        err := suite.useCase.VerifyEmail(context.Background(), token)
+        suite.ErrorIs(err, domain.ErrInvalidVerificationToken, token)
+    }
+    suite.repo.AssertNotCalled(suite.T(), "MarkEmailVerified", mock.Anything, mock.Anything, mock.Anything)
+
+    err = suite.useCase.VerifyEmail(context.Background(), "")
+    suite.ErrorIs(err, domain.ErrValidation)
+}
+
+func (suite *UserUseCaseTestSuite) TestResendVerification_SendsLink() {
+    suite.repo.On("GetByEmail", mock.Anything, "test@example.com").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    var sent *domain.Message
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+        sent = args.Get(1).(*domain.Message)
+    }).Return(nil)
+
+    err := suite.useCase.ResendVerification(context.Background(), &domain.VerificationResendRequest{Email: "test@example.com"})
+    suite.NoError(err)
+    suite.Equal("test@example.com", sent.To)
+    suite.Contains(sent.Body, "GET /auth/verify?token=")
+}
+
+func (suite *UserUseCaseTestSuite) TestResendVerification_NothingToSend() {
+    suite.repo.On("GetByEmail", mock.Anything, "verified@example.com").Return(&domain.User{UserID: "1", Username: "test", Email: "verified@example.com", EmailVerified: true}, nil)
+    suite.repo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+    for _, email := range []string{"verified@example.com", "nobody@example.com"} {
+        err := suite.useCase.ResendVerification(context.Background(), &domain.VerificationResendRequest{Email: email})
+        suite.NoError(err, email)
+    }
+    suite.mailer.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestLogin_Deactivated() {
+    hashedPassword, err := utils.EncryptPassword("hashedPassword")
+    suite.NoError(err)
+    suite.repo.On("GetByUsername", mock.Anything, "test").Return(&domain.User{UserID: "1", Username: "test", Password: hashedPassword, Deactivated: true}, nil)
+
+    _, err = suite.useCase.Login(context.Background(), &domain.UserLogin{Username: "test", Password: "hashedPassword"})
+    suite.ErrorIs(err, domain.ErrUserDeactivated)
+    suite.refreshRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestRefresh_Deactivated() {
+    stored := &domain.RefreshToken{Id: "rt1", UserID: "1", FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour)}
+    suite.refreshRepo.On("GetByHash", mock.Anything, utils.HashToken("refresh")).Return(stored, nil)
+    suite.refreshRepo.On("Revoke", mock.Anything, "rt1").Return(true, nil)
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Role: "user", Deactivated: true}, nil)
+
+    _, err := suite.useCase.Refresh(context.Background(), &domain.RefreshRequest{RefreshToken: "refresh"})
+    suite.ErrorIs(err, domain.ErrInvalidRefreshToken)
+    suite.refreshRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestList_Pages() {
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Role: "admin", Limit: 20}).Return([]*domain.User{{UserID: "1", Username: "root", Role: "admin", Password: "hash"}}, "1", nil)
+    suite.repo.On("List", mock.Anything, &domain.UserQuery{Limit: 100}).Return([]*domain.User{}, "", nil)
+
+    page, err := suite.useCase.List(context.Background(), &domain.UserQuery{Role: "admin"})
+    suite.NoError(err)
+    suite.Equal(&domain.UserPage{Items: []*domain.UserInfo{{UserId: "1", Username: "root", Role: "admin"}}, NextCursor: "1"}, page)
+
+    page, err = suite.useCase.List(context.Background(), &domain.UserQuery{Limit: 1000})
+    suite.NoError(err)
+    suite.Empty(page.Items)
+}
+
+func (suite *UserUseCaseTestSuite) TestList_UnknownRole() {
+    _, err := suite.useCase.List(context.Background(), &domain.UserQuery{Role: "wizard"})
+    suite.ErrorIs(err, domain.ErrUnknownRole)
+    suite.repo.AssertNotCalled(suite.T(), "List", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestProfile() {
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com", Role: "user", EmailVerified: true}, nil)
+
+    profile, err := suite.useCase.Profile(context.Background(), &domain.AuthenticatedUser{UserID: "1", Username: "test", Role: "user"})
+    suite.NoError(err)
+    suite.Equal(&domain.UserInfo{UserId: "1", Username: "test", Email: "test@example.com", EmailVerified: true, Role: "user"}, profile)
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdateProfile_ChangesEmail() {
+    caller := &domain.AuthenticatedUser{UserID: "1", Username: "test", Role: "user"}
+    email := "new@example.com"
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com", EmailVerified: true}, nil)
+    suite.repo.On("UpdateProfile", mock.Anything, "1", "test", email).Return(&domain.User{UserID: "1", Username: "test", Email: email}, nil)
+    var sent *domain.Message
+    suite.mailer.On("Send", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+        sent = args.Get(1).(*domain.Message)
+    }).Return(nil)
+
+    profile, tokens, err := suite.useCase.UpdateProfile(context.Background(), caller, &domain.UserProfileUpdate{Email: &email})
+    suite.NoError(err)
+    // the username stays, and so does the caller's access token
+    suite.Nil(tokens)
+    suite.Equal(email, profile.Email)
+    suite.False(profile.EmailVerified)
+    suite.Equal(email, sent.To)
+    suite.repo.AssertNotCalled(suite.T(), "GetByUsername", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdateProfile_RenameIssuesTokens() {
+    caller := &domain.AuthenticatedUser{UserID: "1", Username: "test", Role: "user", TokenVersion: 2}
+    username := "renamed"
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com", Role: "user", TokenVersion: 2}, nil)
+    suite.repo.On("UpdateProfile", mock.Anything, "1", username, "test@example.com").Return(&domain.User{UserID: "1", Username: username, Email: "test@example.com", Role: "user", TokenVersion: 3}, nil)
+    suite.refreshRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *domain.RefreshToken) bool {
+        return token.UserID == "1"
+    })).Return(nil)
+
+    profile, tokens, err := suite.useCase.UpdateProfile(context.Background(), caller, &domain.UserProfileUpdate{Username: &username})
+    suite.NoError(err)
+    suite.Equal(username, profile.Username)
+    suite.Require().NotNil(tokens)
+
+    // the new access token carries the new username and token version
+    claims := jwt.MapClaims{}
+    _, err = jwt.ParseWithClaims(tokens.AccessToken, claims, suite.keys.Keyfunc)
+    suite.NoError(err)
+    suite.Equal(username, claims["username"])
+    suite.Equal(3.0, claims["ver"])
+    suite.mailer.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdateProfile_Rejected() {
+    caller := &domain.AuthenticatedUser{UserID: "1", Username: "test", Role: "user"}
+    same, taken, takenEmail, invalid := "test", "taken", "taken@example.com", "x"
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "test", Email: "test@example.com"}, nil)
+    suite.repo.On("UpdateProfile", mock.Anything, "1", taken, "test@example.com").Return(nil, domain.ErrUserExists)
+    suite.repo.On("UpdateProfile", mock.Anything, "1", "test", takenEmail).Return(nil, domain.ErrEmailTaken)
+
+    cases := []struct {
+        payload domain.UserProfileUpdate
+        err     error
+    }{
+        {domain.UserProfileUpdate{}, domain.ErrUserUnchanged},
+        {domain.UserProfileUpdate{Username: &same}, domain.ErrUserUnchanged},
+        {domain.UserProfileUpdate{Username: &taken}, domain.ErrUserExists},
+        {domain.UserProfileUpdate{Email: &takenEmail}, domain.ErrEmailTaken},
+        {domain.UserProfileUpdate{Username: &invalid}, domain.ErrValidation},
+        {domain.UserProfileUpdate{Email: &invalid}, domain.ErrValidation},
+    }
+    for _, tc := range cases {
+        _, _, err := suite.useCase.UpdateProfile(context.Background(), caller, &tc.payload)
+        suite.ErrorIs(err, tc.err)
+    }
+    // only the taken username and address get as far as the repository
+    suite.repo.AssertNumberOfCalls(suite.T(), "UpdateProfile", 2)
+    suite.mailer.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdate_DemotesAndDeactivates() {
+    admin := &domain.AuthenticatedUser{UserID: "1", Username: "root", Role: "admin"}
+    role, active := "user", false
+    suite.repo.On("GetById", mock.Anything, "2").Return(&domain.User{UserID: "2", Username: "boss", Role: "admin"}, nil)
+    // both in one step, so a refusal cannot leave the user half changed
+    suite.repo.On("UpdateAccess", mock.Anything, "2", &domain.UserUpdate{Role: &role, Active: &active}).Return(&domain.User{UserID: "2", Username: "boss", Role: "user", Deactivated: true, TokenVersion: 1}, nil)
+    suite.refreshRepo.On("RevokeAllForUser", mock.Anything, "2").Return(nil)
+
+    user, err := suite.useCase.Update(context.Background(), admin, "2", &domain.UserUpdate{Role: &role, Active: &active})
+    suite.NoError(err)
+    suite.Equal("user", user.Role)
+    suite.True(user.Deactivated)
+    suite.repo.AssertExpectations(suite.T())
+    suite.refreshRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdate_Reactivates() {
+    admin := &domain.AuthenticatedUser{UserID: "1", Username: "root", Role: "admin"}
+    active := true
+    suite.repo.On("GetById", mock.Anything, "2").Return(&domain.User{UserID: "2", Username: "test", Role: "user", Deactivated: true}, nil)
+    suite.repo.On("UpdateAccess", mock.Anything, "2", &domain.UserUpdate{Active: &active}).Return(&domain.User{UserID: "2", Username: "test", Role: "user"}, nil)
+
+    user, err := suite.useCase.Update(context.Background(), admin, "2", &domain.UserUpdate{Active: &active})
+    suite.NoError(err)
+    suite.False(user.Deactivated)
+    suite.refreshRepo.AssertNotCalled(suite.T(), "RevokeAllForUser", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdate_OnlyWhatDiffers() {
+    admin := &domain.AuthenticatedUser{UserID: "1", Username: "root", Role: "admin"}
+    role, inactive, active := "manager", false, true
+    suite.repo.On("GetById", mock.Anything, "2").Return(&domain.User{UserID: "2", Username: "boss", Role: "manager"}, nil)
+    suite.repo.On("UpdateAccess", mock.Anything, "2", &domain.UserUpdate{Active: &inactive}).Return(&domain.User{UserID: "2", Username: "boss", Role: "manager", Deactivated: true, TokenVersion: 1}, nil)
+    suite.refreshRepo.On("RevokeAllForUser", mock.Anything, "2").Return(nil)
+
+    user, err := suite.useCase.Update(context.Background(), admin, "2", &domain.UserUpdate{Role: &role, Active: &inactive})
+    suite.NoError(err)
+    suite.True(user.Deactivated)
+
+    // nothing differs, so nothing is written
+    user, err = suite.useCase.Update(context.Background(), admin, "2", &domain.UserUpdate{Role: &role, Active: &active})
+    suite.NoError(err)
+    suite.Equal("manager", user.Role)
+    suite.repo.AssertNumberOfCalls(suite.T(), "UpdateAccess", 1)
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdate_LastAdmin() {
+    admin := &domain.AuthenticatedUser{UserID: "1", Username: "root", Role: "admin"}
+    active := false
+    suite.repo.On("GetById", mock.Anything, "1").Return(&domain.User{UserID: "1", Username: "root", Role: "admin"}, nil)
+    suite.repo.On("UpdateAccess", mock.Anything, "1", &domain.UserUpdate{Active: &active}).Return(nil, domain.ErrLastAdmin)
+
+    _, err := suite.useCase.Update(context.Background(), admin, "1", &domain.UserUpdate{Active: &active})
+    suite.ErrorIs(err, domain.ErrLastAdmin)
+    suite.refreshRepo.AssertNotCalled(suite.T(), "RevokeAllForUser", mock.Anything, mock.Anything)
+}
+
+func (suite *UserUseCaseTestSuite) TestUpdate_Rejected() {
+    admin := &domain.AuthenticatedUser{UserID: "1", Username: "root", Role: "admin"}
+    manager := &domain.AuthenticatedUser{UserID: "3", Username: "manager", Role: "manager"}
+    role, unknown, active := "user", "wizard", false
+
+    cases := []struct {
+        actor   *domain.AuthenticatedUser
+        payload domain.UserUpdate
+        err     error
+    }{
+        {admin, domain.UserUpdate{}, domain.ErrUserUnchanged},
+        {admin, domain.UserUpdate{Role: &unknown}, domain.ErrUnknownRole},
+        {manager, domain.UserUpdate{Role: &role}, domain.ErrUserForbidden},
+        {manager, domain.UserUpdate{Active: &active}, domain.ErrUserForbidden},
+    }
+    for _, tc := range cases {
+        _, err := suite.useCase.Update(context.Background(), tc.actor, "2", &tc.payload)
+        suite.ErrorIs(err, tc.err)
+    }
+    suite.repo.AssertNotCalled(suite.T(), "GetById", mock.Anything, mock.Anything)
+}
+
 func TestUserUseCaseTestSuite(t *testing.T) {
     suite.Run(t, new(UserUseCaseTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"task-manager-api-clean/domain"
+)
+
+// Limits on the fields of tasks and users.
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 5000
+	minUsernameLength    = 3
+	maxUsernameLength    = 32
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// validateTaskInput checks a task payload. A create needs a title and a due
+// date no earlier than today (UTC); an update may leave fields empty to keep
+// them as they are.
+func validateTaskInput(input *domain.TaskInput, creating bool, now time.Time) error {
+	invalid := &domain.ValidationError{}
+
+	title := strings.TrimSpace(input.Title)
+	switch {
+	case title == "" && (creating || input.Title != ""):
+		invalid.Add("title", domain.CodeRequired, "title must not be blank")
+	case utf8.RuneCountInString(title) > maxTitleLength:
+		invalid.Add("title", domain.CodeTooLong, fmt.Sprintf("title must be at most %d characters", maxTitleLength))
+	}
+
+	if utf8.RuneCountInString(input.Description) > maxDescriptionLength {
+		invalid.Add("description", domain.CodeTooLong, fmt.Sprintf("description must be at most %d characters", maxDescriptionLength))
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	if creating && !input.DueDate.IsZero() && input.DueDate.Before(today) {
+		invalid.Add("dueDate", domain.CodeInPast, "dueDate must not be in the past")
+	}
+
+	return invalid.Err()
+}
+
+// validateTaskReplacement checks the fields a task is left with by a PUT or a
+// patch. Unlike an update, nothing is left out: a title and status are required.
+func validateTaskReplacement(input *domain.TaskInput, now time.Time) error {
+	invalid := &domain.ValidationError{}
+	if err := validateTaskInput(input, false, now); err != nil {
+		invalid = err.(*domain.ValidationError)
+	}
+
+	// a title of blanks is already reported
+	if input.Title == "" {
+		invalid.Add("title", domain.CodeRequired, "title must not be blank")
+	}
+	if input.Status == "" {
+		invalid.Add("status", domain.CodeRequired, "status must not be blank")
+	}
+	return invalid.Err()
+}
+
+// validateUserCreate checks a registration payload.
+func validateUserCreate(payload *domain.UserCreate) error {
+	invalid := &domain.ValidationError{}
+	validateUsername(invalid, payload.Username)
+
+	if payload.Password == "" {
+		invalid.Add("password", domain.CodeRequired, "password is required")
+	}
+
+	validateEmail(invalid, payload.Email)
+
+	return invalid.Err()
+}
+
+// validateUserProfileUpdate checks a change to the caller's own account.
+func validateUserProfileUpdate(payload *domain.UserProfileUpdate) error {
+	invalid := &domain.ValidationError{}
+	if payload.Username != nil {
+		validateUsername(invalid, *payload.Username)
+	}
+	if payload.Email != nil {
+		validateEmail(invalid, *payload.Email)
+	}
+	return invalid.Err()
+}
+
+// validatePasswordChange checks a password change payload.
+func validatePasswordChange(payload *domain.PasswordChange) error {
+	invalid := &domain.ValidationError{}
+	if payload.CurrentPassword == "" {
+		invalid.Add("current_password", domain.CodeRequired, "current_password is required")
+	}
+	if payload.NewPassword == "" {
+		invalid.Add("new_password", domain.CodeRequired, "new_password is required")
+	}
+	return invalid.Err()
+}
+
+// validatePasswordResetRequest checks a request for a password reset token.
+func validatePasswordResetRequest(payload *domain.PasswordResetRequest) error {
+	invalid := &domain.ValidationError{}
+	validateEmail(invalid, payload.Email)
+	return invalid.Err()
+}
+
+// validatePasswordReset checks a password reset payload.
+func validatePasswordReset(payload *domain.PasswordReset) error {
+	invalid := &domain.ValidationError{}
+	if payload.Token == "" {
+		invalid.Add("token", domain.CodeRequired, "token is required")
+	}
+	if payload.NewPassword == "" {
+		invalid.Add("new_password", domain.CodeRequired, "new_password is required")
+	}
+	return invalid.Err()
+}
+
+// validateVerificationResendRequest checks a request for a new verification link.
+func validateVerificationResendRequest(payload *domain.VerificationResendRequest) error {
+	invalid := &domain.ValidationError{}
+	validateEmail(invalid, payload.Email)
+	return invalid.Err()
+}
+
+// validateEmail checks that an email address is given and is a bare address.
+func validateEmail(invalid *domain.ValidationError, email string) {
+	if email == "" {
+		invalid.Add("email", domain.CodeRequired, "email is required")
+	} else if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		invalid.Add("email", domain.CodeInvalidFormat, "email must be a valid email address")
+	}
+}
+
+// validateUsername checks the length and the characters of a username.
+func validateUsername(invalid *domain.ValidationError, username string) {
+	length := utf8.RuneCountInString(username)
+	switch {
+	case username == "":
+		invalid.Add("username", domain.CodeRequired, "username is required")
+	case length < minUsernameLength:
+		invalid.Add("username", domain.CodeTooShort, fmt.Sprintf("username must be at least %d characters", minUsernameLength))
+	case length > maxUsernameLength:
+		invalid.Add("username", domain.CodeTooLong, fmt.Sprintf("username must be at most %d characters", maxUsernameLength))
+	case !usernamePattern.MatchString(username):
+		invalid.Add("username", domain.CodeInvalidFormat, "username may only contain letters, digits, '.', '_' and '-'")
+	}
+}
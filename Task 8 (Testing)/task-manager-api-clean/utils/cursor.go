@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"task-manager-api-clean/domain"
+)
+
+// TaskCursor is the decoded form of the opaque cursor handed out as
+// TaskPage.NextCursor. It remembers the ordering it was issued for and the
+// sort key of the last task on the page, so the next page starts right after it.
+type TaskCursor struct {
+	SortBy   string `json:"s"`
+	SortDesc bool   `json:"d"`
+	Value    string `json:"v"`
+	Id       string `json:"id"`
+}
+
+func EncodeTaskCursor(task *domain.Task, query *domain.TaskQuery) string {
+	cursor := TaskCursor{SortBy: query.SortBy, SortDesc: query.SortDesc, Id: task.Id}
+	switch query.SortBy {
+	case domain.TaskSortTitle:
+		cursor.Value = task.Title
+	case domain.TaskSortStatus:
+		cursor.Value = task.Status
+	case domain.TaskSortDueDate:
+		cursor.Value = task.DueDate.UTC().Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeTaskCursor(encoded string) (*TaskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidTaskQuery)
+	}
+
+	var cursor TaskCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Id == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidTaskQuery)
+	}
+	return &cursor, nil
+}
+
+// Pivot rebuilds the last task of the previous page, holding only its id and
+// the field the cursor was sorted by.
+func (tc *TaskCursor) Pivot() (*domain.Task, error) {
+	pivot := &domain.Task{Id: tc.Id}
+	switch tc.SortBy {
+	case domain.TaskSortTitle:
+		pivot.Title = tc.Value
+	case domain.TaskSortStatus:
+		pivot.Status = tc.Value
+	case domain.TaskSortDueDate:
+		dueDate, err := time.Parse(time.RFC3339Nano, tc.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidTaskQuery)
+		}
+		pivot.DueDate = dueDate
+	}
+	return pivot, nil
+}
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// hmacKeyId is the kid of the shared JWT_SECRET key. Tokens issued before key
+// ids existed carry no kid at all and are checked against this key too.
+const hmacKeyId = "hs256"
+
+// SigningKey is one key of a KeyRing. Keys loaded from a public key PEM can
+// only verify tokens.
+type SigningKey struct {
+	Id     string
+	Method jwt.SigningMethod
+
+	private interface{}
+	public  interface{}
+}
+
+// CanSign reports whether the key holds the private half.
+func (key *SigningKey) CanSign() bool {
+	return key.private != nil
+}
+
+// NewHMACKey wraps a shared secret as an HS256 key.
+func NewHMACKey(secret string) *SigningKey {
+	return &SigningKey{
+		Id:      hmacKeyId,
+		Method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+}
+
+// ParseSigningKey reads an RSA or Ed25519 key, private or public, from PEM.
+// The key id is the RFC 7638 thumbprint of the public key, so the same file
+// always gets the same kid.
+func ParseSigningKey(pemBytes []byte) (*SigningKey, error) {
+	key := &SigningKey{}
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		key.Method, key.private, key.public = jwt.SigningMethodRS256, private, &private.PublicKey
+	} else if private, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes); err == nil {
+		key.Method, key.private, key.public = jwt.SigningMethodEdDSA, private, private.(ed25519.PrivateKey).Public()
+	} else if public, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes); err == nil {
+		key.Method, key.public = jwt.SigningMethodRS256, public
+	} else if public, err := jwt.ParseEdPublicKeyFromPEM(pemBytes); err == nil {
+		key.Method, key.public = jwt.SigningMethodEdDSA, public
+	} else {
+		return nil, errors.New("not an RSA or Ed25519 PEM key")
+	}
+
+	thumbprint := sha256.Sum256(key.jwk().thumbprintInput())
+	key.Id = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	return key, nil
+}
+
+// KeyRing signs access tokens with its active key and verifies them against
+// every key it holds, so a key can be rotated out without invalidating the
+// tokens it already signed.
+type KeyRing struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeyRing builds a ring that signs with active and also accepts tokens
+// signed by any of the verification keys.
+func NewKeyRing(active *SigningKey, verification ...*SigningKey) (*KeyRing, error) {
+	if active == nil || !active.CanSign() {
+		return nil, errors.New("the active key must be a private key")
+	}
+
+	ring := &KeyRing{active: active, keys: map[string]*SigningKey{active.Id: active}}
+	for _, key := range verification {
+		if _, exists := ring.keys[key.Id]; !exists {
+			ring.keys[key.Id] = key
+		}
+	}
+	return ring, nil
+}
+
+// NewHMACKeyRing is a ring holding only the shared HS256 secret.
+func NewHMACKeyRing(secret string) *KeyRing {
+	ring, _ := NewKeyRing(NewHMACKey(secret))
+	return ring
+}
+
+// LoadKeyRing builds the ring described by the configuration. Without a
+// signing key file tokens keep being signed with the shared secret; with one,
+// the secret (when set) stays on the ring for verification only so that
+// sessions started before the switch survive it.
+func LoadKeyRing(secret string, signingKeyFile string, verificationKeyFiles []string) (*KeyRing, error) {
+	if signingKeyFile == "" {
+		if secret == "" {
+			return nil, errors.New("either JWT_SECRET or JWT_SIGNING_KEY must be set")
+		}
+		return NewHMACKeyRing(secret), nil
+	}
+
+	active, err := loadSigningKey(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var verification []*SigningKey
+	for _, file := range verificationKeyFiles {
+		key, err := loadSigningKey(file)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+	if secret != "" {
+		verification = append(verification, NewHMACKey(secret))
+	}
+
+	return NewKeyRing(active, verification...)
+}
+
+func loadSigningKey(file string) (*SigningKey, error) {
+	pemBytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseSigningKey(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return key, nil
+}
+
+// Sign signs the claims with the active key and names it in the kid header.
+func (ring *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ring.active.Method, claims)
+	token.Header["kid"] = ring.active.Id
+	return token.SignedString(ring.active.private)
+}
+
+// Keyfunc picks the verification key for a token, for use with jwt.Parse.
+// The token's algorithm must match the key's, so a public key can never be
+// used as an HMAC secret.
+func (ring *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = hmacKeyId
+	}
+
+	key, ok := ring.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// Methods lists the algorithms of the keys on the ring.
+func (ring *KeyRing) Methods() []string {
+	seen := map[string]bool{}
+	methods := []string{}
+	for _, key := range ring.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWKS publishes the public halves of the asymmetric keys on the ring. The
+// shared secret is never part of it.
+func (ring *KeyRing) JWKS() *JSONWebKeySet {
+	set := &JSONWebKeySet{Keys: []JSONWebKey{}}
+	if jwk := ring.active.jwk(); jwk != nil {
+		set.Keys = append(set.Keys, *jwk)
+	}
+	for id, key := range ring.keys {
+		if id == ring.active.Id {
+			continue
+		}
+		if jwk := key.jwk(); jwk != nil {
+			set.Keys = append(set.Keys, *jwk)
+		}
+	}
+	return set
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json (RFC 7517).
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+func (key *SigningKey) jwk() *JSONWebKey {
+	jwk := &JSONWebKey{Kid: key.Id, Use: "sig", Alg: key.Method.Alg()}
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return nil
+	}
+	return jwk
+}
+
+// thumbprintInput is the canonical JSON of the required members, in
+// lexicographic order, as RFC 7638 specifies.
+func (jwk *JSONWebKey) thumbprintInput() []byte {
+	var members interface{}
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	raw, _ := json.Marshal(members)
+	return raw
+}
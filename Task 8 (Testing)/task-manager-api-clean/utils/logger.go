@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger, so code further down the
+// call chain logs with the same request attributes.
+func WithLogger(c context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(c, loggerKey{}, logger)
+}
+
+// Logger returns the logger carried by c, or the default logger.
+func Logger(c context.Context) *slog.Logger {
+	if c != nil {
+		if logger, ok := c.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
@@ -4,7 +4,7 @@ import (
 	"time"
     "task-manager-api-clean/domain"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -18,22 +18,21 @@ func ComparePasswords(hashedPassword string, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
-func TokenGenerate(auth *domain.AuthenticatedUser, secret string) (string, error) {
-    token := jwt.New(jwt.SigningMethodHS256)
-
+// TokenGenerate signs an access token for the user with the ring's active key.
+func TokenGenerate(auth *domain.AuthenticatedUser, keys *KeyRing, expiration time.Duration) (string, error) {
     // Set claims
-    claims := token.Claims.(jwt.MapClaims)
-    claims["username"] = auth.Username
-    claims["role"] = auth.Role
-    claims["user_id"] = auth.UserID
-    claims["exp"] = time.Now().Add(time.Hour * 24 * 7).Unix()
+    claims := jwt.MapClaims{
+        "username": auth.Username,
+        "role":     auth.Role,
+        "user_id":  auth.UserID,
+        "ver":      auth.TokenVersion,
+        "exp":      time.Now().Add(expiration).Unix(),
+    }
 
-    tokenString, err := token.SignedString([]byte(secret))
+    tokenString, err := keys.Sign(claims)
     if err != nil {
         return "", err
     }
 
     return tokenString, nil
-	
 }
-
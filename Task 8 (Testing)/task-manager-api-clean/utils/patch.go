@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"task-manager-api-clean/domain"
+)
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) to the JSON document doc:
+// members of the patch replace those of the document, objects are merged
+// recursively and a null removes the member.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: the merge patch is not valid JSON", domain.ErrInvalidPatch)
+	}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	merged, ok := target.(map[string]interface{})
+	if !ok {
+		merged = map[string]interface{}{}
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = mergePatch(merged[name], value)
+	}
+	return merged
+}
+
+// patchOperation is one operation of a JSON Patch. Value is nil when the
+// operation has none, and the JSON null when it is null.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies a JSON Patch (RFC 6902) to the JSON document doc. The
+// operations run in order and the document is only returned when all of them
+// succeed; a test operation that does not hold fails with
+// domain.ErrPatchTestFailed.
+func JSONPatch(doc []byte, patch []byte) ([]byte, error) {
+	var operations []patchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: a JSON patch must be an array of operations", domain.ErrInvalidPatch)
+	}
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		var err error
+		if target, err = applyOperation(target, operation); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyOperation(target interface{}, operation patchOperation) (interface{}, error) {
+	if operation.Path == nil {
+		return nil, fmt.Errorf("%w: %s needs a path", domain.ErrInvalidPatch, operation.Op)
+	}
+	path, err := parsePointer(*operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%w: %s needs a value", domain.ErrInvalidPatch, operation.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: the value is not valid JSON", domain.ErrInvalidPatch)
+		}
+		switch operation.Op {
+		case "add":
+			return addValue(target, path, value)
+		case "replace":
+			if target, _, err = removeValue(target, path); err != nil {
+				return nil, err
+			}
+			return addValue(target, path, value)
+		}
+		current, err := getValue(target, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s is not %s", domain.ErrPatchTestFailed, *operation.Path, operation.Value)
+		}
+		return target, nil
+	case "remove":
+		target, _, err = removeValue(target, path)
+		return target, err
+	case "move", "copy":
+		if operation.From == nil {
+			return nil, fmt.Errorf("%w: %s needs a from", domain.ErrInvalidPatch, operation.Op)
+		}
+		from, err := parsePointer(*operation.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if operation.Op == "move" {
+			if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+				return nil, fmt.Errorf("%w: cannot move %s into itself", domain.ErrInvalidPatch, *operation.From)
+			}
+			if target, value, err = removeValue(target, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = getValue(target, from); err != nil {
+				return nil, err
+			}
+			value = copyValue(value)
+		}
+		return addValue(target, path, value)
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", domain.ErrInvalidPatch, operation.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference
+// tokens. The empty pointer is the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q is not a JSON pointer", domain.ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getValue(target interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch node := target.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, missingPath(path[:i+1])
+			}
+			target = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			target = node[index]
+		default:
+			return nil, missingPath(path[:i+1])
+		}
+	}
+	return target, nil
+}
+
+// addValue returns target with value added at path, replacing an existing
+// object member or inserting into an array.
+func addValue(target interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(target, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index := len(node)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, missingPath(path)
+	})
+}
+
+// removeValue returns target without the value at path, and that value.
+func removeValue(target interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, target, nil
+	}
+	var removed interface{}
+	target, err := updateParent(target, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, missingPath(path)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index], node[index+1:]...), nil
+		}
+		return nil, missingPath(path)
+	})
+	return target, removed, err
+}
+
+// updateParent walks down to the container holding the last token of path
+// and replaces it with what change makes of it. Arrays change length, so
+// every container on the way is written back into its own parent.
+func updateParent(target interface{}, path []string, change func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(target, path[0])
+	}
+
+	child, err := getValue(target, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, path[1:], change)
+	if err != nil {
+		return nil, err
+	}
+	switch node := target.(type) {
+	case map[string]interface{}:
+		node[path[0]] = child
+	case []interface{}:
+		index, _ := arrayIndex(path[0], len(node)-1)
+		node[index] = child
+	}
+	return target, nil
+}
+
+// arrayIndex parses an array index of at most max.
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || strings.Trim(token, "0123456789") != "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: %q is not an array index", domain.ErrInvalidPatch, token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("%w: array index %d is out of range", domain.ErrInvalidPatch, index)
+	}
+	return index, nil
+}
+
+func missingPath(path []string) error {
+	escaped := make([]string, len(path))
+	for i, token := range path {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	}
+	return fmt.Errorf("%w: /%s does not exist", domain.ErrInvalidPatch, strings.Join(escaped, "/"))
+}
+
+// copyValue returns a deep copy of a decoded JSON value, so a copied value
+// and its source can change independently.
+func copyValue(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for name, member := range node {
+			copied[name] = copyValue(member)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(node))
+		for i, item := range node {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
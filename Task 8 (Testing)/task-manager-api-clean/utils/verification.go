@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// verificationPurpose tells verification tokens apart from access tokens,
+// which are signed with the same keys.
+const verificationPurpose = "email_verification"
+
+var errNotVerificationToken = errors.New("not an email verification token")
+
+// VerificationTokenGenerate signs a token vouching that whoever holds it can
+// read mail sent to the user's address. Nothing is stored, the signature
+// and the address in the token are all VerifyEmail needs.
+func VerificationTokenGenerate(userID string, email string, keys *KeyRing, expiration time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":     userID,
+		"email":   email,
+		"purpose": verificationPurpose,
+		"exp":     time.Now().Add(expiration).Unix(),
+	}
+	return keys.Sign(claims)
+}
+
+// ParseVerificationToken checks a token from VerificationTokenGenerate and
+// returns the user and address it was issued for.
+func ParseVerificationToken(token string, keys *KeyRing) (string, string, error) {
+	parsed, err := jwt.Parse(token, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", "", err
+	}
+
+	claims, _ := parsed.Claims.(jwt.MapClaims)
+	purpose, _ := claims["purpose"].(string)
+	userID, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	if purpose != verificationPurpose || userID == "" || email == "" {
+		return "", "", errNotVerificationToken
+	}
+	return userID, email, nil
+}